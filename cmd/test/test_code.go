@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bxcodec/faker/v4"
 	"github.com/go-resty/resty/v2"
 	"github.com/valyala/fasttemplate"
+
+	"wana/internal/model"
 )
 
 type APIClient struct {
@@ -65,7 +70,7 @@ func (c *APIClient) Register(userData map[string]interface{}) (string, error) {
 	var result struct {
 		ID string `json:"id"`
 	}
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	if err := decodeResponse(resp, &result); err != nil {
 		return "", err
 	}
 
@@ -87,7 +92,7 @@ func (c *APIClient) Login(username, password string) error {
 	var result struct {
 		Token string `json:"token"`
 	}
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	if err := decodeResponse(resp, &result); err != nil {
 		return err
 	}
 
@@ -96,11 +101,57 @@ func (c *APIClient) Login(username, password string) error {
 	return nil
 }
 
+// VerifyEmail подтверждает email по токену из письма
+func (c *APIClient) VerifyEmail(token string) error {
+	resp, err := c.client.R().
+		SetQueryParam("token", token).
+		Get("/auth/verify")
+
+	if err != nil {
+		return err
+	}
+
+	return checkStatus(resp)
+}
+
+var verificationLinkPattern = regexp.MustCompile(`/auth/verify\?token=(\S+)`)
+
+// VerificationToken достает токен из последнего письма с подтверждением email.
+// Письма читаются через отладочный маршрут /debug/mail, поэтому это работает только с сервером
+// на localhost без настоящего почтового сервиса. Письмо отправляется асинхронно, его ждем несколько попыток
+func (c *APIClient) VerificationToken(email string) (string, error) {
+	for attempt := 0; attempt < 10; attempt++ {
+		resp, err := c.client.R().
+			SetQueryParam("to", email).
+			Get("/debug/mail")
+
+		if err != nil {
+			return "", err
+		}
+
+		var mails []struct {
+			Body string `json:"body"`
+		}
+		if err := decodeResponse(resp, &mails); err != nil {
+			return "", err
+		}
+
+		for i := len(mails) - 1; i >= 0; i-- {
+			if match := verificationLinkPattern.FindStringSubmatch(mails[i].Body); match != nil {
+				return url.QueryUnescape(match[1])
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return "", fmt.Errorf("no verification email for %s", email)
+}
+
 func (c *APIClient) CreateWishlist(data map[string]interface{}) (string, error) {
 	resp, err := c.client.R().
 		SetBody(data).
 		SetAuthToken(c.token).
-		Post("/api/wishlists")
+		Post("/api/v1/wishlists")
 
 	if err != nil {
 		return "", err
@@ -109,7 +160,7 @@ func (c *APIClient) CreateWishlist(data map[string]interface{}) (string, error)
 	var result struct {
 		ID string `json:"id"`
 	}
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	if err := decodeResponse(resp, &result); err != nil {
 		return "", err
 	}
 
@@ -120,7 +171,7 @@ func (c *APIClient) AddWishlistItem(wishlistID string, data map[string]interface
 	resp, err := c.client.R().
 		SetBody(data).
 		SetAuthToken(c.token).
-		Post(fmt.Sprintf("/api/wishlists/%s/items", wishlistID))
+		Post(fmt.Sprintf("/api/v1/wishlists/%s/items", wishlistID))
 
 	if err != nil {
 		return "", err
@@ -129,14 +180,130 @@ func (c *APIClient) AddWishlistItem(wishlistID string, data map[string]interface
 	var result struct {
 		ID string `json:"id"`
 	}
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	if err := decodeResponse(resp, &result); err != nil {
 		return "", err
 	}
 
 	return result.ID, nil
 }
 
-func (c *APIClient) ShareWishlist(wishlistID string, user2Id string, canEdit bool) (string, error) {
+func (c *APIClient) GetWishlists() ([]model.WishlistSummary, error) {
+	resp, err := c.client.R().
+		SetAuthToken(c.token).
+		Get("/api/v1/wishlists")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.WishlistSummary
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *APIClient) GetWishlist(wishlistID string) (*model.Wishlist, error) {
+	resp, err := c.client.R().
+		SetAuthToken(c.token).
+		Get(fmt.Sprintf("/api/v1/wishlists/%s", wishlistID))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result model.Wishlist
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// UpdateWishlist сохраняет изменения списка. Версия берется из переданного списка,
+// поэтому обновлять нужно последнюю полученную с сервера копию
+func (c *APIClient) UpdateWishlist(wishlist model.Wishlist) (*model.Wishlist, error) {
+	resp, err := c.client.R().
+		SetBody(wishlist).
+		SetAuthToken(c.token).
+		SetHeader("If-Match", strconv.Itoa(wishlist.Version)).
+		Put(fmt.Sprintf("/api/v1/wishlists/%s", wishlist.ID))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result model.Wishlist
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *APIClient) DeleteWishlist(wishlistID string) error {
+	resp, err := c.client.R().
+		SetAuthToken(c.token).
+		Delete(fmt.Sprintf("/api/v1/wishlists/%s", wishlistID))
+
+	if err != nil {
+		return err
+	}
+
+	return checkStatus(resp)
+}
+
+func (c *APIClient) GetItems(wishlistID string) ([]model.Item, error) {
+	resp, err := c.client.R().
+		SetAuthToken(c.token).
+		Get(fmt.Sprintf("/api/v1/wishlists/%s/items", wishlistID))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result model.ItemPage
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Items, nil
+}
+
+// UpdateItem сохраняет изменения элемента с проверкой версии, как UpdateWishlist
+func (c *APIClient) UpdateItem(item model.Item) (*model.Item, error) {
+	resp, err := c.client.R().
+		SetBody(item).
+		SetAuthToken(c.token).
+		SetHeader("If-Match", strconv.Itoa(item.Version)).
+		Put(fmt.Sprintf("/api/v1/wishlists/%s/items/%s", item.WishlistID, item.ID))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result model.Item
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *APIClient) DeleteItem(wishlistID, itemID string) error {
+	resp, err := c.client.R().
+		SetAuthToken(c.token).
+		Delete(fmt.Sprintf("/api/v1/wishlists/%s/items/%s", wishlistID, itemID))
+
+	if err != nil {
+		return err
+	}
+
+	return checkStatus(resp)
+}
+
+func (c *APIClient) ShareWishlist(wishlistID string, user2Id string, canEdit bool) (*model.SharedWishlist, error) {
 	data := map[string]interface{}{
 		"can_edit":       canEdit,
 		"shared_user_id": user2Id,
@@ -145,13 +312,96 @@ func (c *APIClient) ShareWishlist(wishlistID string, user2Id string, canEdit boo
 	resp, err := c.client.R().
 		SetBody(data).
 		SetAuthToken(c.token).
-		Post(fmt.Sprintf("api/wishlists/%s/share", wishlistID))
+		Post(fmt.Sprintf("/api/v1/wishlists/%s/share", wishlistID))
 
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	var result model.SharedWishlist
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *APIClient) GetSharedWishlists() ([]model.SharedWishlistEntry, error) {
+	resp, err := c.client.R().
+		SetAuthToken(c.token).
+		Get("/api/v1/shared")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.SharedWishlistEntry
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// APIError - ответ сервера со статусом не из диапазона 2xx
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// Code, Message и Field берутся из тела ответа {"error", "code", "field"}
+	Code    string
+	Message string
+	Field   string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: status %d", e.Method, e.URL, e.StatusCode)
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Field != "" {
+		msg += " (field " + e.Field + ")"
+	}
+	return msg
+}
+
+// checkStatus возвращает *APIError, если сервер ответил статусом не из диапазона 2xx
+func checkStatus(resp *resty.Response) error {
+	if resp.IsSuccess() {
+		return nil
+	}
+
+	apiErr := &APIError{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL,
+		StatusCode: resp.StatusCode(),
+	}
+
+	var envelope struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+		Field string `json:"field"`
 	}
+	if err := json.Unmarshal(resp.Body(), &envelope); err == nil && envelope.Error != "" {
+		apiErr.Code = envelope.Code
+		apiErr.Message = envelope.Error
+		apiErr.Field = envelope.Field
+	} else {
+		// Тело не в стандартном формате, сохраняем его как есть
+		apiErr.Message = strings.TrimSpace(string(resp.Body()))
+	}
+	return apiErr
+}
 
-	return string(resp.Body()), nil
+// decodeResponse проверяет статус ответа и разбирает его тело
+func decodeResponse(resp *resty.Response, out interface{}) error {
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	return json.Unmarshal(resp.Body(), out)
 }
 
 func main() {
@@ -174,6 +424,16 @@ func main() {
 	}
 	fmt.Println("Successfully logged in")
 
+	// Делиться списками можно только с подтвержденным email
+	token, err := api.VerificationToken(userData["email"].(string))
+	if err != nil {
+		log.Fatalf("Failed to get verification token: %v", err)
+	}
+	if err := api.VerifyEmail(token); err != nil {
+		log.Fatalf("Email verification failed: %v", err)
+	}
+	fmt.Println("Email verified")
+
 	// 3. Создание списка желаний
 	wishlistData := generateWishlistData(userID)
 	wishlistID, err := api.CreateWishlist(wishlistData)
@@ -220,12 +480,68 @@ func main() {
 	}
 	fmt.Printf("Registered user: %s (%s)\n", userData2["username"], userID)
 
-	result, err = api.ShareWishlist(wishlistID, user2ID, false)
+	// 5. Чтение и изменение списка и его элементов
+	wishlist, err := api.GetWishlist(wishlistID)
+	if err != nil {
+		log.Fatalf("Failed to get wishlist: %v", err)
+	}
+
+	wishlist.Description = faker.Sentence()
+	wishlist, err = api.UpdateWishlist(*wishlist)
+	if err != nil {
+		log.Fatalf("Failed to update wishlist: %v", err)
+	}
+	fmt.Printf("Updated wishlist: %s (version %d)\n", wishlist.ID, wishlist.Version)
+
+	items, err := api.GetItems(wishlistID)
+	if err != nil {
+		log.Fatalf("Failed to get items: %v", err)
+	}
+	fmt.Printf("Wishlist has %d items\n", len(items))
+
+	if len(items) > 0 {
+		item := items[0]
+		item.IsPurchased = true
+		updated, err := api.UpdateItem(item)
+		if err != nil {
+			log.Fatalf("Failed to update item: %v", err)
+		}
+		fmt.Printf("Marked item as purchased: %s\n", updated.Name)
+
+		if err := api.DeleteItem(wishlistID, items[len(items)-1].ID); err != nil {
+			log.Fatalf("Failed to delete item: %v", err)
+		}
+		fmt.Printf("Deleted item: %s\n", items[len(items)-1].Name)
+	}
+
+	summaries, err := api.GetWishlists()
+	if err != nil {
+		log.Fatalf("Failed to get wishlists: %v", err)
+	}
+	for _, summary := range summaries {
+		fmt.Printf("Wishlist %s: %d items, %d purchased\n", summary.Title, summary.ItemCount, summary.PurchasedCount)
+	}
+
+	share, err := api.ShareWishlist(wishlistID, user2ID, false)
 	if err != nil {
 		log.Printf("Failed share wishlist: %v", err)
 		return
 	}
 
 	fmt.Println("\n=== Share Wishlist ===")
-	fmt.Println(result)
+	fmt.Printf("Shared wishlist %s with user %s (can edit: %t)\n", share.WishlistID, share.UserID, share.CanEdit)
+
+	// 6. Второй пользователь видит открытый ему список
+	api2 := NewAPIClient("http://localhost:8080")
+	if err := api2.Login(userData2["username"].(string), userData2["password"].(string)); err != nil {
+		log.Fatalf("Login failed: %v", err)
+	}
+
+	shared, err := api2.GetSharedWishlists()
+	if err != nil {
+		log.Fatalf("Failed to get shared wishlists: %v", err)
+	}
+	for _, entry := range shared {
+		fmt.Printf("Shared with second user: %s by %s (can edit: %t)\n", entry.Wishlist.Title, entry.Owner.Username, entry.CanEdit)
+	}
 }
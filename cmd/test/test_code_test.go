@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"wana/internal/model"
+)
+
+// fakeAPI - сервер с заготовленными ответами. Он запоминает запросы клиента,
+// чтобы тест мог проверить метод, путь, заголовки и тело
+type fakeAPI struct {
+	t         *testing.T
+	responses map[string]string
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+// newFakeAPI поднимает сервер, который на запрос "METHOD /path" отвечает телом из responses
+// со статусом 200. Запрос без заготовленного ответа получает 404 в стандартном формате ошибки
+func newFakeAPI(t *testing.T, responses map[string]string) (*fakeAPI, *APIClient) {
+	t.Helper()
+	api := &fakeAPI{t: t, responses: responses}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	client := NewAPIClient(server.URL)
+	client.token = "token-1"
+	return api, client
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	f.requests = append(f.requests, r)
+	f.bodies = append(f.bodies, string(body))
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	response, ok := f.responses[r.Method+" "+r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"error": "wishlist not found", "code": "NOT_FOUND"}`)
+		return
+	}
+	io.WriteString(w, response)
+}
+
+// last возвращает последний запрос и его тело
+func (f *fakeAPI) last() (*http.Request, string) {
+	f.t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.requests) == 0 {
+		f.t.Fatal("client sent no requests")
+	}
+	return f.requests[len(f.requests)-1], f.bodies[len(f.bodies)-1]
+}
+
+// expectRequest проверяет метод, путь и авторизацию последнего запроса
+func (f *fakeAPI) expectRequest(method, path string) (*http.Request, string) {
+	f.t.Helper()
+	req, body := f.last()
+	if req.Method != method || req.URL.Path != path {
+		f.t.Errorf("request = %s %s, want %s %s", req.Method, req.URL.Path, method, path)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer token-1" {
+		f.t.Errorf("%s %s: Authorization = %q", method, path, got)
+	}
+	return req, body
+}
+
+func TestClientReadsWishlists(t *testing.T) {
+	api, client := newFakeAPI(t, map[string]string{
+		"GET /api/v1/wishlists":          `[{"id": "w1", "title": "Birthday", "item_count": 3, "purchased_count": 1}]`,
+		"GET /api/v1/wishlists/w1":       `{"id": "w1", "user_id": "u1", "title": "Birthday", "version": 4}`,
+		"GET /api/v1/wishlists/w1/items": `{"items": [{"id": "i1", "wishlist_id": "w1", "name": "Lamp", "price": "10.00"}], "total": 1}`,
+		"GET /api/v1/shared":             `[{"wishlist": {"id": "w2", "title": "Group gift"}, "owner": {"id": "u2", "username": "bob"}, "can_edit": true}]`,
+	})
+
+	summaries, err := client.GetWishlists()
+	if err != nil {
+		t.Fatalf("GetWishlists: %v", err)
+	}
+	api.expectRequest(http.MethodGet, "/api/v1/wishlists")
+	if len(summaries) != 1 || summaries[0].Title != "Birthday" || summaries[0].ItemCount != 3 || summaries[0].PurchasedCount != 1 {
+		t.Errorf("GetWishlists = %+v", summaries)
+	}
+
+	wishlist, err := client.GetWishlist("w1")
+	if err != nil {
+		t.Fatalf("GetWishlist: %v", err)
+	}
+	api.expectRequest(http.MethodGet, "/api/v1/wishlists/w1")
+	if wishlist.ID != "w1" || wishlist.UserID != "u1" || wishlist.Version != 4 {
+		t.Errorf("GetWishlist = %+v", wishlist)
+	}
+
+	items, err := client.GetItems("w1")
+	if err != nil {
+		t.Fatalf("GetItems: %v", err)
+	}
+	api.expectRequest(http.MethodGet, "/api/v1/wishlists/w1/items")
+	if len(items) != 1 || items[0].Name != "Lamp" || items[0].Price != "10.00" {
+		t.Errorf("GetItems = %+v", items)
+	}
+
+	shared, err := client.GetSharedWishlists()
+	if err != nil {
+		t.Fatalf("GetSharedWishlists: %v", err)
+	}
+	api.expectRequest(http.MethodGet, "/api/v1/shared")
+	if len(shared) != 1 || shared[0].Wishlist.ID != "w2" || shared[0].Owner.Username != "bob" || !shared[0].CanEdit {
+		t.Errorf("GetSharedWishlists = %+v", shared)
+	}
+}
+
+func TestClientWritesWishlists(t *testing.T) {
+	api, client := newFakeAPI(t, map[string]string{
+		"PUT /api/v1/wishlists/w1":             `{"id": "w1", "title": "Birthday 30", "version": 5}`,
+		"DELETE /api/v1/wishlists/w1":          ``,
+		"PUT /api/v1/wishlists/w1/items/i1":    `{"id": "i1", "wishlist_id": "w1", "name": "Lamp", "is_purchased": true, "version": 3}`,
+		"DELETE /api/v1/wishlists/w1/items/i1": ``,
+		"POST /api/v1/wishlists/w1/share":      `{"id": "s1", "wishlist_id": "w1", "user_id": "u2", "can_edit": true}`,
+	})
+
+	// Обновления отправляют версию в If-Match, чтобы сервер отклонил устаревшую копию
+	updated, err := client.UpdateWishlist(model.Wishlist{ID: "w1", Title: "Birthday 30", Version: 4})
+	if err != nil {
+		t.Fatalf("UpdateWishlist: %v", err)
+	}
+	req, body := api.expectRequest(http.MethodPut, "/api/v1/wishlists/w1")
+	if req.Header.Get("If-Match") != "4" {
+		t.Errorf("UpdateWishlist If-Match = %q, want 4", req.Header.Get("If-Match"))
+	}
+	var sent model.Wishlist
+	if err := json.Unmarshal([]byte(body), &sent); err != nil || sent.Title != "Birthday 30" {
+		t.Errorf("UpdateWishlist body = %s", body)
+	}
+	if updated.Version != 5 {
+		t.Errorf("UpdateWishlist = %+v", updated)
+	}
+
+	item, err := client.UpdateItem(model.Item{ID: "i1", WishlistID: "w1", Name: "Lamp", IsPurchased: true, Version: 2})
+	if err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	req, _ = api.expectRequest(http.MethodPut, "/api/v1/wishlists/w1/items/i1")
+	if req.Header.Get("If-Match") != "2" || !item.IsPurchased || item.Version != 3 {
+		t.Errorf("UpdateItem If-Match %q, result %+v", req.Header.Get("If-Match"), item)
+	}
+
+	share, err := client.ShareWishlist("w1", "u2", true)
+	if err != nil {
+		t.Fatalf("ShareWishlist: %v", err)
+	}
+	_, body = api.expectRequest(http.MethodPost, "/api/v1/wishlists/w1/share")
+	var grant struct {
+		SharedUserID string `json:"shared_user_id"`
+		CanEdit      bool   `json:"can_edit"`
+	}
+	if err := json.Unmarshal([]byte(body), &grant); err != nil || grant.SharedUserID != "u2" || !grant.CanEdit {
+		t.Errorf("ShareWishlist body = %s", body)
+	}
+	if share.ID != "s1" || share.UserID != "u2" {
+		t.Errorf("ShareWishlist = %+v", share)
+	}
+
+	if err := client.DeleteItem("w1", "i1"); err != nil {
+		t.Fatalf("DeleteItem: %v", err)
+	}
+	api.expectRequest(http.MethodDelete, "/api/v1/wishlists/w1/items/i1")
+
+	if err := client.DeleteWishlist("w1"); err != nil {
+		t.Fatalf("DeleteWishlist: %v", err)
+	}
+	api.expectRequest(http.MethodDelete, "/api/v1/wishlists/w1")
+}
+
+func TestClientLoginUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"error": "invalid credentials", "code": "INVALID_CREDENTIALS"}`)
+	}))
+	t.Cleanup(server.Close)
+	client := NewAPIClient(server.URL)
+
+	err := client.Login("alice", "wrong-Password1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Login error = %v, want *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusUnauthorized || apiErr.Code != "INVALID_CREDENTIALS" || apiErr.Message != "invalid credentials" {
+		t.Errorf("Login error = %+v", apiErr)
+	}
+	if apiErr.Method != http.MethodPost || !strings.HasSuffix(apiErr.URL, "/auth/login") {
+		t.Errorf("Login error names request %s %s", apiErr.Method, apiErr.URL)
+	}
+	if !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "invalid credentials") {
+		t.Errorf("error text %q has no status or message", err.Error())
+	}
+	// Токен из тела ошибки не сохраняется
+	if client.token != "" {
+		t.Errorf("token after failed login = %q", client.token)
+	}
+}
+
+func TestClientErrorStatuses(t *testing.T) {
+	_, client := newFakeAPI(t, map[string]string{})
+
+	// Ответ в стандартном формате ошибки
+	_, err := client.GetWishlist("missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Code != "NOT_FOUND" || apiErr.Message != "wishlist not found" {
+		t.Errorf("GetWishlist error = %#v", err)
+	}
+
+	// Методы без тела ответа тоже проверяют статус
+	if err := client.DeleteWishlist("missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("DeleteWishlist error = %v", err)
+	}
+
+	// Тело не в стандартном формате попадает в сообщение как есть
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	t.Cleanup(server.Close)
+	_, err = NewAPIClient(server.URL).GetWishlists()
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway || apiErr.Message != "upstream unavailable" {
+		t.Errorf("GetWishlists error = %#v", err)
+	}
+}
@@ -0,0 +1,199 @@
+// Package model содержит структуры данных API, общие для сервера и клиента.
+// Теги json определяют формат обмена, поэтому менять их нужно вместе с обеими сторонами.
+package model
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// User - учетная запись в хранилище. Password содержит хэш bcrypt и никогда не сериализуется,
+// в ответах используется UserResponse.
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"-"`
+
+	EmailVerified bool   `json:"-"`
+	WebhookURL    string `json:"-"`
+	WebhookSecret string `json:"-"`
+}
+
+// RegisterRequest - тело запроса на регистрацию
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// UserResponse - публичные данные пользователя
+type UserResponse struct {
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+type Wishlist struct {
+	XMLName     xml.Name `json:"-" xml:"wishlist"`
+	ID          string   `json:"id" xml:"id"`
+	UserID      string   `json:"user_id" xml:"user_id"`
+	Title       string   `json:"title" xml:"title" binding:"required"`
+	Description string   `json:"description,omitempty" xml:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty" xml:"tags>tag"`
+	Archived    bool     `json:"archived,omitempty" xml:"archived,omitempty"`
+	// Закрытый владельцем список: элементы нельзя добавлять, менять, удалять и резервировать
+	Locked bool `json:"locked,omitempty" xml:"locked,omitempty"`
+	// Запрещает в списке два элемента с одинаковыми external_source и external_id
+	UniqueExternalIDs bool       `json:"unique_external_ids,omitempty" xml:"unique_external_ids,omitempty"`
+	CreatedAt         time.Time  `json:"created_at" xml:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" xml:"updated_at"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty"`
+	Version           int        `json:"version" xml:"version"`
+
+	// Счетчик изменений элементов списка, участвует в вычислении ETag
+	ItemChanges int64 `json:"-" xml:"-"`
+}
+
+// WishlistSummary - список в ответе GET /wishlists вместе со счетчиками элементов
+type WishlistSummary struct {
+	Wishlist
+	ItemCount      int `json:"item_count"`
+	PurchasedCount int `json:"purchased_count"`
+}
+
+// UserStats - сводка по данным пользователя в ответе GET /stats
+type UserStats struct {
+	Wishlists      int `json:"wishlists"`
+	Items          int `json:"items"`
+	PurchasedItems int `json:"purchased_items"`
+	RemainingItems int `json:"remaining_items"`
+	SharedWithMe   int `json:"shared_with_me"`
+	SharedByMe     int `json:"shared_by_me"`
+}
+
+// DeleteImpact - ответ на DELETE /wishlists/:id?dry_run=true: что будет удалено вместе со списком
+type DeleteImpact struct {
+	WishlistID  string `json:"wishlist_id"`
+	DryRun      bool   `json:"dry_run"`
+	Items       int    `json:"items"`
+	Shares      int    `json:"shares"`
+	PublicLinks int    `json:"public_links"`
+}
+
+// WishlistBatch - ответ на GET /wishlists?ids=...: доступные списки и ID, которые не удалось выдать
+type WishlistBatch struct {
+	Wishlists []WishlistSummary `json:"wishlists"`
+	Denied    []string          `json:"denied"`
+}
+
+// ImportResult - ответ на импорт списка
+type ImportResult struct {
+	ID        string `json:"id"`
+	ItemCount int    `json:"item_count"`
+	// Skipped - число элементов, отброшенных как повторы при dedupe=true
+	Skipped int `json:"skipped,omitempty"`
+}
+
+// BulkPurchaseResult - ответ на отметку всех элементов списка купленными или некупленными
+type BulkPurchaseResult struct {
+	WishlistID string `json:"wishlist_id"`
+	Updated    int    `json:"updated"`
+}
+
+type Item struct {
+	XMLName     xml.Name `json:"-" xml:"item"`
+	ID          string   `json:"id" xml:"id"`
+	WishlistID  string   `json:"wishlist_id" xml:"wishlist_id"`
+	Name        string   `json:"name" xml:"name" binding:"required"`
+	Description string   `json:"description,omitempty" xml:"description,omitempty"`
+	Price       string   `json:"price,omitempty" xml:"price,omitempty"`
+	Link        string   `json:"link,omitempty" xml:"link,omitempty"`
+	ImageURL    string   `json:"image_url,omitempty" xml:"image_url,omitempty"`
+	Category    string   `json:"category,omitempty" xml:"category,omitempty"`
+	IsPurchased bool     `json:"is_purchased,omitempty" xml:"is_purchased,omitempty"`
+	ReservedBy  string   `json:"reserved_by,omitempty" xml:"reserved_by,omitempty"`
+	Claimed     bool     `json:"claimed,omitempty" xml:"claimed,omitempty"`
+	// Идентификатор товара во внешнем магазине или сервисе отслеживания цен и название этого источника
+	ExternalID     string `json:"external_id,omitempty" xml:"external_id,omitempty"`
+	ExternalSource string `json:"external_source,omitempty" xml:"external_source,omitempty"`
+
+	// Кто добавил элемент: ID пользователя и его имя. Имя не хранится,
+	// а подставляется при выдаче списка элементов
+	AddedBy         string `json:"added_by,omitempty" xml:"added_by,omitempty"`
+	AddedByUsername string `json:"added_by_username,omitempty" xml:"added_by_username,omitempty"`
+
+	// Анонимный токен гостя, отметившего подарок по публичной ссылке
+	ClaimToken string    `json:"-" xml:"-"`
+	CreatedAt  time.Time `json:"created_at" xml:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" xml:"updated_at"`
+	Version    int       `json:"version" xml:"version"`
+}
+
+// ItemPage - страница элементов списка в ответе GET /wishlists/:id/items
+type ItemPage struct {
+	Items  []Item `json:"items"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// ItemGroupPage - страница элементов, сгруппированных по категории (group_by=category)
+type ItemGroupPage struct {
+	Groups map[string][]Item `json:"groups"`
+	Total  int               `json:"total"`
+	Limit  int               `json:"limit"`
+	Offset int               `json:"offset"`
+}
+
+// PublicWishlist - список в ответе GET /public/:token
+type PublicWishlist struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description,omitempty"`
+	Items       []PublicItem `json:"items"`
+}
+
+// PublicItem - элемент списка для гостя по публичной ссылке.
+// Кто зарезервировал или отметил подарок, не раскрывается.
+type PublicItem struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Price        string `json:"price,omitempty"`
+	Link         string `json:"link,omitempty"`
+	ImageURL     string `json:"image_url,omitempty"`
+	Category     string `json:"category,omitempty"`
+	IsPurchased  bool   `json:"is_purchased,omitempty"`
+	Reserved     bool   `json:"reserved,omitempty"`
+	Claimed      bool   `json:"claimed,omitempty"`
+	ClaimedByYou bool   `json:"claimed_by_you,omitempty"`
+}
+
+type SharedWishlist struct {
+	ID         string     `json:"id"`
+	WishlistID string     `json:"wishlist_id"`
+	UserID     string     `json:"user_id"`
+	CanEdit    bool       `json:"can_edit"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// SharedWishlistEntry - список, к которому пользователю открыт доступ, в ответе GET /shared
+type SharedWishlistEntry struct {
+	Wishlist Wishlist      `json:"wishlist"`
+	Owner    WishlistOwner `json:"owner"`
+	CanEdit  bool          `json:"can_edit"`
+	Favorite bool          `json:"favorite"`
+}
+
+// WishlistOwner - владелец списка в ответе GET /shared. Email владельца не раскрывается.
+type WishlistOwner struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// PublicUser - пользователь в ответе GET /users/search. Email не раскрывается.
+type PublicUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Пакет собирается без сервера и клиента: тест не импортирует ничего, кроме стандартной библиотеки
+func TestWireFormat(t *testing.T) {
+	created := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		value interface{}
+		want  string
+	}{
+		"user": {
+			User{ID: "u1", Username: "alice", Email: "alice@example.com", Password: "hash", EmailVerified: true, WebhookSecret: "secret"},
+			`{"id":"u1","username":"alice","email":"alice@example.com"}`,
+		},
+		"wishlist": {
+			Wishlist{ID: "w1", UserID: "u1", Title: "Birthday", Tags: []string{"gifts"}, CreatedAt: created, UpdatedAt: created, Version: 2, ItemChanges: 7},
+			`{"id":"w1","user_id":"u1","title":"Birthday","tags":["gifts"],"created_at":"2024-03-01T12:00:00Z","updated_at":"2024-03-01T12:00:00Z","version":2}`,
+		},
+		"shared wishlist": {
+			SharedWishlist{ID: "s1", WishlistID: "w1", UserID: "u2", CanEdit: true},
+			`{"id":"s1","wishlist_id":"w1","user_id":"u2","can_edit":true}`,
+		},
+	} {
+		raw, err := json.Marshal(tc.value)
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", name, err)
+		}
+		if string(raw) != tc.want {
+			t.Errorf("%s:\n got %s\nwant %s", name, raw, tc.want)
+		}
+	}
+}
+
+func TestItemWireFormat(t *testing.T) {
+	raw := `{"id":"i1","wishlist_id":"w1","name":"Lamp","price":"10.00","is_purchased":true,"reserved_by":"u2","version":3}`
+
+	var item Item
+	if err := json.Unmarshal([]byte(raw), &item); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if item.ID != "i1" || item.WishlistID != "w1" || item.Name != "Lamp" || item.Price != "10.00" ||
+		!item.IsPurchased || item.ReservedBy != "u2" || item.Version != 3 {
+		t.Errorf("decoded item = %+v", item)
+	}
+
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	for _, key := range []string{`"wishlist_id":"w1"`, `"is_purchased":true`, `"reserved_by":"u2"`, `"version":3`} {
+		if !strings.Contains(string(encoded), key) {
+			t.Errorf("encoded item %s has no %s", encoded, key)
+		}
+	}
+}
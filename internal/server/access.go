@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// Режим приватности: на запрос к чужому списку без доступа отвечаем 404, а не 403,
+// чтобы по ответам нельзя было узнать, существует ли список с таким ID.
+// Для отладки его можно выключить через PRIVACY_MODE=false.
+var privacyMode = getEnvBool("PRIVACY_MODE", true)
+
+// denyWishlistAccess отвечает на запрос к списку, на который у пользователя не хватает прав.
+// Вызывается под блокировкой mu.
+func (s *Server) denyWishlistAccess(c *gin.Context, userID string, wishlist model.Wishlist, message string) {
+	s.denyAccess(c, userID, wishlist, "wishlist not found", message)
+}
+
+// denyAccess отвечает 403 с сообщением forbidden, если пользователь может просматривать список
+// и о его существовании и так знает. Иначе в режиме приватности отвечает 404 с тем же
+// сообщением notFound, что и для несуществующего списка. Вызывается под блокировкой mu.
+func (s *Server) denyAccess(c *gin.Context, userID string, wishlist model.Wishlist, notFound, forbidden string) {
+	canView := wishlist.UserID == userID || s.hasSharedAccess(userID, wishlist.ID)
+	if privacyMode && !canView {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": forbidden})
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestForbiddenWishlistInBothModes(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	stranger := ts.addUser("stranger")
+	wishlist := ts.createWishlist(owner.ID, "Private")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	path := "/api/v1/wishlists/" + wishlist.ID
+
+	requests := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodGet, path, nil},
+		{http.MethodPut, path, gin.H{"title": "Mine", "version": wishlist.Version}},
+		{http.MethodDelete, path, nil},
+		{http.MethodGet, path + "/items", nil},
+		{http.MethodPost, path + "/items", gin.H{"name": "Mug"}},
+		{http.MethodPut, path + "/items/" + item.ID, item},
+		{http.MethodDelete, path + "/items/" + item.ID, nil},
+	}
+
+	for _, mode := range []struct {
+		privacy bool
+		status  int
+	}{{true, http.StatusNotFound}, {false, http.StatusForbidden}} {
+		usePrivacyMode(t, mode.privacy)
+
+		missing := ts.do(http.MethodGet, "/api/v1/wishlists/00000000-0000-0000-0000-000000000000", stranger.ID, nil)
+		for _, req := range requests {
+			rec := ts.do(req.method, req.path, stranger.ID, req.body)
+			if rec.Code != mode.status {
+				t.Errorf("privacy %v: %s %s status = %d, want %d", mode.privacy, req.method, req.path, rec.Code, mode.status)
+			}
+			// В режиме приватности ответ совпадает с ответом для несуществующего списка
+			if mode.privacy && req.method == http.MethodGet && req.path == path && rec.Body.String() != missing.Body.String() {
+				t.Errorf("forbidden body %s differs from missing body %s", rec.Body.String(), missing.Body.String())
+			}
+		}
+	}
+
+	if items := ts.items(owner.ID, wishlist.ID); len(items) != 1 || items[0].Name != "Lamp" {
+		t.Errorf("items after rejected requests = %+v", items)
+	}
+}
+
+func TestViewerWithoutEditRightsGetsForbidden(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	viewer := ts.addUser("viewer")
+	wishlist := ts.createWishlist(owner.ID, "Shared")
+	ts.share(owner.ID, wishlist.ID, viewer.ID, false)
+
+	// Гость и так знает о списке, поэтому ему сообщается об отсутствии прав даже в режиме приватности
+	for _, privacy := range []bool{true, false} {
+		usePrivacyMode(t, privacy)
+		expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID, viewer.ID, nil), http.StatusOK)
+		rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/items", viewer.ID, gin.H{"name": "Mug"})
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("privacy %v: viewer add item status = %d, want 403", privacy, rec.Code)
+		}
+	}
+}
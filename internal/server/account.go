@@ -0,0 +1,193 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) getAccount(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c.JSON(http.StatusOK, userResponse(s.users[userID]))
+}
+
+func (s *Server) updateAccount(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	var update struct {
+		Username *string `json:"username"`
+		Email    *string `json:"email"`
+	}
+
+	if err := bindStrictJSON(c, &update); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	if update.Username != nil {
+		username, err := normalizeUsername("username", *update.Username)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, validationError(err))
+			return
+		}
+		update.Username = &username
+	}
+	if update.Email != nil && strings.TrimSpace(*update.Email) == "" {
+		c.JSON(http.StatusBadRequest, validationError(&FieldError{Field: "email", Message: "must not be empty"}))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.users[userID]
+	username, email := user.Username, user.Email
+	if update.Username != nil {
+		username = *update.Username
+	}
+	if update.Email != nil {
+		email = *update.Email
+	}
+
+	if s.credentialsTaken(username, email, userID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "username or email already exists"})
+		return
+	}
+
+	emailChanged := email != user.Email
+	s.unindexUser(user)
+	user.Username = username
+	user.Email = email
+
+	// Новый email нужно подтвердить заново
+	if emailChanged {
+		user.EmailVerified = false
+	}
+	s.users[userID] = user
+	s.indexUser(user)
+
+	if emailChanged {
+		// Ссылки, отправленные на прежний адрес, больше не подтверждают email
+		s.revokeVerifications(userID)
+		if err := s.issueVerification(user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not issue verification token"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, userResponse(user))
+}
+
+func (s *Server) deleteAccount(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	var confirmation struct {
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := bindJSON(c, &confirmation); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	// Пароль проверяется вне блокировки, так как bcrypt работает медленно
+	passwordHash := s.passwordHashOf(userID)
+	if !checkPasswordHash(confirmation.Password, passwordHash) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "password is incorrect"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Пароль могли сменить, пока проверялся старый
+	if s.users[userID].Password != passwordHash {
+		c.JSON(http.StatusConflict, gin.H{"error": "password was changed concurrently, try again"})
+		return
+	}
+
+	// Удаляем все списки пользователя, включая находящиеся в корзине,
+	// вместе с их элементами и выданными доступами
+	for wishlistID, w := range s.wishlists {
+		if w.UserID == userID {
+			s.purgeWishlist(wishlistID)
+		}
+	}
+
+	// Удаляем доступы, выданные пользователю к чужим спискам
+	for shareID, share := range s.sharedWishlists {
+		if share.UserID == userID {
+			delete(s.sharedWishlists, shareID)
+		}
+	}
+
+	delete(s.favorites, userID)
+	delete(s.notifications, userID)
+
+	s.revokeVerifications(userID)
+
+	// Токен совпадает с ID пользователя, поэтому после удаления он перестает действовать
+	s.unindexUser(s.users[userID])
+	delete(s.users, userID)
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) changePassword(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	var request struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+
+	if err := bindStrictJSON(c, &request); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	// Проверяем текущий пароль до применения политики к новому.
+	// Хэширование выполняется вне блокировки, так как bcrypt работает медленно.
+	passwordHash := s.passwordHashOf(userID)
+	if !checkPasswordHash(request.CurrentPassword, passwordHash) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "current password is incorrect"})
+		return
+	}
+
+	if err := validatePassword("new_password", request.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	hashedPassword, err := hashPassword(request.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not hash password"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists || user.Password != passwordHash {
+		c.JSON(http.StatusConflict, gin.H{"error": "password was changed concurrently, try again"})
+		return
+	}
+
+	user.Password = hashedPassword
+	s.users[userID] = user
+
+	c.Status(http.StatusNoContent)
+}
+
+// passwordHashOf возвращает текущий хэш пароля пользователя
+func (s *Server) passwordHashOf(userID string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.users[userID].Password
+}
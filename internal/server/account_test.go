@@ -0,0 +1,183 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// login выполняет вход по имени пользователя или email
+func (ts *testServer) login(identifier, password string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	return ts.do(http.MethodPost, "/auth/login", "", gin.H{"identifier": identifier, "password": password})
+}
+
+func TestChangePasswordWrongCurrent(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.addUser("alice")
+
+	rec := ts.do(http.MethodPost, "/api/v1/account/password", user.ID, gin.H{
+		"current_password": "not-my-Password1",
+		"new_password":     "another-Password2",
+	})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	expectStatus(t, ts.login("alice", testPassword), http.StatusOK)
+}
+
+func TestChangePasswordWeakNew(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.addUser("alice")
+
+	for _, weak := range []string{"short1A", "alllowercaseletters"} {
+		rec := ts.do(http.MethodPost, "/api/v1/account/password", user.ID, gin.H{
+			"current_password": testPassword,
+			"new_password":     weak,
+		})
+		expectFieldError(t, rec, "new_password")
+	}
+}
+
+func TestChangePasswordThenLogin(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.addUser("alice")
+	const newPassword = "fresh-Password-42"
+
+	rec := ts.do(http.MethodPost, "/api/v1/account/password", user.ID, gin.H{
+		"current_password": testPassword,
+		"new_password":     newPassword,
+	})
+	expectStatus(t, rec, http.StatusNoContent)
+
+	expectStatus(t, ts.login("alice", testPassword), http.StatusUnauthorized)
+	expectStatus(t, ts.login("alice", newPassword), http.StatusOK)
+}
+
+func TestGetAccount(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.addUser("alice")
+
+	rec := ts.do(http.MethodGet, "/api/v1/account", user.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var profile model.UserResponse
+	decodeBody(t, rec, &profile)
+	want := model.UserResponse{ID: user.ID, Username: "alice", Email: "alice@example.com", EmailVerified: true}
+	if profile != want {
+		t.Errorf("profile = %+v, want %+v", profile, want)
+	}
+}
+
+func TestUpdateAccountUsername(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.addUser("alice")
+
+	rec := ts.do(http.MethodPatch, "/api/v1/account", user.ID, gin.H{"username": "alice_2"})
+	expectStatus(t, rec, http.StatusOK)
+
+	var profile model.UserResponse
+	decodeBody(t, rec, &profile)
+	if profile.Username != "alice_2" || !profile.EmailVerified {
+		t.Errorf("profile = %+v", profile)
+	}
+	expectStatus(t, ts.login("alice_2", testPassword), http.StatusOK)
+	expectStatus(t, ts.login("alice", testPassword), http.StatusUnauthorized)
+}
+
+func TestUpdateAccountEmailTaken(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	ts.addUser("bob")
+
+	rec := ts.do(http.MethodPatch, "/api/v1/account", alice.ID, gin.H{"email": "bob@example.com"})
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = ts.do(http.MethodGet, "/api/v1/account", alice.ID, nil)
+	var profile model.UserResponse
+	decodeBody(t, rec, &profile)
+	if profile.Email != "alice@example.com" {
+		t.Errorf("email changed to %q despite the conflict", profile.Email)
+	}
+}
+
+func TestEmailChangeInvalidatesOldVerification(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.register("account-old")
+	oldToken := ts.verificationToken("account-old@example.com")
+
+	rec := ts.do(http.MethodPatch, "/api/v1/account", user.ID, gin.H{"email": "account-new@example.com"})
+	expectStatus(t, rec, http.StatusOK)
+
+	// Ссылка, отправленная на прежний адрес, не подтверждает новый
+	rec = ts.do(http.MethodGet, "/auth/verify?token="+url.QueryEscape(oldToken), "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+	if ts.emailVerified(user.ID) {
+		t.Fatal("old token verified the new email")
+	}
+
+	newToken := ts.verificationToken("account-new@example.com")
+	rec = ts.do(http.MethodGet, "/auth/verify?token="+url.QueryEscape(newToken), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	// После повторной смены адреса подтверждение нужно заново, старый токен снова недействителен
+	rec = ts.do(http.MethodPatch, "/api/v1/account", user.ID, gin.H{"email": "account-third@example.com"})
+	expectStatus(t, rec, http.StatusOK)
+	if ts.emailVerified(user.ID) {
+		t.Fatal("changed email is still verified")
+	}
+	rec = ts.do(http.MethodGet, "/auth/verify?token="+url.QueryEscape(newToken), "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ts := newTestServer(t)
+	leaving := ts.addUser("leaving")
+	friend := ts.addUser("friend")
+
+	own := ts.createWishlist(leaving.ID, "Mine")
+	ts.addItem(leaving.ID, own.ID, gin.H{"name": "Bike"})
+	ts.share(leaving.ID, own.ID, friend.ID, false)
+
+	theirs := ts.createWishlist(friend.ID, "Theirs")
+	ts.addItem(friend.ID, theirs.ID, gin.H{"name": "Tent"})
+	ts.share(friend.ID, theirs.ID, leaving.ID, true)
+
+	rec := ts.do(http.MethodDelete, "/api/v1/account", leaving.ID, gin.H{"password": "wrong-Password1"})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = ts.do(http.MethodDelete, "/api/v1/account", leaving.ID, gin.H{"password": testPassword})
+	expectStatus(t, rec, http.StatusNoContent)
+
+	expectStatus(t, ts.login("leaving", testPassword), http.StatusUnauthorized)
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists", leaving.ID, nil), http.StatusUnauthorized)
+
+	ts.srv.mu.RLock()
+	for _, w := range ts.srv.wishlists {
+		if w.UserID == leaving.ID {
+			t.Errorf("wishlist %q of the deleted user remains", w.Title)
+		}
+	}
+	for _, item := range ts.srv.items {
+		if item.WishlistID == own.ID {
+			t.Errorf("item %q of the deleted user remains", item.Name)
+		}
+	}
+	for _, share := range ts.srv.sharedWishlists {
+		if share.UserID == leaving.ID || share.WishlistID == own.ID {
+			t.Errorf("share %+v remains", share)
+		}
+	}
+	ts.srv.mu.RUnlock()
+
+	// Список друга, открытый удаленному пользователю, не пострадал
+	if got := ts.items(friend.ID, theirs.ID); len(got) != 1 || got[0].Name != "Tent" {
+		t.Errorf("friend's items = %+v", got)
+	}
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+theirs.ID, friend.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Максимальное число хранимых событий на список, старые события вытесняются
+const maxAuditEventsPerWishlist = 500
+
+// Действия, записываемые в журнал
+const (
+	auditItemAdded      = "item.added"
+	auditItemUpdated    = "item.updated"
+	auditItemDeleted    = "item.deleted"
+	auditItemMoved      = "item.moved"
+	auditItemPurchased  = "item.purchased"
+	auditItemReserved   = "item.reserved"
+	auditItemUnreserved = "item.unreserved"
+	auditItemClaimed    = "item.claimed"
+	auditItemUnclaimed  = "item.unclaimed"
+	auditWishlistShared = "wishlist.shared"
+)
+
+type AuditEvent struct {
+	ID         string    `json:"id"`
+	WishlistID string    `json:"wishlist_id"`
+	ActorID    string    `json:"actor_id"`
+	Action     string    `json:"action"`
+	TargetID   string    `json:"target_id"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// recordAudit добавляет событие в журнал списка.
+// Вызывается под блокировкой mu.
+func (s *MemStore) recordAudit(wishlistID, actorID, action, targetID string) {
+	events := append(s.auditLog[wishlistID], AuditEvent{
+		ID:         uuid.New().String(),
+		WishlistID: wishlistID,
+		ActorID:    actorID,
+		Action:     action,
+		TargetID:   targetID,
+		Timestamp:  clock.Now(),
+	})
+	if len(events) > maxAuditEventsPerWishlist {
+		events = append([]AuditEvent(nil), events[len(events)-maxAuditEventsPerWishlist:]...)
+	}
+	s.auditLog[wishlistID] = events
+}
+
+func (s *Server) getAuditLog(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	// Журнал доступен владельцу и редакторам
+	if wishlist.UserID != userID && !s.hasEditAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	// Отдаем события от новых к старым
+	events := s.auditLog[wishlistID]
+	start, end := pageBounds(len(events), limit, offset)
+	page := make([]AuditEvent, 0, end-start)
+	for i := len(events) - 1 - start; i >= len(events)-end; i-- {
+		page = append(page, events[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": page,
+		"total":  len(events),
+		"limit":  limit,
+		"offset": offset,
+	})
+}
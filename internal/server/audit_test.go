@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditLogRecordsActors(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	editor := ts.addUser("editor")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	ts.share(owner.ID, wishlist.ID, editor.ID, true)
+
+	item := ts.addItem(editor.ID, wishlist.ID, gin.H{"name": "Drone"})
+	item.IsPurchased = true
+	rec := ts.do(http.MethodPut, "/api/v1/wishlists/"+wishlist.ID+"/items/"+item.ID, owner.ID, item)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/audit", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var log struct {
+		Events []AuditEvent `json:"events"`
+		Total  int          `json:"total"`
+	}
+	decodeBody(t, rec, &log)
+
+	// События идут от новых к старым
+	want := []struct{ action, actor string }{
+		{auditItemPurchased, owner.ID},
+		{auditItemUpdated, owner.ID},
+		{auditItemAdded, editor.ID},
+		{auditWishlistShared, owner.ID},
+	}
+	if len(log.Events) != len(want) || log.Total != len(want) {
+		t.Fatalf("events = %+v", log.Events)
+	}
+	for i, w := range want {
+		event := log.Events[i]
+		if event.Action != w.action || event.ActorID != w.actor {
+			t.Errorf("event %d = %s by %s, want %s by %s", i, event.Action, event.ActorID, w.action, w.actor)
+		}
+	}
+	if log.Events[0].TargetID != item.ID || log.Events[2].TargetID != item.ID {
+		t.Errorf("item events target %q and %q, want %q", log.Events[0].TargetID, log.Events[2].TargetID, item.ID)
+	}
+}
+
+func TestAuditLogHiddenFromViewers(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	viewer := ts.addUser("viewer")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	ts.share(owner.ID, wishlist.ID, viewer.ID, false)
+
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/audit", viewer.ID, nil)
+	expectStatus(t, rec, http.StatusForbidden)
+}
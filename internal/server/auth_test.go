@@ -0,0 +1,236 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"wana/internal/model"
+)
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	ts := newTestServer(t)
+	ts.addUser("alice")
+
+	for name, body := range map[string]gin.H{
+		"username": {"username": "alice", "email": "other@example.com", "password": testPassword},
+		"email":    {"username": "other", "email": "alice@example.com", "password": testPassword},
+	} {
+		rec := ts.do(http.MethodPost, "/auth/register", "", body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("duplicate %s: status = %d, want 400", name, rec.Code)
+		}
+	}
+}
+
+func TestUserIndexesFollowEmailChange(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+
+	rec := ts.do(http.MethodPatch, "/api/v1/account", alice.ID, gin.H{"email": "alice@new.example.com"})
+	expectStatus(t, rec, http.StatusOK)
+
+	ts.srv.mu.RLock()
+	_, oldIndexed := ts.srv.usersByEmail["alice@example.com"]
+	newID := ts.srv.usersByEmail["alice@new.example.com"]
+	ts.srv.mu.RUnlock()
+	if oldIndexed || newID != alice.ID {
+		t.Fatalf("email index: old present %v, new -> %q", oldIndexed, newID)
+	}
+
+	// Прежний адрес свободен, новый занят
+	expectStatus(t, ts.login("alice@new.example.com", testPassword), http.StatusOK)
+	expectStatus(t, ts.login("alice@example.com", testPassword), http.StatusUnauthorized)
+	rec = ts.do(http.MethodPost, "/auth/register", "", gin.H{"username": "bob", "email": "alice@new.example.com", "password": testPassword})
+	expectStatus(t, rec, http.StatusBadRequest)
+	rec = ts.do(http.MethodPost, "/auth/register", "", gin.H{"username": "bob", "email": "alice@example.com", "password": testPassword})
+	expectStatus(t, rec, http.StatusCreated)
+}
+
+func TestLoginIdentifiers(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+
+	for name, body := range map[string]gin.H{
+		"email":           {"identifier": "alice@example.com", "password": testPassword},
+		"username":        {"identifier": "alice", "password": testPassword},
+		"legacy username": {"username": "alice", "password": testPassword},
+	} {
+		rec := ts.do(http.MethodPost, "/auth/login", "", body)
+		if rec.Code != http.StatusOK {
+			t.Errorf("login by %s: status = %d", name, rec.Code)
+			continue
+		}
+		var resp struct {
+			Token string             `json:"token"`
+			User  model.UserResponse `json:"user"`
+		}
+		decodeBody(t, rec, &resp)
+		if resp.Token != alice.ID || resp.User.Username != "alice" {
+			t.Errorf("login by %s: response = %+v", name, resp)
+		}
+	}
+}
+
+func TestLoginErrorsDoNotRevealAccounts(t *testing.T) {
+	ts := newTestServer(t)
+	ts.addUser("alice")
+
+	unknown := ts.login("nobody@example.com", testPassword)
+	wrongPassword := ts.login("alice@example.com", "wrong-Password1")
+	expectStatus(t, unknown, http.StatusUnauthorized)
+	expectStatus(t, wrongPassword, http.StatusUnauthorized)
+	if unknown.Body.String() != wrongPassword.Body.String() {
+		t.Errorf("responses differ: %s vs %s", unknown.Body.String(), wrongPassword.Body.String())
+	}
+}
+
+func TestResponsesNeverContainPassword(t *testing.T) {
+	ts := newTestServer(t)
+	rec := ts.do(http.MethodPost, "/auth/register", "", gin.H{"username": "alice", "email": "alice@example.com", "password": testPassword})
+	expectStatus(t, rec, http.StatusCreated)
+	var user model.UserResponse
+	decodeBody(t, rec, &user)
+	viewer := ts.addUser("bob")
+
+	ts.srv.mu.RLock()
+	hash := ts.srv.users[user.ID].Password
+	ts.srv.mu.RUnlock()
+	if hash == "" || hash == testPassword {
+		t.Fatalf("stored password = %q, want a hash", hash)
+	}
+
+	expectNoPassword := func(name string, rec *httptest.ResponseRecorder) {
+		t.Helper()
+		body := rec.Body.String()
+		for _, secret := range []string{hash, testPassword, `"password"`} {
+			if strings.Contains(body, secret) {
+				t.Errorf("%s response contains %s: %s", name, secret, body)
+			}
+		}
+	}
+	expectNoPassword("register", rec)
+
+	rec = ts.login("alice", testPassword)
+	expectStatus(t, rec, http.StatusOK)
+	expectNoPassword("login", rec)
+
+	rec = ts.do(http.MethodGet, "/api/v1/account", user.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	expectNoPassword("get account", rec)
+
+	rec = ts.do(http.MethodPatch, "/api/v1/account", user.ID, gin.H{"username": "alice2"})
+	expectStatus(t, rec, http.StatusOK)
+	expectNoPassword("update account", rec)
+
+	rec = ts.do(http.MethodGet, "/api/v1/users/search?q=alice", viewer.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), user.ID) {
+		t.Fatalf("search did not find the user: %s", rec.Body.String())
+	}
+	expectNoPassword("search users", rec)
+}
+
+func TestUsernameCaseInsensitive(t *testing.T) {
+	ts := newTestServer(t)
+	rec := ts.do(http.MethodPost, "/auth/register", "", gin.H{"username": "Alice", "email": "alice@example.com", "password": testPassword})
+	expectStatus(t, rec, http.StatusCreated)
+	var alice model.UserResponse
+	decodeBody(t, rec, &alice)
+	if alice.Username != "Alice" {
+		t.Errorf("username = %q, want the display form Alice", alice.Username)
+	}
+
+	rec = ts.do(http.MethodPost, "/auth/register", "", gin.H{"username": "aLiCe", "email": "other@example.com", "password": testPassword})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	for _, identifier := range []string{"alice", "ALICE", " Alice "} {
+		rec := ts.login(identifier, testPassword)
+		expectStatus(t, rec, http.StatusOK)
+		var resp struct {
+			User model.UserResponse `json:"user"`
+		}
+		decodeBody(t, rec, &resp)
+		if resp.User.ID != alice.ID || resp.User.Username != "Alice" {
+			t.Errorf("login as %q: user = %+v", identifier, resp.User)
+		}
+	}
+}
+
+func TestInvalidUsernameRejected(t *testing.T) {
+	withoutRateLimits(t)
+	ts := newTestServer(t)
+	user := ts.addUser("alice")
+
+	for _, username := range []string{"al", strings.Repeat("a", 33), "alice smith", "alice!", "алиса", "alice@home"} {
+		rec := ts.do(http.MethodPost, "/auth/register", "", gin.H{"username": username, "email": "new@example.com", "password": testPassword})
+		expectFieldError(t, rec, "username")
+
+		rec = ts.do(http.MethodPatch, "/api/v1/account", user.ID, gin.H{"username": username})
+		expectFieldError(t, rec, "username")
+	}
+
+	for _, username := range []string{"bob", "bob_smith-2", strings.Repeat("b", 32)} {
+		rec := ts.do(http.MethodPost, "/auth/register", "", gin.H{"username": username, "email": username + "@example.com", "password": testPassword})
+		expectStatus(t, rec, http.StatusCreated)
+	}
+}
+
+// useBcryptCost задает стоимость хэширования паролей до конца теста
+func useBcryptCost(t *testing.T, cost int) {
+	t.Helper()
+	previous := bcryptCost
+	bcryptCost = cost
+	t.Cleanup(func() { bcryptCost = previous })
+}
+
+// passwordCost возвращает стоимость bcrypt хэша пароля пользователя в хранилище
+func (ts *testServer) passwordCost(userID string) int {
+	ts.t.Helper()
+	ts.srv.mu.RLock()
+	hash := ts.srv.users[userID].Password
+	ts.srv.mu.RUnlock()
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		ts.t.Fatalf("stored password is not a bcrypt hash: %v", err)
+	}
+	return cost
+}
+
+func TestLoginUpgradesPasswordHash(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	useBcryptCost(t, bcrypt.MinCost+1)
+
+	// Неверный пароль хэш не трогает
+	expectStatus(t, ts.login("alice", "wrong-Password1"), http.StatusUnauthorized)
+	if cost := ts.passwordCost(alice.ID); cost != bcrypt.MinCost {
+		t.Fatalf("cost after failed login = %d, want %d", cost, bcrypt.MinCost)
+	}
+
+	expectStatus(t, ts.login("alice", testPassword), http.StatusOK)
+	if cost := ts.passwordCost(alice.ID); cost != bcrypt.MinCost+1 {
+		t.Errorf("cost after login = %d, want %d", cost, bcrypt.MinCost+1)
+	}
+	expectStatus(t, ts.login("alice", testPassword), http.StatusOK)
+	expectStatus(t, ts.login("alice", "wrong-Password1"), http.StatusUnauthorized)
+}
+
+func TestLoginRehashDisabled(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	useBcryptCost(t, bcrypt.MinCost+1)
+	previous := rehashOnLogin
+	rehashOnLogin = false
+	t.Cleanup(func() { rehashOnLogin = previous })
+
+	expectStatus(t, ts.login("alice", testPassword), http.StatusOK)
+	if cost := ts.passwordCost(alice.ID); cost != bcrypt.MinCost {
+		t.Errorf("cost with rehash disabled = %d, want %d", cost, bcrypt.MinCost)
+	}
+}
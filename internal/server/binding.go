@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// BodyError - тело запроса не удалось разобрать как JSON.
+// Offset - позиция в байтах, на которой разбор остановился.
+type BodyError struct {
+	Message string
+	Offset  int64
+}
+
+func (e *BodyError) Error() string {
+	return e.Message
+}
+
+// bindJSON разбирает тело запроса и проверяет теги binding, как ShouldBindJSON,
+// но возвращает понятные клиенту ошибки вместо текста ошибок декодера:
+// *BodyError для синтаксических ошибок и *FieldError для поля неверного типа.
+func bindJSON(c *gin.Context, obj interface{}) error {
+	return decodeJSON(c, obj, false)
+}
+
+// bindStrictJSON работает как bindJSON, но отклоняет неизвестные поля,
+// чтобы опечатка в имени поля не приводила к молчаливой потере значения.
+// Ошибка для неизвестного поля возвращается как *FieldError.
+func bindStrictJSON(c *gin.Context, obj interface{}) error {
+	return decodeJSON(c, obj, true)
+}
+
+func decodeJSON(c *gin.Context, obj interface{}, strict bool) error {
+	if c.Request.Body == nil {
+		return errors.New("invalid request")
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return errors.New("could not read request body")
+	}
+
+	if err := checkJSONShape(body, maxJSONDepth, maxJSONArrayLength); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(obj); err != nil {
+		return jsonBindError(err, int64(len(body)))
+	}
+
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// checkJSONShape проходит по токенам тела, не создавая значений, и отклоняет JSON
+// с вложенностью глубже maxDepth или массивом длиннее maxArray элементов.
+// Синтаксические ошибки здесь не сообщаются: их с позицией вернет основной разбор.
+func checkJSONShape(body []byte, maxDepth, maxArray int64) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+
+	// Открытые массивы и объекты: число элементов массива или -1 для объекта
+	var open []int64
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+
+		if token == json.Delim(']') || token == json.Delim('}') {
+			open = open[:len(open)-1]
+			if len(open) == 0 {
+				return nil
+			}
+			continue
+		}
+
+		if n := len(open); n > 0 && open[n-1] >= 0 {
+			open[n-1]++
+			if open[n-1] > maxArray {
+				return &BodyError{
+					Message: "request body contains an array longer than " + strconv.FormatInt(maxArray, 10) + " elements",
+					Offset:  decoder.InputOffset(),
+				}
+			}
+		}
+
+		switch token {
+		case json.Delim('['):
+			open = append(open, 0)
+		case json.Delim('{'):
+			open = append(open, -1)
+		default:
+			if len(open) == 0 {
+				return nil
+			}
+			continue
+		}
+		if int64(len(open)) > maxDepth {
+			return &BodyError{
+				Message: "request body is nested deeper than " + strconv.FormatInt(maxDepth, 10) + " levels",
+				Offset:  decoder.InputOffset(),
+			}
+		}
+	}
+}
+
+// jsonBindError переводит ошибку декодера в ошибку для ответа клиенту.
+// size - размер тела, на нем разбор останавливается при обрыве данных.
+func jsonBindError(err error, size int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return &BodyError{Message: "request body is empty"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BodyError{Message: "request body is not valid JSON: unexpected end of input", Offset: size}
+	case errors.As(err, &syntaxErr):
+		return &BodyError{Message: "request body is not valid JSON", Offset: syntaxErr.Offset}
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return &BodyError{Message: "request body must be " + jsonTypeName(typeErr.Type), Offset: typeErr.Offset}
+		}
+		return &FieldError{Field: typeErr.Field, Message: "must be " + jsonTypeName(typeErr.Type)}
+	}
+
+	if field, ok := unknownField(err); ok {
+		return &FieldError{Field: field, Message: "unknown field"}
+	}
+	return err
+}
+
+// jsonTypeName описывает ожидаемый тип значения в терминах JSON
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// unknownField извлекает имя поля из ошибки DisallowUnknownFields.
+// encoding/json не экспортирует тип этой ошибки, поэтому разбирается ее текст.
+func unknownField(err error) (string, bool) {
+	const prefix = "json: unknown field "
+
+	msg := err.Error()
+	if !strings.HasPrefix(msg, prefix) {
+		return "", false
+	}
+
+	field, unquoteErr := strconv.Unquote(strings.TrimPrefix(msg, prefix))
+	if unquoteErr != nil {
+		return "", false
+	}
+	return field, true
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUnknownFieldsRejected(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+
+	for _, tc := range []struct {
+		method, path string
+		body         gin.H
+		field        string
+	}{
+		{http.MethodPost, "/api/v1/wishlists", gin.H{"titel": "Birthday"}, "titel"},
+		{http.MethodPut, "/api/v1/wishlists/" + wishlist.ID, gin.H{"title": "Home", "version": 1, "descripton": "typo"}, "descripton"},
+		{http.MethodPost, "/api/v1/wishlists/" + wishlist.ID + "/items", gin.H{"name": "Mug", "prise": "5"}, "prise"},
+		{http.MethodPut, "/api/v1/wishlists/" + wishlist.ID + "/items/" + item.ID, gin.H{"name": "Lamp", "version": 1, "purchased": true}, "purchased"},
+		{http.MethodPatch, "/api/v1/account", gin.H{"user_name": "renamed"}, "user_name"},
+	} {
+		rec := ts.do(tc.method, tc.path, owner.ID, tc.body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: status = %d, want 400", tc.method, tc.path, rec.Code)
+			continue
+		}
+		var body map[string]interface{}
+		decodeBody(t, rec, &body)
+		if body["field"] != tc.field {
+			t.Errorf("%s %s: field = %v, want %q", tc.method, tc.path, body["field"], tc.field)
+		}
+	}
+
+	// Ни один запрос с опечаткой ничего не изменил
+	stored := ts.items(owner.ID, wishlist.ID)
+	if len(stored) != 1 || stored[0].Version != 1 {
+		t.Errorf("items after rejected requests = %+v", stored)
+	}
+}
+
+func TestCorrectFieldsAccepted(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, gin.H{"title": "Birthday", "description": "30th"})
+	expectStatus(t, rec, http.StatusCreated)
+
+	// Поля, которые клиент получил в ответе, можно отправить обратно как есть
+	var created map[string]interface{}
+	decodeBody(t, rec, &created)
+	created["title"] = "Birthday party"
+	rec = ts.do(http.MethodPut, "/api/v1/wishlists/"+created["id"].(string), owner.ID, created)
+	expectStatus(t, rec, http.StatusOK)
+}
+
+// bodyError разбирает ответ 400 на некорректное тело
+func bodyError(t *testing.T, rec *httptest.ResponseRecorder) (message string, offset int64) {
+	t.Helper()
+	expectStatus(t, rec, http.StatusBadRequest)
+	var body struct {
+		Error  string `json:"error"`
+		Offset int64  `json:"offset"`
+	}
+	decodeBody(t, rec, &body)
+	// Текст ошибки декодера наружу не попадает
+	if strings.Contains(body.Error, "json:") {
+		t.Errorf("error leaks decoder details: %q", body.Error)
+	}
+	return body.Error, body.Offset
+}
+
+func TestMalformedJSONRejected(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+
+	for _, path := range []string{"/api/v1/wishlists", "/api/v1/wishlists/" + wishlist.ID + "/items"} {
+		truncated := `{"title": "Birth`
+		message, offset := bodyError(t, ts.do(http.MethodPost, path, owner.ID, truncated))
+		if !strings.HasPrefix(message, "request body is not valid JSON") || offset != int64(len(truncated)) {
+			t.Errorf("POST %s truncated: error %q at offset %d, want offset %d", path, message, offset, len(truncated))
+		}
+
+		message, offset = bodyError(t, ts.do(http.MethodPost, path, owner.ID, `{"name" "Lamp"}`))
+		if message != "request body is not valid JSON" || offset != 9 {
+			t.Errorf("POST %s missing colon: error %q at offset %d, want offset 9", path, message, offset)
+		}
+
+		message, _ = bodyError(t, ts.do(http.MethodPost, path, owner.ID, `["Lamp"]`))
+		if message != "request body must be an object" {
+			t.Errorf("POST %s array body: error %q", path, message)
+		}
+	}
+
+	message, _ := bodyError(t, ts.do(http.MethodPost, "/auth/login", "", ""))
+	if message != "request body is empty" {
+		t.Errorf("empty login body: error %q", message)
+	}
+}
+
+func TestJSONTypeMismatchNamesField(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+
+	for _, tc := range []struct {
+		path  string
+		body  string
+		field string
+		want  string
+	}{
+		{"/api/v1/wishlists", `{"title": 5}`, "title", "title: must be a string"},
+		{"/api/v1/wishlists", `{"title": "Home", "tags": "gifts"}`, "tags", "tags: must be an array"},
+		{"/api/v1/wishlists/" + wishlist.ID + "/items", `{"name": "Lamp", "is_purchased": "yes"}`, "is_purchased", "is_purchased: must be a boolean"},
+	} {
+		rec := ts.do(http.MethodPost, tc.path, owner.ID, tc.body)
+		expectFieldError(t, rec, tc.field)
+		var body map[string]interface{}
+		decodeBody(t, rec, &body)
+		if body["error"] != tc.want {
+			t.Errorf("POST %s %s: error %v, want %q", tc.path, tc.body, body["error"], tc.want)
+		}
+	}
+}
+
+// useJSONShapeLimits задает ограничения вложенности и длины массивов тела до конца теста
+func useJSONShapeLimits(t *testing.T, depth, arrayLength int64) {
+	t.Helper()
+	previousDepth, previousArray := maxJSONDepth, maxJSONArrayLength
+	maxJSONDepth, maxJSONArrayLength = depth, arrayLength
+	t.Cleanup(func() { maxJSONDepth, maxJSONArrayLength = previousDepth, previousArray })
+}
+
+func TestOverNestedJSONRejected(t *testing.T) {
+	useJSONShapeLimits(t, 4, 100)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+
+	nested := `{"title": "Birthday", "tags": ` + strings.Repeat("[", 10) + strings.Repeat("]", 10) + `}`
+	message, offset := bodyError(t, ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, nested))
+	// Разбор останавливается на пятом уровне, не дочитав тело
+	if message != "request body is nested deeper than 4 levels" || offset != int64(strings.Index(nested, "[")+4) {
+		t.Errorf("nested body: error %q at offset %d", message, offset)
+	}
+
+	patch := ts.newRequest(http.MethodPatch, "/api/v1/wishlists/"+wishlist.ID+"/items/"+item.ID, owner.ID, nested)
+	patch.Header.Set("Content-Type", "application/merge-patch+json")
+	if message, _ := bodyError(t, ts.serve(patch)); message != "request body is nested deeper than 4 levels" {
+		t.Errorf("nested merge patch: error %q", message)
+	}
+
+	// Вложенность в пределах лимита принимается
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, `{"title": "Flat", "tags": ["a"]}`)
+	expectStatus(t, rec, http.StatusCreated)
+}
+
+func TestOverLongArrayRejected(t *testing.T) {
+	useJSONShapeLimits(t, 32, 3)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	items := make([]gin.H, 4)
+	for i := range items {
+		items[i] = gin.H{"name": "Item"}
+	}
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/import", owner.ID, gin.H{"wishlist": gin.H{"title": "Imported"}, "items": items})
+	if message, _ := bodyError(t, rec); message != "request body contains an array longer than 3 elements" {
+		t.Errorf("long array: error %q", message)
+	}
+	if titles := wishlistTitles(t, ts, owner.ID, ""); len(titles) != 0 {
+		t.Errorf("wishlists after rejected import = %v", titles)
+	}
+
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/import", owner.ID, gin.H{"wishlist": gin.H{"title": "Imported"}, "items": items[:3]})
+	expectStatus(t, rec, http.StatusCreated)
+}
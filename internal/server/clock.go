@@ -0,0 +1,44 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock - источник текущего времени для обработчиков
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock возвращает системное время
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock - управляемые часы для тестов
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance сдвигает часы вперед на d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Часы, используемые обработчиками. В тестах подменяются на FakeClock.
+var clock Clock = realClock{}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFakeClockAdvance(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	fake := NewFakeClock(start)
+
+	fake.Advance(90 * time.Minute)
+	if got, want := fake.Now(), start.Add(90*time.Minute); !got.Equal(want) {
+		t.Errorf("Now() = %v, want %v", got, want)
+	}
+}
+
+func TestTimestampsComeFromClock(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	wishlist := ts.createWishlist(owner.ID, "Travel")
+	if !wishlist.CreatedAt.Equal(fake.Now()) || !wishlist.UpdatedAt.Equal(fake.Now()) {
+		t.Errorf("timestamps = %v / %v, want %v", wishlist.CreatedAt, wishlist.UpdatedAt, fake.Now())
+	}
+}
+
+func TestShareRejectedAfterExpiry(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	friend := ts.addUser("friend")
+	wishlist := ts.createWishlist(owner.ID, "Travel")
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/share", owner.ID, gin.H{
+		"shared_user_id": friend.ID,
+		"expires_at":     fake.Now().Add(time.Hour),
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID, friend.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	fake.Advance(time.Hour)
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID, friend.ID, nil)
+	if rec.Code == http.StatusOK {
+		t.Fatalf("expired share still grants access")
+	}
+}
+
+func TestVerificationTokenRejectedAfterTTL(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	user := ts.addUser("newcomer")
+
+	ts.srv.mu.Lock()
+	err := ts.srv.issueVerification(user)
+	var token string
+	for issued := range ts.srv.verifications {
+		token = issued
+	}
+	ts.srv.mu.Unlock()
+	if err != nil {
+		t.Fatalf("issue verification: %v", err)
+	}
+
+	fake.Advance(verificationTTL)
+	rec := ts.do(http.MethodGet, "/auth/verify?token="+token, "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
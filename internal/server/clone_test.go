@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// usePrivacyMode включает или выключает режим приватности до конца теста
+func usePrivacyMode(t *testing.T, enabled bool) {
+	t.Helper()
+	previous := privacyMode
+	privacyMode = enabled
+	t.Cleanup(func() { privacyMode = previous })
+}
+
+func clonePath(wishlistID string) string {
+	return "/api/v1/shared/" + wishlistID + "/clone"
+}
+
+func TestCloneSharedWishlist(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	template := ts.createWishlist(owner.ID, "Template")
+	lamp := ts.addItem(owner.ID, template.ID, gin.H{"name": "Lamp", "price": "10"})
+	ts.addItem(owner.ID, template.ID, gin.H{"name": "Book"})
+	lamp.IsPurchased = true
+	expectStatus(t, ts.do(http.MethodPut, "/api/v1/wishlists/"+template.ID+"/items/"+lamp.ID, owner.ID, lamp), http.StatusOK)
+	ts.share(owner.ID, template.ID, guest.ID, false)
+
+	rec := ts.do(http.MethodPost, clonePath(template.ID), guest.ID, nil)
+	expectStatus(t, rec, http.StatusCreated)
+	var clone model.Wishlist
+	decodeBody(t, rec, &clone)
+	if clone.ID == template.ID || clone.UserID != guest.ID || clone.Title != "Template" || clone.Version != 1 {
+		t.Fatalf("clone = %+v", clone)
+	}
+	if location := rec.Header().Get("Location"); location != "/api/v1/wishlists/"+clone.ID {
+		t.Errorf("Location = %q", location)
+	}
+
+	copies := ts.items(guest.ID, clone.ID)
+	if len(copies) != 2 {
+		t.Fatalf("clone has %d items, want 2", len(copies))
+	}
+	for _, item := range copies {
+		if item.ID == lamp.ID || item.IsPurchased || item.AddedBy != guest.ID {
+			t.Errorf("cloned item = %+v", item)
+		}
+	}
+
+	// Копия принадлежит гостю: он может ее менять, а исходный список не меняется
+	clone.Title = "My copy"
+	expectStatus(t, ts.do(http.MethodPut, "/api/v1/wishlists/"+clone.ID, guest.ID, clone), http.StatusOK)
+	if original := ts.items(owner.ID, template.ID); len(original) != 2 {
+		t.Errorf("template has %d items after clone", len(original))
+	}
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists/"+clone.ID, owner.ID, nil), http.StatusNotFound)
+}
+
+func TestCloneRequiresAccess(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	stranger := ts.addUser("stranger")
+	wishlist := ts.createWishlist(owner.ID, "Private")
+
+	// В режиме приватности чужой список неотличим от несуществующего
+	usePrivacyMode(t, true)
+	expectStatus(t, ts.do(http.MethodPost, clonePath(wishlist.ID), stranger.ID, nil), http.StatusNotFound)
+
+	usePrivacyMode(t, false)
+	expectStatus(t, ts.do(http.MethodPost, clonePath(wishlist.ID), stranger.ID, nil), http.StatusForbidden)
+
+	// Свой список копируется через duplicate
+	expectStatus(t, ts.do(http.MethodPost, clonePath(wishlist.ID), owner.ID, nil), http.StatusBadRequest)
+
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	if len(ts.srv.wishlists) != 1 {
+		t.Errorf("store has %d wishlists after rejected clones", len(ts.srv.wishlists))
+	}
+}
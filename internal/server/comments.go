@@ -0,0 +1,148 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"wana/internal/model"
+)
+
+// Максимальная длина комментария в символах
+const maxCommentLength = 1000
+
+type Comment struct {
+	ID        string    `json:"id"`
+	ItemID    string    `json:"item_id"`
+	AuthorID  string    `json:"author_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// findCommentTarget проверяет, что элемент принадлежит списку и пользователь имеет доступ к списку.
+// При ошибке ответ уже отправлен и возвращается false. Вызывается под блокировкой mu.
+func (s *Server) findCommentTarget(c *gin.Context, userID, wishlistID, itemID string) (model.Wishlist, bool) {
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return model.Wishlist{}, false
+	}
+
+	if wishlist.UserID != userID && !s.hasSharedAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return model.Wishlist{}, false
+	}
+
+	if _, err := s.getItemInWishlist(wishlistID, itemID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return model.Wishlist{}, false
+	}
+
+	return wishlist, true
+}
+
+func (s *Server) addComment(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+	itemID := c.Param("item_id")
+
+	var req struct {
+		Text string `json:"text" binding:"required"`
+	}
+	if err := bindStrictJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "comment text must not be empty", "field": "text"})
+		return
+	}
+	if utf8.RuneCountInString(text) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "comment text is too long", "field": "text"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, ok := s.findCommentTarget(c, userID, wishlistID, itemID)
+	if !ok {
+		return
+	}
+
+	comment := Comment{
+		ID:        uuid.New().String(),
+		ItemID:    itemID,
+		AuthorID:  userID,
+		Text:      text,
+		CreatedAt: clock.Now(),
+	}
+	s.comments[itemID] = append(s.comments[itemID], comment)
+
+	// Владельцу списка сообщаем о комментариях других пользователей
+	if wishlist.UserID != userID {
+		authorName := s.users[userID].Username
+		s.notify(wishlist.UserID, notificationCommentAdded, authorName+" commented on \""+s.items[itemID].Name+"\"")
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+func (s *Server) getComments(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+	itemID := c.Param("item_id")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.findCommentTarget(c, userID, wishlistID, itemID); !ok {
+		return
+	}
+
+	// Комментарии отдаются от старых к новым
+	result := make([]Comment, len(s.comments[itemID]))
+	copy(result, s.comments[itemID])
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *Server) deleteComment(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+	itemID := c.Param("item_id")
+	commentID := c.Param("comment_id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, ok := s.findCommentTarget(c, userID, wishlistID, itemID)
+	if !ok {
+		return
+	}
+
+	itemComments := s.comments[itemID]
+	for i, comment := range itemComments {
+		if comment.ID != commentID {
+			continue
+		}
+
+		// Удалить комментарий может его автор или владелец списка
+		if comment.AuthorID != userID && wishlist.UserID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the author or the wishlist owner can delete this comment"})
+			return
+		}
+
+		s.comments[itemID] = append(itemComments[:i:i], itemComments[i+1:]...)
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// commentsPath возвращает адрес комментариев элемента
+func commentsPath(item model.Item) string {
+	return "/api/v1/wishlists/" + item.WishlistID + "/items/" + item.ID + "/comments"
+}
+
+// addComment оставляет комментарий к элементу через API и возвращает его
+func (ts *testServer) addComment(token string, item model.Item, text string) Comment {
+	ts.t.Helper()
+	rec := ts.do(http.MethodPost, commentsPath(item), token, gin.H{"text": text})
+	expectStatus(ts.t, rec, http.StatusCreated)
+
+	var comment Comment
+	decodeBody(ts.t, rec, &comment)
+	return comment
+}
+
+func TestCommentsListedInOrder(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	friend := ts.addUser("friend")
+	wishlist := ts.createWishlist(owner.ID, "Group gift")
+	ts.share(owner.ID, wishlist.ID, friend.ID, false)
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Bike"})
+
+	first := ts.addComment(friend.ID, item, "  I can chip in  ")
+	if first.Text != "I can chip in" || first.AuthorID != friend.ID || first.ItemID != item.ID {
+		t.Errorf("comment = %+v", first)
+	}
+	fake.Advance(time.Minute)
+	ts.addComment(owner.ID, item, "Thanks!")
+
+	rec := ts.do(http.MethodGet, commentsPath(item), friend.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var comments []Comment
+	decodeBody(t, rec, &comments)
+	if len(comments) != 2 || comments[0].Text != "I can chip in" || comments[1].Text != "Thanks!" {
+		t.Fatalf("comments = %+v", comments)
+	}
+	if !comments[1].CreatedAt.After(comments[0].CreatedAt) {
+		t.Errorf("comments are not ordered by creation time: %+v", comments)
+	}
+}
+
+func TestCommentValidationAndAccess(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	stranger := ts.addUser("stranger")
+	wishlist := ts.createWishlist(owner.ID, "Group gift")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Bike"})
+
+	for name, text := range map[string]string{
+		"blank":    "   ",
+		"too long": strings.Repeat("a", maxCommentLength+1),
+	} {
+		rec := ts.do(http.MethodPost, commentsPath(item), owner.ID, gin.H{"text": text})
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s comment: status = %d, want 400", name, rec.Code)
+		}
+	}
+
+	// Без доступа к списку комментарии не видны и не добавляются
+	expectStatus(t, ts.do(http.MethodGet, commentsPath(item), stranger.ID, nil), http.StatusNotFound)
+	expectStatus(t, ts.do(http.MethodPost, commentsPath(item), stranger.ID, gin.H{"text": "Hi"}), http.StatusNotFound)
+}
+
+func TestDeleteCommentAuthorization(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	author := ts.addUser("author")
+	other := ts.addUser("other")
+	wishlist := ts.createWishlist(owner.ID, "Group gift")
+	ts.share(owner.ID, wishlist.ID, author.ID, false)
+	ts.share(owner.ID, wishlist.ID, other.ID, true)
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Bike"})
+
+	byAuthor := ts.addComment(author.ID, item, "I can chip in")
+	forOwner := ts.addComment(author.ID, item, "Me too")
+
+	// Другой участник, даже с правом редактирования, чужой комментарий не удаляет
+	expectStatus(t, ts.do(http.MethodDelete, commentsPath(item)+"/"+byAuthor.ID, other.ID, nil), http.StatusForbidden)
+
+	expectStatus(t, ts.do(http.MethodDelete, commentsPath(item)+"/"+byAuthor.ID, author.ID, nil), http.StatusNoContent)
+	expectStatus(t, ts.do(http.MethodDelete, commentsPath(item)+"/"+forOwner.ID, owner.ID, nil), http.StatusNoContent)
+	expectStatus(t, ts.do(http.MethodDelete, commentsPath(item)+"/"+forOwner.ID, owner.ID, nil), http.StatusNotFound)
+
+	rec := ts.do(http.MethodGet, commentsPath(item), owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var comments []Comment
+	decodeBody(t, rec, &comments)
+	if len(comments) != 0 {
+		t.Errorf("comments after deletion = %+v", comments)
+	}
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"wana/internal/model"
+)
+
+// withoutRateLimits отключает ограничения частоты для роутеров, созданных до конца теста
+func withoutRateLimits(t testing.TB) {
+	t.Helper()
+	api, auth, register := apiRatePerMinute, authRatePerMinute, registerRatePerMinute
+	apiRatePerMinute, authRatePerMinute, registerRatePerMinute = 0, 0, 0
+	t.Cleanup(func() {
+		apiRatePerMinute, authRatePerMinute, registerRatePerMinute = api, auth, register
+	})
+}
+
+// TestConcurrentWishlistOperations запускает одновременно создание, изменение, удаление списков,
+// выдачу доступа и резервирование на одном роутере. Смысл теста - запуск с -race:
+// гонки и нарушения порядка блокировок обнаруживает детектор. Кроме того, после всех
+// операций проверяется, что в хранилище не осталось ссылок на удаленные данные.
+func TestConcurrentWishlistOperations(t *testing.T) {
+	withoutRateLimits(t)
+	ts := newTestServer(t)
+
+	const workers = 12
+	const itemsPerList = 4
+
+	users := make([]model.User, workers)
+	for i := range users {
+		users[i] = ts.addUser(fmt.Sprintf("user%02d", i))
+	}
+
+	// expect выполняет запрос и сообщает об ответе со статусом не из списка допустимых.
+	// Тело успешного ответа разбирается в out, если он передан.
+	// t.Fatal из горутин вызывать нельзя, поэтому только t.Errorf
+	expect := func(out interface{}, method, path, token string, body interface{}, allowed ...int) int {
+		rec := ts.serve(ts.newRequest(method, path, token, body))
+		for _, status := range allowed {
+			if rec.Code != status {
+				continue
+			}
+			if out != nil && rec.Code < 300 {
+				if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+					t.Errorf("%s %s: decode response: %v", method, path, err)
+				}
+			}
+			return rec.Code
+		}
+		t.Errorf("%s %s: status %d, want one of %v; body: %s", method, path, rec.Code, allowed, rec.Body.String())
+		return rec.Code
+	}
+
+	// Первая фаза: каждый пользователь создает список с элементами и открывает его соседу
+	lists := make([]model.Wishlist, workers)
+	items := make([][]model.Item, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			owner := users[i].ID
+			var wishlist model.Wishlist
+			if expect(&wishlist, http.MethodPost, "/api/v1/wishlists", owner,
+				gin.H{"title": fmt.Sprintf("List %d", i)}, http.StatusCreated) != http.StatusCreated {
+				return
+			}
+			lists[i] = wishlist
+
+			for j := 0; j < itemsPerList; j++ {
+				var item model.Item
+				expect(&item, http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/items", owner,
+					gin.H{"name": fmt.Sprintf("Item %d-%d", i, j)}, http.StatusCreated)
+				items[i] = append(items[i], item)
+			}
+
+			neighbor := users[(i+1)%workers].ID
+			expect(nil, http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/share", owner,
+				gin.H{"shared_user_id": neighbor, "can_edit": i%2 == 0}, http.StatusCreated)
+		}(i)
+	}
+	wg.Wait()
+	if t.Failed() {
+		t.FailNow()
+	}
+
+	// Вторая фаза: все операции вперемешку. Ответы зависят от порядка выполнения
+	// (резерв мог опередить удаление или наоборот), поэтому допустимы несколько статусов,
+	// но не 5xx и не неожиданные коды
+	for i := 0; i < workers; i++ {
+		owner := users[i].ID
+		list := lists[i]
+		// Сосед слева получил доступ к списку i
+		guest := users[(i+1)%workers].ID
+
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for _, item := range items[i] {
+				item.Description = "updated concurrently"
+				expect(nil, http.MethodPut, "/api/v1/wishlists/"+list.ID+"/items/"+item.ID, owner, item,
+					http.StatusOK, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnauthorized)
+				expect(nil, http.MethodGet, "/api/v1/wishlists", owner, nil, http.StatusOK, http.StatusUnauthorized)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, item := range items[i] {
+				expect(nil, http.MethodPost, "/api/v1/wishlists/"+list.ID+"/items/"+item.ID+"/reserve", guest, nil,
+					http.StatusOK, http.StatusNotFound, http.StatusConflict, http.StatusUnauthorized)
+				expect(nil, http.MethodGet, "/api/v1/shared", guest, nil, http.StatusOK, http.StatusUnauthorized)
+				expect(nil, http.MethodGet, "/api/v1/wishlists/"+list.ID+"/items", guest, nil,
+					http.StatusOK, http.StatusNotFound, http.StatusUnauthorized)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			switch i % 3 {
+			case 0:
+				// Удаление списка в корзину и окончательная очистка вместе с доступами
+				expect(nil, http.MethodDelete, "/api/v1/wishlists/"+list.ID, owner, nil, http.StatusNoContent)
+				ts.srv.mu.Lock()
+				ts.srv.purgeWishlist(list.ID)
+				ts.srv.mu.Unlock()
+			case 1:
+				// Удаление аккаунта со всеми списками и доступами к чужим
+				expect(nil, http.MethodDelete, "/api/v1/account", owner, gin.H{"password": testPassword}, http.StatusNoContent)
+			default:
+				// Новые списки и выдача доступа, пока соседи удаляются
+				var duplicate model.Wishlist
+				expect(&duplicate, http.MethodPost, "/api/v1/wishlists/"+list.ID+"/duplicate", owner, nil, http.StatusCreated)
+				other := users[(i+2)%workers].ID
+				expect(nil, http.MethodPost, "/api/v1/wishlists/"+duplicate.ID+"/share", owner,
+					gin.H{"shared_user_id": other}, http.StatusCreated, http.StatusNotFound)
+				SweepExpired(ts.srv.MemStore)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Ни один элемент, доступ или список не ссылается на удаленные данные
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	for _, item := range ts.srv.items {
+		if _, exists := ts.srv.wishlists[item.WishlistID]; !exists {
+			t.Errorf("item %s belongs to a missing wishlist", item.ID)
+		}
+	}
+	for _, share := range ts.srv.sharedWishlists {
+		if _, exists := ts.srv.wishlists[share.WishlistID]; !exists {
+			t.Errorf("share %s refers to a missing wishlist", share.ID)
+		}
+		if _, exists := ts.srv.users[share.UserID]; !exists {
+			t.Errorf("share %s refers to a deleted user", share.ID)
+		}
+	}
+	for _, wishlist := range ts.srv.wishlists {
+		if _, exists := ts.srv.users[wishlist.UserID]; !exists {
+			t.Errorf("wishlist %s belongs to a deleted user", wishlist.ID)
+		}
+	}
+}
+
+// BenchmarkConcurrentUsers измеряет пропускную способность роутера, когда независимые
+// пользователи одновременно работают каждый со своим списком: семь чтений на одну запись.
+// Все запросы проходят через общий мьютекс хранилища, поэтому бенчмарк служит точкой
+// отсчета при любых изменениях блокировок. Запуск: go test -bench ConcurrentUsers -cpu 1,4,8
+func BenchmarkConcurrentUsers(b *testing.B) {
+	withoutRateLimits(b)
+	store := NewMemStore()
+	srv, router := newServer(store), NewRouter(store)
+
+	serve := func(method, path, token string, body interface{}, out interface{}) {
+		var reader io.Reader
+		if body != nil {
+			raw, err := json.Marshal(body)
+			if err != nil {
+				b.Errorf("marshal request body: %v", err)
+				return
+			}
+			reader = bytes.NewReader(raw)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK && rec.Code != http.StatusCreated {
+			b.Errorf("%s %s: status %d; body: %s", method, path, rec.Code, rec.Body.String())
+			return
+		}
+		if out != nil {
+			if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+				b.Errorf("%s %s: decode response: %v", method, path, err)
+			}
+		}
+	}
+
+	var users atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		// У каждой горутины свой пользователь со своим списком, как у addUser - прямо в хранилище
+		user := model.User{
+			ID:            uuid.New().String(),
+			Username:      fmt.Sprintf("bench%03d", users.Add(1)),
+			EmailVerified: true,
+		}
+		user.Email = user.Username + "@example.com"
+		srv.mu.Lock()
+		srv.users[user.ID] = user
+		srv.indexUser(user)
+		srv.mu.Unlock()
+
+		var wishlist model.Wishlist
+		serve(http.MethodPost, "/api/v1/wishlists", user.ID, gin.H{"title": "Benchmark"}, &wishlist)
+		path := "/api/v1/wishlists/" + wishlist.ID
+
+		for i := 0; pb.Next(); i++ {
+			if i%8 == 0 {
+				serve(http.MethodPut, path, user.ID, gin.H{"title": "Benchmark", "version": wishlist.Version}, &wishlist)
+				continue
+			}
+			serve(http.MethodGet, path, user.ID, nil, nil)
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// getEnv возвращает значение переменной окружения или значение по умолчанию
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt читает целое число из переменной окружения.
+// Некорректное значение логируется и заменяется значением по умолчанию.
+func getEnvInt(key string, fallback int64) int64 {
+	raw := getEnv(key, "")
+	if raw == "" {
+		return fallback
+	}
+
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		log.Printf("invalid %s=%q, using default %d", key, raw, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvBool читает логическое значение из переменной окружения.
+// Некорректное значение логируется и заменяется значением по умолчанию.
+func getEnvBool(key string, fallback bool) bool {
+	raw := getEnv(key, "")
+	if raw == "" {
+		return fallback
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("invalid %s=%q, using default %t", key, raw, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration читает длительность из переменной окружения в формате time.ParseDuration.
+// Некорректное или неположительное значение логируется и заменяется значением по умолчанию.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	raw := getEnv(key, "")
+	if raw == "" {
+		return fallback
+	}
+
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		log.Printf("invalid %s=%q, using default %s", key, raw, fallback)
+		return fallback
+	}
+	return value
+}
+
+// Внешний адрес сервиса, используемый в ссылках из писем
+var baseURL = getEnv("BASE_URL", "http://localhost:8080")
+
+// Ограничения на размер тела запроса: обычное и для пакетных операций
+var (
+	maxBodyBytes      = getEnvInt("MAX_BODY_BYTES", 1<<20)
+	maxBatchBodyBytes = getEnvInt("MAX_BATCH_BODY_BYTES", 4<<20)
+)
+
+// Ограничения на форму JSON в теле запроса: глубина вложенности и число элементов в одном массиве.
+// Проверяются до разбора тела в структуры
+var (
+	maxJSONDepth       = getEnvInt("MAX_JSON_DEPTH", 32)
+	maxJSONArrayLength = getEnvInt("MAX_JSON_ARRAY_LENGTH", 10000)
+)
+
+// Максимальное число ID в одном запросе GET /wishlists?ids=...
+var maxBatchIDs = getEnvInt("MAX_BATCH_IDS", 100)
+
+// Квоты: максимальное число списков у пользователя и элементов в одном списке
+var (
+	maxWishlistsPerUser = getEnvInt("MAX_WISHLISTS_PER_USER", 1000)
+	maxItemsPerWishlist = getEnvInt("MAX_ITEMS_PER_WISHLIST", 5000)
+)
+
+// Максимальное число действующих записей о доступе и публичных ссылок у одного списка
+var (
+	maxSharesPerWishlist      = getEnvInt("MAX_SHARES_PER_WISHLIST", 50)
+	maxPublicLinksPerWishlist = getEnvInt("MAX_PUBLIC_LINKS_PER_WISHLIST", 10)
+)
+
+// Ограничения частоты запросов: число запросов в минуту и допустимый всплеск.
+// Для API считаются запросы пользователя, для /auth - запросы с одного адреса.
+// Нулевая частота отключает ограничение.
+var (
+	apiRatePerMinute  = getEnvInt("API_RATE_PER_MINUTE", 600)
+	apiRateBurst      = getEnvInt("API_RATE_BURST", 100)
+	authRatePerMinute = getEnvInt("AUTH_RATE_PER_MINUTE", 60)
+	authRateBurst     = getEnvInt("AUTH_RATE_BURST", 20)
+
+	// Регистрация ограничивается отдельно и строже: каждая создает аккаунт и письмо
+	registerRatePerMinute = getEnvInt("REGISTER_RATE_PER_MINUTE", 5)
+	registerRateBurst     = getEnvInt("REGISTER_RATE_BURST", 3)
+)
+
+// Максимальное время обработки запроса
+var requestTimeout = getEnvDuration("REQUEST_TIMEOUT", 15*time.Second)
+
+// Как часто удалять из хранилища истекшие данные: списки из корзины, доступ, токены подтверждения email
+var retentionSweepInterval = getEnvDuration("RETENTION_SWEEP_INTERVAL", time.Hour)
+
+// Стоимость bcrypt по умолчанию
+const defaultBcryptCost = 12
+
+// Стоимость хэширования паролей, для тестов ее можно понизить через BCRYPT_COST
+var bcryptCost = parseBcryptCost(getEnv("BCRYPT_COST", ""))
+
+// Пересчитывать при входе хэши паролей, созданные с меньшей стоимостью, чем bcryptCost
+var rehashOnLogin = getEnvBool("REHASH_ON_LOGIN", true)
+
+// parseBcryptCost проверяет настроенную стоимость bcrypt.
+// Пустое или нечисловое значение заменяется значением по умолчанию,
+// значение вне допустимого диапазона ограничивается его границами.
+func parseBcryptCost(raw string) int {
+	if raw == "" {
+		return defaultBcryptCost
+	}
+
+	cost, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("invalid BCRYPT_COST=%q, using default %d", raw, defaultBcryptCost)
+		return defaultBcryptCost
+	}
+
+	if cost < bcrypt.MinCost {
+		log.Printf("BCRYPT_COST=%d is below the minimum, using %d", cost, bcrypt.MinCost)
+		return bcrypt.MinCost
+	}
+	if cost > bcrypt.MaxCost {
+		log.Printf("BCRYPT_COST=%d is above the maximum, using %d", cost, bcrypt.MaxCost)
+		return bcrypt.MaxCost
+	}
+	return cost
+}
+
+// Прокси, которым разрешено передавать адрес клиента в X-Forwarded-For.
+// По умолчанию не доверяем никому, и адресом клиента считается адрес соединения.
+// Значение - IP-адреса и CIDR-подсети через запятую.
+var trustedProxies = parseCommaList(getEnv("TRUSTED_PROXIES", ""))
+
+// parseCommaList разбирает список значений через запятую, пропуская пустые
+func parseCommaList(raw string) []string {
+	var values []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// Заголовки безопасности. SECURITY_HEADERS=false отключает X-Content-Type-Options и X-Frame-Options,
+// CONTENT_SECURITY_POLICY=off - Content-Security-Policy, HSTS_MAX_AGE=0 - Strict-Transport-Security.
+var (
+	securityHeaders       = getEnvBool("SECURITY_HEADERS", true)
+	contentSecurityPolicy = getEnv("CONTENT_SECURITY_POLICY", "default-src 'none'; frame-ancestors 'none'")
+	hstsMaxAge            = getEnvInt("HSTS_MAX_AGE", 365*24*60*60)
+)
+
+// Параметры доступа по умолчанию: право на редактирование, если can_edit не передан,
+// и срок действия доступа, если не передан expires_at. Без SHARE_DEFAULT_TTL доступ бессрочный.
+var (
+	shareDefaultCanEdit = getEnvBool("SHARE_DEFAULT_CAN_EDIT", false)
+	shareDefaultTTL     = getEnvDuration("SHARE_DEFAULT_TTL", 0)
+)
+
+// CORS для браузерных клиентов: разрешенные источники через запятую ("*" - любой), срок кэширования
+// ответа на предварительный запрос и отправка cookie и заголовка Authorization с запросами.
+// Без CORS_ALLOWED_ORIGINS заголовки CORS не выставляются.
+var (
+	corsAllowedOrigins   = parseCommaList(getEnv("CORS_ALLOWED_ORIGINS", ""))
+	corsMaxAge           = getEnvDuration("CORS_MAX_AGE", 10*time.Minute)
+	corsAllowCredentials = getEnvBool("CORS_ALLOW_CREDENTIALS", false)
+)
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestParseBcryptCost(t *testing.T) {
+	for raw, want := range map[string]int{
+		"":     defaultBcryptCost,
+		"abc":  defaultBcryptCost,
+		"12.5": defaultBcryptCost,
+		"10":   10,
+		"1":    bcrypt.MinCost,
+		"-3":   bcrypt.MinCost,
+		"99":   bcrypt.MaxCost,
+	} {
+		if got := parseBcryptCost(raw); got != want {
+			t.Errorf("parseBcryptCost(%q) = %d, want %d", raw, got, want)
+		}
+	}
+}
+
+func TestRegisterUsesConfiguredBcryptCost(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.register("newcomer")
+
+	ts.srv.mu.RLock()
+	hash := ts.srv.users[user.ID].Password
+	ts.srv.mu.RUnlock()
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		t.Fatalf("stored password is not a bcrypt hash: %v", err)
+	}
+	if cost != bcryptCost {
+		t.Errorf("hash cost = %d, want configured %d", cost, bcryptCost)
+	}
+}
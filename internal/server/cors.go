@@ -0,0 +1,89 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Методы и заголовки, которые браузер может использовать в запросах к API,
+// и заголовки ответа, доступные скрипту
+const (
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE"
+	corsAllowHeaders  = "Authorization, Content-Type, If-Match, If-None-Match, If-Unmodified-Since, Idempotency-Key, X-Request-ID"
+	corsExposeHeaders = "ETag, Last-Modified, Location, Retry-After, X-Request-ID, Deprecation, Link"
+)
+
+// corsPolicy - разрешенные источники и параметры CORS
+type corsPolicy struct {
+	origins     map[string]bool
+	anyOrigin   bool
+	credentials bool
+	maxAge      int64
+}
+
+// newCORSPolicy проверяет настройки CORS. С credentials браузер не принимает "*",
+// а отвечать любому источнику его же адресом значило бы открыть API с cookie для всех сайтов,
+// поэтому такое сочетание считается ошибкой конфигурации.
+func newCORSPolicy(origins []string, credentials bool, maxAgeSeconds int64) (*corsPolicy, error) {
+	policy := &corsPolicy{
+		origins:     make(map[string]bool),
+		credentials: credentials,
+		maxAge:      maxAgeSeconds,
+	}
+	for _, origin := range origins {
+		if origin == "*" {
+			policy.anyOrigin = true
+			continue
+		}
+		policy.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	if policy.anyOrigin && credentials {
+		return nil, errors.New("CORS_ALLOW_CREDENTIALS cannot be used with CORS_ALLOWED_ORIGINS=*, list the origins explicitly")
+	}
+	return policy, nil
+}
+
+// Middleware для CORS. Предварительный запрос (OPTIONS с Access-Control-Request-Method)
+// обрабатывается здесь и до маршрутов не доходит
+func corsMiddleware(policy *corsPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || len(policy.origins) == 0 && !policy.anyOrigin {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		if !policy.anyOrigin && !policy.origins[origin] {
+			c.Next()
+			return
+		}
+
+		if policy.anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.credentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			if policy.maxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.FormatInt(policy.maxAge, 10))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		c.Next()
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// useCORS задает настройки CORS до конца теста. Вызывается до newTestServer:
+// политика собирается при создании роутера
+func useCORS(t *testing.T, origins []string, credentials bool, maxAge time.Duration) {
+	t.Helper()
+	previousOrigins, previousCredentials, previousMaxAge := corsAllowedOrigins, corsAllowCredentials, corsMaxAge
+	corsAllowedOrigins, corsAllowCredentials, corsMaxAge = origins, credentials, maxAge
+	t.Cleanup(func() {
+		corsAllowedOrigins, corsAllowCredentials, corsMaxAge = previousOrigins, previousCredentials, previousMaxAge
+	})
+}
+
+func TestCORSPreflightMaxAge(t *testing.T) {
+	useCORS(t, []string{"https://app.example.com"}, false, 10*time.Minute)
+	ts := newTestServer(t)
+
+	req := ts.newRequest(http.MethodOptions, "/api/v1/wishlists", "", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec := ts.serve(req)
+
+	expectStatus(t, rec, http.StatusNoContent)
+	h := rec.Header()
+	if h.Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("Access-Control-Max-Age = %q, want 600", h.Get("Access-Control-Max-Age"))
+	}
+	if h.Get("Access-Control-Allow-Origin") != "https://app.example.com" || h.Get("Access-Control-Allow-Methods") == "" {
+		t.Errorf("preflight headers = %v", h)
+	}
+	// Без флага credentials браузеру не разрешается отправлять cookie
+	if h.Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q without credentials", h.Get("Access-Control-Allow-Credentials"))
+	}
+}
+
+func TestCORSCredentialsEchoOrigin(t *testing.T) {
+	useCORS(t, []string{"https://app.example.com", "https://admin.example.com/"}, true, 0)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	for _, origin := range []string{"https://app.example.com", "https://admin.example.com"} {
+		req := ts.newRequest(http.MethodGet, "/api/v1/wishlists", owner.ID, nil)
+		req.Header.Set("Origin", origin)
+		rec := ts.serve(req)
+
+		expectStatus(t, rec, http.StatusOK)
+		h := rec.Header()
+		if h.Get("Access-Control-Allow-Origin") != origin || h.Get("Access-Control-Allow-Credentials") != "true" {
+			t.Errorf("%s: CORS headers = %v", origin, h)
+		}
+		if !containsHeaderValue(h, "Vary", "Origin") {
+			t.Errorf("%s: response does not vary by Origin: %v", origin, h)
+		}
+	}
+
+	// Источнику не из списка разрешение не выдается
+	req := ts.newRequest(http.MethodGet, "/api/v1/wishlists", owner.ID, nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec := ts.serve(req)
+	if h := rec.Header(); h.Get("Access-Control-Allow-Origin") != "" || h.Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("unknown origin got CORS headers: %v", h)
+	}
+
+	// Без max-age заголовок кэширования предварительного запроса не отправляется
+	req = ts.newRequest(http.MethodOptions, "/api/v1/wishlists", "", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rec = ts.serve(req)
+	expectStatus(t, rec, http.StatusNoContent)
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("Access-Control-Max-Age = %q with zero max age", got)
+	}
+}
+
+func TestCORSCredentialsWithWildcardRejected(t *testing.T) {
+	if _, err := newCORSPolicy([]string{"https://app.example.com", "*"}, true, 600); err == nil {
+		t.Error("credentials with a wildcard origin were accepted")
+	}
+
+	// Без credentials "*" допустим и отдается как есть
+	useCORS(t, []string{"*"}, false, 0)
+	ts := newTestServer(t)
+	req := ts.newRequest(http.MethodGet, "/openapi.json", "", nil)
+	req.Header.Set("Origin", "https://any.example.com")
+	rec := ts.serve(req)
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Версия сборки и коммит задаются при сборке:
+//
+//	go build -ldflags "-X wana/internal/server.Version=1.2.0 -X wana/internal/server.Commit=$(git rev-parse --short HEAD)" ./cmd/app
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
+
+// Время запуска процесса
+var startedAt = time.Now()
+
+// Токен доступа к отладочным маршрутам. Без него они доступны только с localhost.
+var adminToken = getEnv("ADMIN_TOKEN", "")
+
+// Маршруты профилирования /debug/pprof включаются переменной PPROF_ENABLED
+var pprofEnabled = getEnvBool("PPROF_ENABLED", false)
+
+// Middleware для доступа к отладочным маршрутам.
+// Если задан ADMIN_TOKEN, требуется заголовок X-Admin-Token с этим значением,
+// иначе запрос принимается только с адреса loopback. Адрес берется из соединения,
+// а не из X-Forwarded-For, чтобы его нельзя было подделать.
+func debugAccessMiddleware(c *gin.Context) {
+	if adminToken != "" {
+		token := c.GetHeader("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		c.Next()
+		return
+	}
+
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil || !ip.IsLoopback() {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+	c.Next()
+}
+
+// getDebugInfo возвращает сведения о сборке и состоянии запущенного сервера
+func (s *Server) getDebugInfo(c *gin.Context) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comments := 0
+	for _, list := range s.comments {
+		comments += len(list)
+	}
+	notifications := 0
+	for _, inbox := range s.notifications {
+		notifications += len(inbox)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"version":        Version,
+		"commit":         Commit,
+		"go_version":     runtime.Version(),
+		"started_at":     startedAt.UTC(),
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+		"goroutines":     runtime.NumGoroutine(),
+		"counts": gin.H{
+			"users":         len(s.users),
+			"wishlists":     len(s.wishlists),
+			"items":         len(s.items),
+			"shares":        len(s.sharedWishlists),
+			"comments":      comments,
+			"notifications": notifications,
+		},
+	})
+}
+
+// getDevMail возвращает письма, отправленные адресату из параметра to через logMailer хранилища.
+// Так сценарии, проверяющие регистрацию целиком (cmd/test), получают ссылку подтверждения email.
+// Если письма отправляет настоящий Mailer, маршрут отвечает 404.
+func (s *Server) getDevMail(c *gin.Context) {
+	dev, ok := s.mailer.(*logMailer)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "mail outbox is not available"})
+		return
+	}
+
+	to := c.Query("to")
+	if to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to is required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dev.sentTo(to))
+}
+
+// registerPprofRoutes регистрирует обработчики net/http/pprof в отладочной группе.
+// Именованные профили (heap, goroutine и т.д.) отдает pprof.Index по имени из адреса.
+func registerPprofRoutes(debug *gin.RouterGroup) {
+	debug.GET("/pprof/", gin.WrapF(pprof.Index))
+	debug.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	debug.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	debug.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	debug.GET("/pprof/:name", gin.WrapF(pprof.Index))
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// debugRequest обращается к отладочному маршруту с адреса remoteAddr.
+// Непустой adminHeader передается в X-Admin-Token
+func (ts *testServer) debugRequest(path, remoteAddr, adminHeader string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	req := ts.newRequest(http.MethodGet, path, "", nil)
+	req.RemoteAddr = remoteAddr
+	if adminHeader != "" {
+		req.Header.Set("X-Admin-Token", adminHeader)
+	}
+	return ts.serve(req)
+}
+
+// useAdminToken задает токен доступа к отладочным маршрутам до конца теста
+func useAdminToken(t *testing.T, token string) {
+	t.Helper()
+	previous := adminToken
+	adminToken = token
+	t.Cleanup(func() { adminToken = previous })
+}
+
+func TestDebugInfoFields(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+
+	rec := ts.debugRequest("/debug/info", "127.0.0.1:5000", "")
+	expectStatus(t, rec, http.StatusOK)
+
+	var info struct {
+		Version   string         `json:"version"`
+		Commit    string         `json:"commit"`
+		GoVersion string         `json:"go_version"`
+		Uptime    *int64         `json:"uptime_seconds"`
+		Routines  int            `json:"goroutines"`
+		Counts    map[string]int `json:"counts"`
+	}
+	decodeBody(t, rec, &info)
+	if info.Version != Version || info.Commit != Commit || info.GoVersion == "" || info.Uptime == nil || info.Routines < 1 {
+		t.Errorf("debug info = %s", rec.Body.String())
+	}
+	if info.Counts["users"] != 1 || info.Counts["wishlists"] != 1 || info.Counts["items"] != 1 {
+		t.Errorf("counts = %v", info.Counts)
+	}
+}
+
+func TestDebugInfoAccess(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Без ADMIN_TOKEN маршрут доступен только с loopback, и X-Forwarded-For этого не обходит
+	expectStatus(t, ts.debugRequest("/debug/info", "[::1]:5000", ""), http.StatusOK)
+	expectStatus(t, ts.debugRequest("/debug/info", "203.0.113.5:5000", ""), http.StatusForbidden)
+	spoofed := ts.newRequest(http.MethodGet, "/debug/info", "", nil)
+	spoofed.RemoteAddr = "203.0.113.5:5000"
+	spoofed.Header.Set("X-Forwarded-For", "127.0.0.1")
+	expectStatus(t, ts.serve(spoofed), http.StatusForbidden)
+
+	// С ADMIN_TOKEN нужен токен, но адрес не важен
+	useAdminToken(t, "admin-secret")
+	expectStatus(t, ts.debugRequest("/debug/info", "203.0.113.5:5000", "admin-secret"), http.StatusOK)
+	expectStatus(t, ts.debugRequest("/debug/info", "127.0.0.1:5000", ""), http.StatusForbidden)
+	expectStatus(t, ts.debugRequest("/debug/info", "127.0.0.1:5000", "wrong"), http.StatusForbidden)
+}
+
+// usePprof включает или выключает маршруты профилирования до конца теста.
+// Маршруты регистрируются при создании роутера, поэтому вызывается до newTestServer
+func usePprof(t *testing.T, enabled bool) {
+	t.Helper()
+	previous := pprofEnabled
+	pprofEnabled = enabled
+	t.Cleanup(func() { pprofEnabled = previous })
+}
+
+func TestPprofDisabledByDefault(t *testing.T) {
+	usePprof(t, false)
+	ts := newTestServer(t)
+
+	expectStatus(t, ts.debugRequest("/debug/pprof/", "127.0.0.1:5000", ""), http.StatusNotFound)
+	expectStatus(t, ts.debugRequest("/debug/pprof/heap", "127.0.0.1:5000", ""), http.StatusNotFound)
+}
+
+func TestPprofEnabled(t *testing.T) {
+	usePprof(t, true)
+	ts := newTestServer(t)
+
+	rec := ts.debugRequest("/debug/pprof/", "127.0.0.1:5000", "")
+	expectStatus(t, rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "heap") {
+		t.Errorf("pprof index does not list profiles: %s", rec.Body.String())
+	}
+	expectStatus(t, ts.debugRequest("/debug/pprof/heap?debug=1", "127.0.0.1:5000", ""), http.StatusOK)
+
+	// Профили закрыты той же проверкой доступа, что и остальные отладочные маршруты
+	expectStatus(t, ts.debugRequest("/debug/pprof/", "203.0.113.5:5000", ""), http.StatusForbidden)
+}
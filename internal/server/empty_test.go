@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Пустые коллекции в ответах - это [] (или {} для групп), а не null: клиенты перебирают их без проверок
+func TestEmptyCollectionsAreNotNull(t *testing.T) {
+	ts := newTestServer(t)
+	newcomer := ts.addUser("newcomer")
+	owner := ts.addUser("owner")
+	home := ts.createWishlist(owner.ID, "Home")
+	item := ts.addItem(owner.ID, home.ID, gin.H{"name": "Lamp"})
+	empty := ts.createWishlist(owner.ID, "Nothing")
+	shown := ts.createWishlist(owner.ID, "Shown")
+	link := ts.publicLink(owner.ID, shown.ID)
+	path := "/api/v1/wishlists/" + empty.ID
+
+	for _, tc := range []struct {
+		path, token string
+		// key - поле с коллекцией в объекте ответа; пустое значение - ответ сам является коллекцией
+		key, want string
+	}{
+		{"/api/v1/wishlists", newcomer.ID, "", "[]"},
+		{"/api/v1/wishlists/trash", newcomer.ID, "", "[]"},
+		{"/api/v1/shared", newcomer.ID, "", "[]"},
+		{"/api/v1/items/search?q=lamp", newcomer.ID, "items", "[]"},
+		{"/api/v1/users/search?q=zz", newcomer.ID, "users", "[]"},
+		{"/api/v1/notifications", newcomer.ID, "notifications", "[]"},
+		{path + "/items", owner.ID, "items", "[]"},
+		{path + "/items?group_by=category", owner.ID, "groups", "{}"},
+		{path + "/export", owner.ID, "items", "[]"},
+		{path + "/public-links", owner.ID, "", "[]"},
+		{"/api/v1/wishlists/" + home.ID + "/items/" + item.ID + "/comments", owner.ID, "", "[]"},
+		{"/public/" + link.Token, "", "items", "[]"},
+	} {
+		rec := ts.do(http.MethodGet, tc.path, tc.token, nil)
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status = %d, body %s", tc.path, rec.Code, rec.Body.String())
+			continue
+		}
+
+		raw := json.RawMessage(rec.Body.Bytes())
+		if tc.key != "" {
+			var fields map[string]json.RawMessage
+			decodeBody(t, rec, &fields)
+			raw = fields[tc.key]
+		}
+		if string(raw) != tc.want {
+			t.Errorf("GET %s: %s = %s, want %s", tc.path, tc.key, raw, tc.want)
+		}
+	}
+}
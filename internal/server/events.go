@@ -0,0 +1,161 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+const (
+	// Размер буфера событий подписчика. Если клиент не успевает читать,
+	// лишние события для него отбрасываются, чтобы не блокировать изменения списка
+	streamBufferSize = 16
+	// Интервал отправки комментария-пинга, чтобы прокси не закрывали простаивающее соединение
+	streamKeepAlive = 30 * time.Second
+)
+
+// StreamEvent - событие, отправляемое подписчикам потока изменений списка
+type StreamEvent struct {
+	Event      string      `json:"event"`
+	WishlistID string      `json:"wishlist_id"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Payload    interface{} `json:"payload"`
+}
+
+// Подписчики потоков: ID списка -> каналы подключенных клиентов.
+// Реестр защищен собственным мьютексом streamMu, под которым другие мьютексы не захватываются.
+var (
+	streamSubscribers = make(map[string]map[chan StreamEvent]struct{})
+	streamMu          sync.Mutex
+)
+
+func subscribeWishlist(wishlistID string) chan StreamEvent {
+	ch := make(chan StreamEvent, streamBufferSize)
+
+	streamMu.Lock()
+	defer streamMu.Unlock()
+
+	if streamSubscribers[wishlistID] == nil {
+		streamSubscribers[wishlistID] = make(map[chan StreamEvent]struct{})
+	}
+	streamSubscribers[wishlistID][ch] = struct{}{}
+	return ch
+}
+
+func unsubscribeWishlist(wishlistID string, ch chan StreamEvent) {
+	streamMu.Lock()
+	defer streamMu.Unlock()
+
+	delete(streamSubscribers[wishlistID], ch)
+	if len(streamSubscribers[wishlistID]) == 0 {
+		delete(streamSubscribers, wishlistID)
+	}
+}
+
+// publishWishlistEvent рассылает событие подписчикам списка без ожидания медленных клиентов.
+// Может вызываться под блокировкой mu.
+func publishWishlistEvent(wishlistID, event string, payload interface{}) {
+	streamMu.Lock()
+	defer streamMu.Unlock()
+
+	ev := StreamEvent{
+		Event:      event,
+		WishlistID: wishlistID,
+		CreatedAt:  clock.Now(),
+		Payload:    payload,
+	}
+	for ch := range streamSubscribers[wishlistID] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (s *Server) streamWishlistEvents(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	if !s.checkStreamAccess(c, userID, wishlistID) {
+		return
+	}
+
+	ch := subscribeWishlist(wishlistID)
+	defer unsubscribeWishlist(wishlistID, ch)
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	// Доступ проверяется и после подписки: список могут удалить, а доступ отозвать или дать ему истечь.
+	// Тогда поток закрывается, не отправив ни одного события
+	ctx := c.Request.Context()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case ev := <-ch:
+			allowed, owner := s.streamAccess(userID, wishlistID)
+			if !allowed {
+				return false
+			}
+			// Событие общее для всех подписчиков, поэтому элемент для владельца копируется
+			if item, ok := ev.Payload.(model.Item); ok && owner {
+				ev.Payload = hideReserver(item)
+			}
+			c.SSEvent(ev.Event, ev)
+			return true
+		case <-keepAlive.C:
+			if allowed, _ := s.streamAccess(userID, wishlistID); !allowed {
+				return false
+			}
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
+
+// checkStreamAccess проверяет, что пользователь может подписаться на изменения списка.
+// При отказе ответ уже отправлен и возвращается false. Мьютекс захватывает сам.
+func (s *Server) checkStreamAccess(c *gin.Context, userID, wishlistID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return false
+	}
+
+	// Подписаться может любой, кто видит список
+	if wishlist.UserID != userID && !s.hasSharedAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return false
+	}
+	return true
+}
+
+// streamAccess сообщает, может ли подписчик по-прежнему получать события списка
+// и является ли он сейчас владельцем списка. Мьютекс захватывает сам.
+func (s *Server) streamAccess(userID, wishlistID string) (allowed, owner bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		return false, false
+	}
+	owner = wishlist.UserID == userID
+	return owner || s.hasSharedAccess(userID, wishlistID), owner
+}
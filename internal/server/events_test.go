@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// eventStream - открытый поток событий списка
+type eventStream struct {
+	t      *testing.T
+	reader *bufio.Reader
+}
+
+// openEventStream подписывается на события списка через настоящее HTTP-соединение:
+// ответ пишется по частям, а httptest.ResponseRecorder отдает тело только после завершения обработчика
+func (ts *testServer) openEventStream(token, wishlistID string) *eventStream {
+	ts.t.Helper()
+	server := httptest.NewServer(ts.router)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ts.t.Cleanup(func() {
+		cancel()
+		server.Close()
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/wishlists/"+wishlistID+"/events", nil)
+	if err != nil {
+		ts.t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		ts.t.Fatalf("open stream: %v", err)
+	}
+	ts.t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		ts.t.Fatalf("stream status = %d, want 200", resp.StatusCode)
+	}
+	return &eventStream{t: ts.t, reader: bufio.NewReader(resp.Body)}
+}
+
+// next читает следующее событие потока. Если поток закрыт, возвращает io.EOF
+func (s *eventStream) next() (string, StreamEvent, error) {
+	s.t.Helper()
+	var name string
+	var event StreamEvent
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return "", event, err
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &event); err != nil {
+				s.t.Fatalf("decode event %q: %v", line, err)
+			}
+		case line == "" && name != "":
+			return name, event, nil
+		}
+	}
+}
+
+func TestEventStreamReceivesItemAdded(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	wishlist := ts.createWishlist(owner.ID, "Group gift")
+	ts.share(owner.ID, wishlist.ID, guest.ID, false)
+
+	stream := ts.openEventStream(guest.ID, wishlist.ID)
+	added := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Bike"})
+
+	name, event, err := stream.next()
+	if err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	if name != auditItemAdded || event.Event != auditItemAdded || event.WishlistID != wishlist.ID {
+		t.Fatalf("event %q = %+v", name, event)
+	}
+	raw, _ := json.Marshal(event.Payload)
+	var item model.Item
+	if err := json.Unmarshal(raw, &item); err != nil || item.ID != added.ID || item.Name != "Bike" {
+		t.Errorf("event payload = %s", raw)
+	}
+}
+
+func TestEventStreamClosedAfterUnshare(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	wishlist := ts.createWishlist(owner.ID, "Group gift")
+	ts.share(owner.ID, wishlist.ID, guest.ID, false)
+
+	stream := ts.openEventStream(guest.ID, wishlist.ID)
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+wishlist.ID+"/share/"+guest.ID, owner.ID, nil), http.StatusNoContent)
+
+	// Событие после отзыва доступа бывшему гостю не отправляется, поток закрывается
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Secret"})
+	if name, event, err := stream.next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("stream after unshare: event %q %+v, err %v; want EOF", name, event, err)
+	}
+}
+
+func TestEventStreamRequiresAccess(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	stranger := ts.addUser("stranger")
+	wishlist := ts.createWishlist(owner.ID, "Group gift")
+
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/events", stranger.ID, nil), http.StatusNotFound)
+}
+
+func TestOwnerEventStreamHidesReserver(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	wishlist := ts.createWishlist(owner.ID, "Group gift")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Bike"})
+	ts.share(owner.ID, wishlist.ID, guest.ID, false)
+
+	ownerStream := ts.openEventStream(owner.ID, wishlist.ID)
+	guestStream := ts.openEventStream(guest.ID, wishlist.ID)
+	expectStatus(t, ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/items/"+item.ID+"/reserve", guest.ID, nil), http.StatusOK)
+
+	for name, tc := range map[string]struct {
+		stream *eventStream
+		want   string
+	}{
+		"owner": {ownerStream, ""},
+		"guest": {guestStream, guest.ID},
+	} {
+		_, event, err := tc.stream.next()
+		if err != nil {
+			t.Fatalf("%s: read event: %v", name, err)
+		}
+		raw, _ := json.Marshal(event.Payload)
+		var reserved model.Item
+		if err := json.Unmarshal(raw, &reserved); err != nil || reserved.ID != item.ID || reserved.ReservedBy != tc.want {
+			t.Errorf("%s: %s payload = %s, want reserved_by %q", name, event.Event, raw, tc.want)
+		}
+	}
+}
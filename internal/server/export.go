@@ -0,0 +1,266 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+
+	"wana/internal/model"
+)
+
+// Пакет для резервного копирования списка вместе с элементами
+type WishlistBundle struct {
+	Wishlist model.Wishlist `json:"wishlist"`
+	Items    []model.Item   `json:"items"`
+}
+
+func (s *Server) exportWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported export format"})
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Проверяем существование списка и права доступа
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID && !s.hasSharedAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	bundle := WishlistBundle{
+		Wishlist: wishlist,
+		Items:    []model.Item{},
+	}
+	for _, item := range s.items {
+		if item.WishlistID != wishlistID {
+			continue
+		}
+		if wishlist.UserID == userID {
+			item = ownerItemView(item)
+		}
+		bundle.Items = append(bundle.Items, item)
+	}
+	// Порядок элементов в выгрузке не зависит от обхода словаря
+	sortItemsByCreatedAt(bundle.Items, false)
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="wishlist-%s.json"`, wishlist.ID))
+	c.JSON(http.StatusOK, bundle)
+}
+
+func (s *Server) importWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	dedupe, err := parseDedupe(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var bundle WishlistBundle
+	if err := bindStrictJSON(c, &bundle); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	// Проверяем все элементы до создания чего-либо
+	for i := range bundle.Items {
+		err := binding.Validator.ValidateStruct(&bundle.Items[i])
+		if err == nil {
+			err = normalizeItem(&bundle.Items[i])
+		}
+		if err != nil {
+			resp := validationError(err)
+			resp["error"] = fmt.Sprintf("invalid item at index %d: %s", i, err.Error())
+			resp["index"] = i
+			c.JSON(http.StatusBadRequest, resp)
+			return
+		}
+	}
+
+	if err := normalizeWishlist(&bundle.Wishlist); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	tags, err := normalizeTags(bundle.Wishlist.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// С dedupe=true из элементов с одинаковым названием импортируется только первый
+	skipped := 0
+	if dedupe {
+		bundle.Items, skipped = dedupeItemsByName(bundle.Items)
+	}
+
+	if bundle.Wishlist.UniqueExternalIDs {
+		if i := duplicateExternalIDIndex(bundle.Items); i >= 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": fmt.Sprintf("item at index %d repeats an external_id", i),
+				"field": "external_id",
+				"code":  "EXTERNAL_ID_CONFLICT",
+				"index": i,
+			})
+			return
+		}
+	}
+
+	if int64(len(bundle.Items)) > maxItemsPerWishlist {
+		abortQuotaExceeded(c, "item quota exceeded for this wishlist", maxItemsPerWishlist)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.canAddWishlists(userID, 1) {
+		abortQuotaExceeded(c, "wishlist quota exceeded", maxWishlistsPerUser)
+		return
+	}
+
+	// Идентификаторы, владелец и время из пакета игнорируются
+	wishlist := model.Wishlist{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Title:       bundle.Wishlist.Title,
+		Description: bundle.Wishlist.Description,
+		Tags:        tags,
+		// Флаг уникальности переносится, повторы в пакете проверены выше
+		UniqueExternalIDs: bundle.Wishlist.UniqueExternalIDs,
+		CreatedAt:         clock.Now(),
+		UpdatedAt:         clock.Now(),
+		Version:           1,
+	}
+	s.wishlists[wishlist.ID] = wishlist
+
+	for _, item := range bundle.Items {
+		item.ID = uuid.New().String()
+		item.WishlistID = wishlist.ID
+		item.AddedBy = userID
+		item.AddedByUsername = ""
+		item.ReservedBy = ""
+		item.Claimed = false
+		item.ClaimToken = ""
+		item.CreatedAt = clock.Now()
+		item.UpdatedAt = item.CreatedAt
+		item.Version = 1
+		s.putItem(item)
+	}
+
+	c.Header("Location", wishlistLocation(wishlist.ID))
+	c.JSON(http.StatusCreated, model.ImportResult{
+		ID:        wishlist.ID,
+		ItemCount: len(bundle.Items),
+		Skipped:   skipped,
+	})
+}
+
+// dedupeItemsByName оставляет из элементов с одинаковым без учета регистра названием первый
+// и возвращает число отброшенных
+func dedupeItemsByName(items []model.Item) ([]model.Item, int) {
+	seen := make(map[string]bool, len(items))
+	kept := items[:0]
+	for _, item := range items {
+		key := strings.ToLower(item.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, item)
+	}
+	return kept, len(items) - len(kept)
+}
+
+// Строки потокового экспорта аккаунта: поля списка или элемента и тип объекта в поле type
+type exportWishlistLine struct {
+	Type string `json:"type"`
+	model.Wishlist
+}
+
+type exportItemLine struct {
+	Type string `json:"type"`
+	model.Item
+}
+
+// exportAll выгружает все списки пользователя (кроме корзины) и их элементы в формате NDJSON:
+// по объекту на строку, за каждым списком следуют его элементы.
+// Ответ пишется по мере чтения, поэтому мьютекс берется отдельно на каждый список
+// и не удерживается во время записи в сеть: в памяти одновременно находятся элементы только одного списка.
+func (s *Server) exportAll(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	ctx := c.Request.Context()
+
+	s.mu.RLock()
+	var snapshot []model.Wishlist
+	for _, wishlist := range s.wishlists {
+		if wishlist.UserID == userID && wishlist.DeletedAt == nil {
+			snapshot = append(snapshot, wishlist)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		if !snapshot[i].CreatedAt.Equal(snapshot[j].CreatedAt) {
+			return snapshot[i].CreatedAt.Before(snapshot[j].CreatedAt)
+		}
+		return snapshot[i].ID < snapshot[j].ID
+	})
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="wana-export.ndjson"`)
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	for _, listed := range snapshot {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Список могли изменить или удалить, пока выгружались предыдущие
+		s.mu.RLock()
+		wishlist, exists := s.findWishlist(listed.ID)
+		var items []model.Item
+		if exists && wishlist.UserID == userID {
+			for _, item := range s.items {
+				if item.WishlistID == wishlist.ID {
+					items = append(items, ownerItemView(item))
+				}
+			}
+		}
+		s.mu.RUnlock()
+
+		if !exists || wishlist.UserID != userID {
+			continue
+		}
+		sortItemsByCreatedAt(items, false)
+
+		if err := encoder.Encode(exportWishlistLine{Type: "wishlist", Wishlist: wishlist}); err != nil {
+			return
+		}
+		for _, item := range items {
+			if err := encoder.Encode(exportItemLine{Type: "item", Item: item}); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
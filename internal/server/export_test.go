@@ -0,0 +1,248 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+func TestExportWishlistBundle(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Book", "price": "12.50"})
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/export", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	if got, want := rec.Header().Get("Content-Disposition"), `attachment; filename="wishlist-`+wishlist.ID+`.json"`; got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+
+	// Проверяем форму документа, а не только то, что он разбирается в WishlistBundle
+	var raw map[string]interface{}
+	decodeBody(t, rec, &raw)
+	if len(raw) != 2 || raw["wishlist"] == nil || raw["items"] == nil {
+		t.Fatalf("bundle keys = %v, want wishlist and items", raw)
+	}
+
+	var bundle WishlistBundle
+	decodeBody(t, rec, &bundle)
+	if bundle.Wishlist.ID != wishlist.ID || bundle.Wishlist.Title != "Birthday" {
+		t.Errorf("bundle wishlist = %+v", bundle.Wishlist)
+	}
+	if len(bundle.Items) != 2 {
+		t.Fatalf("bundle has %d items, want 2", len(bundle.Items))
+	}
+	prices := map[string]string{}
+	for _, item := range bundle.Items {
+		if item.WishlistID != wishlist.ID {
+			t.Errorf("item %s belongs to %s", item.ID, item.WishlistID)
+		}
+		prices[item.Name] = item.Price
+	}
+	if prices["Book"] != "12.50" || prices["Lamp"] != "" {
+		t.Errorf("exported items = %v", prices)
+	}
+}
+
+func TestExportWishlistUnknownFormat(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/export?format=csv", owner.ID, nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	var body map[string]string
+	decodeBody(t, rec, &body)
+	if body["error"] != "unsupported export format" {
+		t.Errorf("error = %q", body["error"])
+	}
+}
+
+func TestExportWishlistRequiresAccess(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	stranger := ts.addUser("stranger")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/export", stranger.ID, nil)
+	if rec.Code != http.StatusForbidden && rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 403 or 404", rec.Code)
+	}
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	source := ts.createWishlist(owner.ID, "Books")
+	ts.addItem(owner.ID, source.ID, gin.H{"name": "Dune", "price": "9.99", "category": "sci-fi"})
+	ts.addItem(owner.ID, source.ID, gin.H{"name": "Emma"})
+
+	exported := ts.do(http.MethodGet, "/api/v1/wishlists/"+source.ID+"/export", owner.ID, nil)
+	expectStatus(t, exported, http.StatusOK)
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/import", owner.ID, exported.Body.String())
+	expectStatus(t, rec, http.StatusCreated)
+
+	var result model.ImportResult
+	decodeBody(t, rec, &result)
+	if result.ItemCount != 2 {
+		t.Errorf("item_count = %d, want 2", result.ItemCount)
+	}
+	if result.ID == "" || result.ID == source.ID {
+		t.Fatalf("imported wishlist id = %q, want a new id", result.ID)
+	}
+
+	// Повторный экспорт импортированного списка совпадает с исходным по содержимому
+	reexported := ts.do(http.MethodGet, "/api/v1/wishlists/"+result.ID+"/export", owner.ID, nil)
+	expectStatus(t, reexported, http.StatusOK)
+	var bundle WishlistBundle
+	decodeBody(t, reexported, &bundle)
+
+	if bundle.Wishlist.Title != "Books" || bundle.Wishlist.UserID != owner.ID {
+		t.Errorf("imported wishlist = %+v", bundle.Wishlist)
+	}
+	byName := map[string]model.Item{}
+	for _, item := range bundle.Items {
+		byName[item.Name] = item
+	}
+	if dune := byName["Dune"]; dune.Price != "9.99" || dune.Category != "sci-fi" || dune.WishlistID != result.ID {
+		t.Errorf("imported Dune = %+v", dune)
+	}
+	if _, ok := byName["Emma"]; !ok || len(bundle.Items) != 2 {
+		t.Errorf("imported items = %v", byName)
+	}
+}
+
+func TestImportMalformedBundle(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	t.Run("invalid item", func(t *testing.T) {
+		bundle := gin.H{
+			"wishlist": gin.H{"title": "Gifts"},
+			"items":    []gin.H{{"name": "Ok"}, {"name": "Bad", "price": "cheap"}},
+		}
+		rec := ts.do(http.MethodPost, "/api/v1/wishlists/import", owner.ID, bundle)
+		expectStatus(t, rec, http.StatusBadRequest)
+
+		var body struct {
+			Error string `json:"error"`
+			Index int    `json:"index"`
+		}
+		decodeBody(t, rec, &body)
+		if body.Index != 1 || !strings.Contains(body.Error, "index 1") {
+			t.Errorf("error = %+v, want one naming index 1", body)
+		}
+	})
+
+	t.Run("broken json", func(t *testing.T) {
+		rec := ts.do(http.MethodPost, "/api/v1/wishlists/import", owner.ID, `{"wishlist": {"title": "Gifts"`)
+		expectStatus(t, rec, http.StatusBadRequest)
+	})
+
+	// Ни одна неудачная попытка не создала список
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	if len(ts.srv.wishlists) != 0 {
+		t.Errorf("store has %d wishlists after failed imports", len(ts.srv.wishlists))
+	}
+}
+
+func TestImportDedupe(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	bundle := gin.H{
+		"wishlist": gin.H{"title": "Imported"},
+		"items":    []gin.H{{"name": "Lamp"}, {"name": "Chair"}, {"name": "lamp"}},
+	}
+
+	for query, want := range map[string]model.ImportResult{
+		"?dedupe=true": {ItemCount: 2, Skipped: 1},
+		"":             {ItemCount: 3},
+	} {
+		rec := ts.do(http.MethodPost, "/api/v1/wishlists/import"+query, owner.ID, bundle)
+		expectStatus(t, rec, http.StatusCreated)
+		var result model.ImportResult
+		decodeBody(t, rec, &result)
+		if result.ItemCount != want.ItemCount || result.Skipped != want.Skipped {
+			t.Errorf("import%s = %+v, want %d items and %d skipped", query, result, want.ItemCount, want.Skipped)
+		}
+		if items := ts.items(owner.ID, result.ID); len(items) != want.ItemCount {
+			t.Errorf("import%s stored %d items", query, len(items))
+		}
+	}
+}
+
+func TestExportAllStreamsOwnData(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	friend := ts.addUser("friend")
+
+	home := ts.createWishlist(owner.ID, "Home")
+	ts.addItem(owner.ID, home.ID, gin.H{"name": "Lamp"})
+	ts.addItem(owner.ID, home.ID, gin.H{"name": "Chair"})
+	fake.Advance(time.Minute)
+	office := ts.createWishlist(owner.ID, "Office")
+	ts.addItem(owner.ID, office.ID, gin.H{"name": "Desk"})
+	trashed := ts.createWishlist(owner.ID, "Trashed")
+	ts.addItem(owner.ID, trashed.ID, gin.H{"name": "Vase"})
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+trashed.ID, owner.ID, nil), http.StatusNoContent)
+	// Чужой список, открытый пользователю, в его выгрузку не попадает
+	foreign := ts.createWishlist(friend.ID, "Friend's")
+	ts.addItem(friend.ID, foreign.ID, gin.H{"name": "Guitar"})
+	ts.share(friend.ID, foreign.ID, owner.ID, true)
+
+	rec := ts.do(http.MethodGet, "/api/v1/export/all", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	var wishlists []string
+	items := make(map[string]int)
+	current := ""
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var line struct {
+			Type       string `json:"type"`
+			ID         string `json:"id"`
+			Title      string `json:"title"`
+			WishlistID string `json:"wishlist_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("decode line %q: %v", scanner.Text(), err)
+		}
+		switch line.Type {
+		case "wishlist":
+			wishlists = append(wishlists, line.Title)
+			current = line.ID
+		case "item":
+			// Элементы идут сразу за своим списком
+			if line.WishlistID != current {
+				t.Errorf("item of %s follows wishlist %s", line.WishlistID, current)
+			}
+			items[line.WishlistID]++
+		default:
+			t.Errorf("line has unknown type: %s", scanner.Text())
+		}
+	}
+
+	if strings.Join(wishlists, ",") != "Home,Office" {
+		t.Errorf("exported wishlists = %v, want Home,Office", wishlists)
+	}
+	if len(items) != 2 || items[home.ID] != 2 || items[office.ID] != 1 {
+		t.Errorf("exported items per wishlist = %v", items)
+	}
+}
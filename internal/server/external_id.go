@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// externalKey - ключ внешнего идентификатора: один и тот же ID в разных источниках - разные товары
+func externalKey(item model.Item) string {
+	return item.ExternalSource + "\x00" + item.ExternalID
+}
+
+// externalIDTaken сообщает, что в списке с unique_external_ids уже есть другой элемент
+// с тем же внешним идентификатором. Элемент exceptItemID (сам изменяемый элемент) не учитывается.
+// Вызывается под блокировкой mu.
+func (s *Server) externalIDTaken(wishlist model.Wishlist, item model.Item, exceptItemID string) bool {
+	if !wishlist.UniqueExternalIDs || item.ExternalID == "" {
+		return false
+	}
+	key := externalKey(item)
+	for _, other := range s.items {
+		if other.WishlistID == wishlist.ID && other.ID != exceptItemID && other.ExternalID != "" && externalKey(other) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDuplicateExternalIDs проверяет, есть ли в списке элементы с одинаковым внешним идентификатором.
+// Без повторов нельзя включить unique_external_ids. Вызывается под блокировкой mu.
+func (s *Server) hasDuplicateExternalIDs(wishlistID string) bool {
+	seen := make(map[string]bool)
+	for _, item := range s.items {
+		if item.WishlistID != wishlistID || item.ExternalID == "" {
+			continue
+		}
+		key := externalKey(item)
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+	}
+	return false
+}
+
+// duplicateExternalIDIndex возвращает индекс первого элемента, повторяющего внешний идентификатор
+// одного из предыдущих, или -1
+func duplicateExternalIDIndex(items []model.Item) int {
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		if item.ExternalID == "" {
+			continue
+		}
+		key := externalKey(item)
+		if seen[key] {
+			return i
+		}
+		seen[key] = true
+	}
+	return -1
+}
+
+// abortExternalIDConflict отвечает 409 с кодом EXTERNAL_ID_CONFLICT
+func abortExternalIDConflict(c *gin.Context, message string) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error": message,
+		"field": "external_id",
+		"code":  "EXTERNAL_ID_CONFLICT",
+	})
+}
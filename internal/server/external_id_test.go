@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// expectExternalIDConflict проверяет ответ 409 с кодом EXTERNAL_ID_CONFLICT
+func expectExternalIDConflict(t *testing.T, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	expectStatus(t, rec, http.StatusConflict)
+	var body map[string]string
+	decodeBody(t, rec, &body)
+	if body["code"] != "EXTERNAL_ID_CONFLICT" || body["field"] != "external_id" {
+		t.Errorf("conflict body = %v", body)
+	}
+}
+
+func TestItemExternalID(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	lamp := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp", "external_id": "B00LAMP", "external_source": "amazon"})
+	if lamp.ExternalID != "B00LAMP" || lamp.ExternalSource != "amazon" {
+		t.Errorf("created item = %+v", lamp)
+	}
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp shade", "external_id": "B00LAMP", "external_source": "ebay"})
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Chair"})
+	path := "/api/v1/wishlists/" + wishlist.ID + "/items"
+
+	lamp.ExternalID = "B00LAMP2"
+	rec := ts.do(http.MethodPut, path+"/"+lamp.ID, owner.ID, lamp)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &lamp)
+	if lamp.ExternalID != "B00LAMP2" {
+		t.Errorf("updated external_id = %q", lamp.ExternalID)
+	}
+
+	for query, want := range map[string]string{
+		"?external_id=B00LAMP2":                        "Lamp",
+		"?external_id=B00LAMP":                         "Lamp shade",
+		"?external_id=B00LAMP2&external_source=amazon": "Lamp",
+		"?external_id=B00LAMP2&external_source=ebay":   "",
+		"?external_id=unknown":                         "",
+	} {
+		if got := itemNames(t, ts, owner.ID, wishlist.ID, query); got != want {
+			t.Errorf("%s: items = %q, want %q", query, got, want)
+		}
+	}
+
+	for field, item := range map[string]gin.H{
+		"external_id":     {"name": "Mug", "external_id": strings.Repeat("x", maxExternalIDLength+1)},
+		"external_source": {"name": "Mug", "external_id": "M1", "external_source": strings.Repeat("x", maxExternalSourceLength+1)},
+	} {
+		expectFieldError(t, ts.do(http.MethodPost, path, owner.ID, item), field)
+	}
+	// Источник без идентификатора ничего не обозначает
+	expectFieldError(t, ts.do(http.MethodPost, path, owner.ID, gin.H{"name": "Mug", "external_source": "amazon"}), "external_id")
+}
+
+func TestExternalIDUniquePerWishlist(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, gin.H{"title": "Unique", "unique_external_ids": true})
+	expectStatus(t, rec, http.StatusCreated)
+	var unique model.Wishlist
+	decodeBody(t, rec, &unique)
+	path := "/api/v1/wishlists/" + unique.ID + "/items"
+
+	lamp := ts.addItem(owner.ID, unique.ID, gin.H{"name": "Lamp", "external_id": "B00LAMP", "external_source": "amazon"})
+	expectExternalIDConflict(t, ts.do(http.MethodPost, path, owner.ID, gin.H{"name": "Lamp again", "external_id": "B00LAMP", "external_source": "amazon"}))
+
+	// Тот же идентификатор в другом источнике - другой товар
+	shade := ts.addItem(owner.ID, unique.ID, gin.H{"name": "Shade", "external_id": "B00LAMP", "external_source": "ebay"})
+	shade.ExternalSource = "amazon"
+	expectExternalIDConflict(t, ts.do(http.MethodPut, path+"/"+shade.ID, owner.ID, shade))
+
+	// Элемент можно сохранить с его собственным идентификатором
+	lamp.Name = "Desk lamp"
+	expectStatus(t, ts.do(http.MethodPut, path+"/"+lamp.ID, owner.ID, lamp), http.StatusOK)
+
+	// Ограничение действует в пределах списка и только там, где включено
+	plain := ts.createWishlist(owner.ID, "Plain")
+	ts.addItem(owner.ID, plain.ID, gin.H{"name": "Lamp", "external_id": "B00LAMP", "external_source": "amazon"})
+	ts.addItem(owner.ID, plain.ID, gin.H{"name": "Lamp again", "external_id": "B00LAMP", "external_source": "amazon"})
+
+	// Включить ограничение в списке, где уже есть повторы, нельзя
+	rec = ts.do(http.MethodPut, "/api/v1/wishlists/"+plain.ID, owner.ID, gin.H{"title": "Plain", "version": plain.Version, "unique_external_ids": true})
+	expectExternalIDConflict(t, rec)
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) addFavorite(c *gin.Context) {
+	s.setFavorite(c, true)
+}
+
+func (s *Server) removeFavorite(c *gin.Context) {
+	s.setFavorite(c, false)
+}
+
+// setFavorite добавляет список в избранное пользователя или убирает его оттуда.
+// Повторный вызов с тем же значением ничего не меняет.
+func (s *Server) setFavorite(c *gin.Context, favorite bool) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	// В избранное можно добавить любой список, который пользователь может просматривать
+	if wishlist.UserID != userID && !s.hasSharedAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	if favorite {
+		if s.favorites[userID] == nil {
+			s.favorites[userID] = make(map[string]bool)
+		}
+		s.favorites[userID][wishlistID] = true
+	} else {
+		delete(s.favorites[userID], wishlistID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"wishlist_id": wishlistID, "favorite": favorite})
+}
+
+// isFavorite сообщает, добавлен ли список в избранное пользователя.
+// Вызывается под блокировкой mu.
+func (s *Server) isFavorite(userID, wishlistID string) bool {
+	return s.favorites[userID][wishlistID]
+}
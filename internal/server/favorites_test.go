@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"wana/internal/model"
+)
+
+// sharedWishlists возвращает списки, открытые пользователю, по запросу с параметрами query
+func (ts *testServer) sharedWishlists(token, query string) map[string]model.SharedWishlistEntry {
+	ts.t.Helper()
+	rec := ts.do(http.MethodGet, "/api/v1/shared"+query, token, nil)
+	expectStatus(ts.t, rec, http.StatusOK)
+
+	var entries []model.SharedWishlistEntry
+	decodeBody(ts.t, rec, &entries)
+	byTitle := make(map[string]model.SharedWishlistEntry, len(entries))
+	for _, entry := range entries {
+		byTitle[entry.Wishlist.Title] = entry
+	}
+	return byTitle
+}
+
+func TestFavoriteSharedWishlist(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	birthday := ts.createWishlist(bob.ID, "Birthday")
+	wedding := ts.createWishlist(bob.ID, "Wedding")
+	ts.share(bob.ID, birthday.ID, alice.ID, false)
+	ts.share(bob.ID, wedding.ID, alice.ID, false)
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wedding.ID+"/favorite", alice.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	// Повторное добавление ничего не меняет
+	expectStatus(t, ts.do(http.MethodPost, "/api/v1/wishlists/"+wedding.ID+"/favorite", alice.ID, nil), http.StatusOK)
+
+	shared := ts.sharedWishlists(alice.ID, "")
+	if len(shared) != 2 || !shared["Wedding"].Favorite || shared["Birthday"].Favorite {
+		t.Errorf("shared wishlists = %+v", shared)
+	}
+
+	favorites := ts.sharedWishlists(alice.ID, "?favorites=true")
+	if len(favorites) != 1 || !favorites["Wedding"].Favorite {
+		t.Errorf("favorite wishlists = %+v", favorites)
+	}
+
+	// Избранное у каждого пользователя свое
+	carol := ts.addUser("carol")
+	ts.share(bob.ID, wedding.ID, carol.ID, false)
+	if shared := ts.sharedWishlists(carol.ID, ""); shared["Wedding"].Favorite {
+		t.Error("favorite leaked to another user")
+	}
+}
+
+func TestUnfavoriteSharedWishlist(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	wedding := ts.createWishlist(bob.ID, "Wedding")
+	ts.share(bob.ID, wedding.ID, alice.ID, false)
+
+	path := "/api/v1/wishlists/" + wedding.ID + "/favorite"
+	expectStatus(t, ts.do(http.MethodPost, path, alice.ID, nil), http.StatusOK)
+	expectStatus(t, ts.do(http.MethodDelete, path, alice.ID, nil), http.StatusOK)
+
+	if favorites := ts.sharedWishlists(alice.ID, "?favorites=true"); len(favorites) != 0 {
+		t.Errorf("favorite wishlists after removal = %+v", favorites)
+	}
+	if shared := ts.sharedWishlists(alice.ID, "?favorites=false"); len(shared) != 1 || shared["Wedding"].Favorite {
+		t.Errorf("shared wishlists after removal = %+v", shared)
+	}
+}
+
+func TestFavoriteRequiresAccess(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	wedding := ts.createWishlist(bob.ID, "Wedding")
+
+	expectStatus(t, ts.do(http.MethodPost, "/api/v1/wishlists/"+wedding.ID+"/favorite", alice.ID, nil), http.StatusNotFound)
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/shared?favorites=yes", alice.ID, nil), http.StatusBadRequest)
+}
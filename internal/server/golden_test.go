@@ -0,0 +1,73 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// С флагом -update тесты перезаписывают эталонные файлы в testdata фактическими ответами
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// expectGolden сравнивает JSON с эталоном testdata/name. Случайные ID заменяются
+// на стабильные подписи из ids, чтобы эталон не зависел от запуска
+func expectGolden(t *testing.T, name string, body []byte, ids map[string]string) {
+	t.Helper()
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "", "  "); err != nil {
+		t.Fatalf("indent %s: %v", body, err)
+	}
+	got := pretty.String() + "\n"
+	for id, label := range ids {
+		got = strings.ReplaceAll(got, id, label)
+	}
+
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s does not match the golden file:\n got %s\nwant %s", name, got, want)
+	}
+}
+
+func TestWishlistBundleGolden(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	fake.Advance(time.Minute)
+	lamp := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp", "price": "12.5", "category": "home", "link": "https://example.com/lamp"})
+	fake.Advance(time.Minute)
+	// У элемента только обязательные поля: пустые необязательные в ответ не попадают
+	book := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Book"})
+	ts.share(owner.ID, wishlist.ID, guest.ID, false)
+	expectStatus(t, ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/items/"+book.ID+"/reserve", guest.ID, nil), http.StatusOK)
+
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/export", guest.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	expectGolden(t, "wishlist_bundle.json", rec.Body.Bytes(), map[string]string{
+		wishlist.ID: "WISHLIST_ID",
+		owner.ID:    "OWNER_ID",
+		guest.ID:    "GUEST_ID",
+		lamp.ID:     "LAMP_ID",
+		book.ID:     "BOOK_ID",
+	})
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ответы меньше этого размера не сжимаются: выигрыш не окупает накладные расходы
+const gzipMinBytes = 1024
+
+// gzipWriter накапливает начало ответа, пока не станет ясно, нужно ли его сжимать.
+// Если тело превысило порог, ответ сжимается, иначе отправляется как есть.
+type gzipWriter struct {
+	gin.ResponseWriter
+	status      int
+	wroteHeader bool
+	buf         bytes.Buffer
+	gz          *gzip.Writer
+	// passthrough - решение принято, данные пишутся без сжатия
+	passthrough bool
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	w.status = code
+	w.wroteHeader = true
+}
+
+func (w *gzipWriter) WriteHeaderNow() {
+	if w.gz == nil && !w.passthrough {
+		w.startPassthrough()
+	}
+}
+
+func (w *gzipWriter) Status() int {
+	return w.status
+}
+
+func (w *gzipWriter) Written() bool {
+	return w.wroteHeader || w.buf.Len() > 0 || w.gz != nil || w.passthrough
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(data)
+	case w.passthrough:
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() >= gzipMinBytes {
+		if compressible(w.Header()) {
+			w.startGzip()
+		} else {
+			w.startPassthrough()
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush означает, что клиент ждет данные немедленно (например, поток SSE),
+// поэтому накопленное отправляется без сжатия
+func (w *gzipWriter) Flush() {
+	switch {
+	case w.gz != nil:
+		w.gz.Flush()
+	case !w.passthrough:
+		w.startPassthrough()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipWriter) startGzip() {
+	header := w.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	w.writeStatus()
+
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	w.gz.Write(w.buf.Bytes())
+	w.buf.Reset()
+}
+
+func (w *gzipWriter) startPassthrough() {
+	w.passthrough = true
+	w.writeStatus()
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+func (w *gzipWriter) writeStatus() {
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// finish отправляет остаток ответа после завершения обработчиков
+func (w *gzipWriter) finish() {
+	switch {
+	case w.gz != nil:
+		w.gz.Close()
+	case !w.passthrough:
+		w.startPassthrough()
+	}
+}
+
+// compressible проверяет, что ответ еще не сжат и его тип имеет смысл сжимать
+func compressible(header http.Header) bool {
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	for _, prefix := range []string{"image/", "video/", "audio/", "application/zip", "application/gzip", "text/event-stream"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// Middleware для сжатия крупных ответов, если клиент поддерживает gzip
+func gzipMiddleware(c *gin.Context) {
+	c.Writer.Header().Add("Vary", "Accept-Encoding")
+	if !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+		c.Next()
+		return
+	}
+
+	writer := &gzipWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = writer
+	defer func() {
+		writer.finish()
+		c.Writer = writer.ResponseWriter
+	}()
+
+	c.Next()
+}
+
+// acceptsGzip разбирает Accept-Encoding с учетом явного запрета gzip;q=0
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getWithEncoding запрашивает path с заголовком Accept-Encoding (пустой - без заголовка)
+func (ts *testServer) getWithEncoding(path, token, encoding string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	req := ts.newRequest(http.MethodGet, path, token, nil)
+	if encoding != "" {
+		req.Header.Set("Accept-Encoding", encoding)
+	}
+	return ts.serve(req)
+}
+
+func gunzip(t *testing.T, body []byte) []byte {
+	t.Helper()
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("response is not gzip: %v", err)
+	}
+	plain, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("decompress response: %v", err)
+	}
+	return plain
+}
+
+func TestLargeItemsResponseGzipped(t *testing.T) {
+	withoutRateLimits(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	for i := 0; i < 20; i++ {
+		ts.addItem(owner.ID, wishlist.ID, gin.H{"name": fmt.Sprintf("Item %d", i), "description": strings.Repeat("cozy ", 20)})
+	}
+	path := "/api/v1/wishlists/" + wishlist.ID + "/items"
+
+	plain := ts.getWithEncoding(path, owner.ID, "")
+	expectStatus(t, plain, http.StatusOK)
+	if plain.Header().Get("Content-Encoding") != "" {
+		t.Fatal("response is compressed without Accept-Encoding")
+	}
+	if plain.Body.Len() < gzipMinBytes {
+		t.Fatalf("response of %d bytes is below the threshold, the test proves nothing", plain.Body.Len())
+	}
+
+	rec := ts.getWithEncoding(path, owner.ID, "deflate, gzip")
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if !strings.Contains(strings.Join(rec.Header().Values("Vary"), ","), "Accept-Encoding") {
+		t.Errorf("Vary = %q, want Accept-Encoding", rec.Header().Values("Vary"))
+	}
+	if rec.Body.Len() >= plain.Body.Len() {
+		t.Errorf("compressed body of %d bytes is not smaller than %d", rec.Body.Len(), plain.Body.Len())
+	}
+	if got := gunzip(t, rec.Body.Bytes()); !bytes.Equal(got, plain.Body.Bytes()) {
+		t.Errorf("decompressed body = %s\nwant %s", got, plain.Body.String())
+	}
+}
+
+func TestSmallResponseNotGzipped(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+
+	rec := ts.getWithEncoding("/api/v1/wishlists/"+wishlist.ID, owner.ID, "gzip")
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q for a %d-byte body", got, rec.Body.Len())
+	}
+	if !strings.Contains(rec.Body.String(), `"title":"Home"`) {
+		t.Errorf("body = %s", rec.Body.String())
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"GZIP":              true,
+		"br, gzip;q=0.8":    true,
+		"gzip;q=0":          false,
+		"gzip; q=0.000":     false,
+		"deflate, identity": false,
+		"x-gzip":            false,
+	} {
+		if got := acceptsGzip(header); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"wana/internal/model"
+)
+
+// Пароль, с которым addUser создает пользователей
+const testPassword = "s3cret-Password"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	log.SetOutput(io.Discard)
+	// Минимальная стоимость, чтобы тесты не тратили время на bcrypt
+	bcryptCost = bcrypt.MinCost
+	// Тестовые получатели вебхуков слушают loopback, который внешний транспорт запрещает.
+	// Клиент подменяется один раз: доставка идет в фоне и может пережить тест
+	webhookClient = &http.Client{Timeout: webhookTimeout}
+
+	os.Exit(m.Run())
+}
+
+// register регистрирует пользователя через API
+func (ts *testServer) register(username string) model.UserResponse {
+	ts.t.Helper()
+	rec := ts.do(http.MethodPost, "/auth/register", "", gin.H{
+		"username": username,
+		"email":    username + "@example.com",
+		"password": testPassword,
+	})
+	expectStatus(ts.t, rec, http.StatusCreated)
+
+	var user model.UserResponse
+	decodeBody(ts.t, rec, &user)
+	return user
+}
+
+// useFakeClock подменяет часы обработчиков на FakeClock до конца теста
+func useFakeClock(t *testing.T) *FakeClock {
+	t.Helper()
+	fake := NewFakeClock(time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	previous := clock
+	clock = fake
+	t.Cleanup(func() { clock = previous })
+	return fake
+}
+
+// testServer - роутер над пустым хранилищем. Хранилище доступно тестам напрямую,
+// чтобы готовить данные и проверять состояние без лишних запросов
+type testServer struct {
+	t      *testing.T
+	srv    *Server
+	router *gin.Engine
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	store := NewMemStore()
+	return &testServer{t: t, srv: newServer(store), router: NewRouter(store)}
+}
+
+// addUser создает пользователя с подтвержденным email прямо в хранилище,
+// минуя регистрацию и ее ограничение частоты. Токен пользователя - его ID
+func (ts *testServer) addUser(username string) model.User {
+	ts.t.Helper()
+	hash, err := hashPassword(testPassword)
+	if err != nil {
+		ts.t.Fatalf("hash password: %v", err)
+	}
+
+	user := model.User{
+		ID:            uuid.New().String(),
+		Username:      username,
+		Email:         username + "@example.com",
+		Password:      hash,
+		EmailVerified: true,
+	}
+	ts.srv.mu.Lock()
+	ts.srv.users[user.ID] = user
+	ts.srv.indexUser(user)
+	ts.srv.mu.Unlock()
+	return user
+}
+
+// newRequest готовит запрос от имени пользователя с токеном token (пустой - без авторизации).
+// body - nil, строка, отправляемая как есть, или значение, кодируемое в JSON
+func (ts *testServer) newRequest(method, path, token string, body interface{}) *http.Request {
+	ts.t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(b)
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			ts.t.Fatalf("marshal request body: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func (ts *testServer) serve(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	ts.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (ts *testServer) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	return ts.serve(ts.newRequest(method, path, token, body))
+}
+
+// expectStatus завершает тест, если ответ пришел с другим статусом
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, status, rec.Body.String())
+	}
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, out interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+}
+
+// createWishlist создает список через API и возвращает его
+func (ts *testServer) createWishlist(token, title string) model.Wishlist {
+	ts.t.Helper()
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists", token, gin.H{"title": title})
+	expectStatus(ts.t, rec, http.StatusCreated)
+
+	var wishlist model.Wishlist
+	decodeBody(ts.t, rec, &wishlist)
+	return wishlist
+}
+
+// addItem добавляет элемент в список через API и возвращает сохраненный элемент
+func (ts *testServer) addItem(token, wishlistID string, item gin.H) model.Item {
+	ts.t.Helper()
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlistID+"/items", token, item)
+	expectStatus(ts.t, rec, http.StatusCreated)
+
+	var created model.Item
+	decodeBody(ts.t, rec, &created)
+	return created
+}
+
+// share открывает пользователю userID доступ к списку от имени владельца
+func (ts *testServer) share(ownerToken, wishlistID, userID string, canEdit bool) model.SharedWishlist {
+	ts.t.Helper()
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlistID+"/share", ownerToken,
+		gin.H{"shared_user_id": userID, "can_edit": canEdit})
+	expectStatus(ts.t, rec, http.StatusCreated)
+
+	var share model.SharedWishlist
+	decodeBody(ts.t, rec, &share)
+	return share
+}
+
+// items возвращает первую страницу элементов списка
+func (ts *testServer) items(token, wishlistID string) []model.Item {
+	ts.t.Helper()
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlistID+"/items", token, nil)
+	expectStatus(ts.t, rec, http.StatusOK)
+
+	var page model.ItemPage
+	decodeBody(ts.t, rec, &page)
+	return page.Items
+}
+
+// expectFieldError проверяет ответ 400 с ошибкой проверки поля field
+func expectFieldError(t *testing.T, rec *httptest.ResponseRecorder, field string) {
+	t.Helper()
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	var body map[string]interface{}
+	decodeBody(t, rec, &body)
+	if body["field"] != field {
+		t.Fatalf("error field = %v, want %q; body: %s", body["field"], field, rec.Body.String())
+	}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Срок хранения результата запроса с ключом идемпотентности
+const idempotencyTTL = 24 * time.Hour
+
+// Срок, после которого запись незавершенного запроса считается брошенной:
+// повтор с тем же ключом снова выполняется, а очистка удаляет запись
+const idempotencyPendingTTL = 5 * time.Minute
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyRecord struct {
+	// Хэш тела запроса: повтор ключа с другим телом - ошибка клиента, а не повтор запроса
+	Fingerprint [sha256.Size]byte
+	Pending     bool
+	Status      int
+	ContentType string
+	Location    string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// Результаты запросов по ключам идемпотентности. Хранилище не связано с остальными,
+// поэтому защищено собственным мьютексом, под которым не захватываются другие.
+var (
+	idempotencyRecords = make(map[string]idempotencyRecord)
+	idempotencyMu      sync.Mutex
+)
+
+// idempotencyWriter сохраняет копию тела ответа
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware для create-эндпоинтов: повторный запрос с тем же заголовком
+// Idempotency-Key возвращает сохраненный ответ вместо повторного создания.
+// Ключи действуют в рамках пользователя и адреса запроса; адреса по /api и /api/v1 считаются одним.
+// Повтор ключа с другим телом запроса отклоняется с 422.
+func idempotencyMiddleware(c *gin.Context) {
+	key := c.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		c.Next()
+		return
+	}
+
+	// Тело уже прочитано в память bodyLimitMiddleware, поэтому его можно прочитать еще раз
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(c.Request.Body); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "could not read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	fingerprint := sha256.Sum256(body)
+
+	userID := c.MustGet("userID").(string)
+	scope := userID + " " + c.Request.Method + " " + canonicalAPIPath(c.Request.URL.Path) + " " + key
+
+	idempotencyMu.Lock()
+	record, exists := idempotencyRecords[scope]
+	if exists && !clock.Now().Before(record.ExpiresAt) {
+		exists = false
+	}
+	if exists {
+		idempotencyMu.Unlock()
+
+		if record.Fingerprint != fingerprint {
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "idempotency key was already used with a different request body",
+				"code":  "IDEMPOTENCY_KEY_REUSED",
+			})
+			return
+		}
+
+		// Запрос с тем же ключом еще выполняется
+		if record.Pending {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this idempotency key is in progress"})
+			return
+		}
+
+		c.Header("Idempotent-Replayed", "true")
+		if record.Location != "" {
+			c.Header("Location", record.Location)
+		}
+		c.Data(record.Status, record.ContentType, record.Body)
+		c.Abort()
+		return
+	}
+	idempotencyRecords[scope] = idempotencyRecord{
+		Fingerprint: fingerprint,
+		Pending:     true,
+		ExpiresAt:   clock.Now().Add(idempotencyPendingTTL),
+	}
+	idempotencyMu.Unlock()
+
+	writer := &idempotencyWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+
+	// Запись завершается в defer, чтобы паника обработчика не оставила ее в состоянии Pending
+	completed := false
+	defer func() {
+		idempotencyMu.Lock()
+		defer idempotencyMu.Unlock()
+
+		// Сохраняем только записанные успешные ответы, после ошибки или паники запрос можно повторить
+		status := writer.Status()
+		if !completed || !writer.Written() || status < 200 || status > 299 {
+			delete(idempotencyRecords, scope)
+			return
+		}
+
+		idempotencyRecords[scope] = idempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Location:    writer.Header().Get("Location"),
+			Body:        writer.body.Bytes(),
+			ExpiresAt:   clock.Now().Add(idempotencyTTL),
+		}
+	}()
+
+	c.Next()
+	completed = true
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"wana/internal/model"
+)
+
+// createWithKey создает список с заголовком Idempotency-Key
+func (ts *testServer) createWithKey(token, key, title string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	req := ts.newRequest(http.MethodPost, "/api/v1/wishlists", token, gin.H{"title": title})
+	req.Header.Set(idempotencyKeyHeader, key)
+	return ts.serve(req)
+}
+
+func TestIdempotentCreateReturnsSameWishlist(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	first := ts.createWithKey(owner.ID, "key-1", "Birthday")
+	expectStatus(t, first, http.StatusCreated)
+	var created model.Wishlist
+	decodeBody(t, first, &created)
+
+	// Повтор с тем же телом возвращает исходный ответ
+	repeated := ts.createWithKey(owner.ID, "key-1", "Birthday")
+	expectStatus(t, repeated, http.StatusCreated)
+	var replayed model.Wishlist
+	decodeBody(t, repeated, &replayed)
+	if replayed.ID != created.ID || replayed.Title != "Birthday" {
+		t.Errorf("replayed wishlist = %+v, want %+v", replayed, created)
+	}
+	if repeated.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Idempotent-Replayed header = %q", repeated.Header().Get("Idempotent-Replayed"))
+	}
+	if repeated.Header().Get("Location") != first.Header().Get("Location") {
+		t.Errorf("Location = %q, want %q", repeated.Header().Get("Location"), first.Header().Get("Location"))
+	}
+
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	if len(ts.srv.wishlists) != 1 {
+		t.Errorf("store has %d wishlists, want 1", len(ts.srv.wishlists))
+	}
+}
+
+func TestIdempotencyKeyReusedWithDifferentBody(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	expectStatus(t, ts.createWithKey(owner.ID, "key-1", "Birthday"), http.StatusCreated)
+
+	rec := ts.createWithKey(owner.ID, "key-1", "Something else")
+	expectStatus(t, rec, http.StatusUnprocessableEntity)
+	var body struct {
+		Code string `json:"code"`
+	}
+	decodeBody(t, rec, &body)
+	if body.Code != "IDEMPOTENCY_KEY_REUSED" {
+		t.Errorf("code = %q, want IDEMPOTENCY_KEY_REUSED", body.Code)
+	}
+
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	if len(ts.srv.wishlists) != 1 {
+		t.Errorf("store has %d wishlists, want 1", len(ts.srv.wishlists))
+	}
+}
+
+func TestIdempotencyKeySharedByAPIAlias(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	first := ts.createWithKey(owner.ID, "key-1", "Birthday")
+	expectStatus(t, first, http.StatusCreated)
+
+	// Тот же запрос по устаревшему префиксу /api - повтор, а не новый список
+	req := ts.newRequest(http.MethodPost, "/api/wishlists", owner.ID, gin.H{"title": "Birthday"})
+	req.Header.Set(idempotencyKeyHeader, "key-1")
+	repeated := ts.serve(req)
+	expectStatus(t, repeated, http.StatusCreated)
+	if repeated.Header().Get("Idempotent-Replayed") != "true" || repeated.Body.String() != first.Body.String() {
+		t.Errorf("alias response = %v %s, want the replayed %s", repeated.Header(), repeated.Body.String(), first.Body.String())
+	}
+}
+
+func TestIdempotencyKeysAreDistinct(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+
+	ids := map[string]bool{}
+	for _, request := range []struct{ token, key string }{
+		{alice.ID, "key-1"},
+		{alice.ID, "key-2"},
+		// Ключи действуют в рамках пользователя
+		{bob.ID, "key-1"},
+	} {
+		rec := ts.createWithKey(request.token, request.key, "Birthday")
+		expectStatus(t, rec, http.StatusCreated)
+		var created model.Wishlist
+		decodeBody(t, rec, &created)
+		ids[created.ID] = true
+	}
+	if len(ids) != 3 {
+		t.Errorf("created %d distinct wishlists, want 3", len(ids))
+	}
+}
+
+func TestIdempotentCreateRetriedAfterError(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	// Ответ с ошибкой не сохраняется, повтор с исправленным телом выполняется
+	expectStatus(t, ts.createWithKey(owner.ID, "key-1", ""), http.StatusBadRequest)
+	rec := ts.createWithKey(owner.ID, "key-1", "Birthday")
+	expectStatus(t, rec, http.StatusCreated)
+	if rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry after an error was replayed")
+	}
+}
+
+func TestConcurrentIdempotentCreates(t *testing.T) {
+	withoutRateLimits(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	const requests = 8
+	codes := make([]int, requests)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = ts.createWithKey(owner.ID, "key-1", "Birthday").Code
+		}(i)
+	}
+	wg.Wait()
+
+	// Остальные запросы получают либо сохраненный ответ, либо 409, пока первый выполняется
+	for _, code := range codes {
+		if code != http.StatusCreated && code != http.StatusConflict {
+			t.Errorf("status = %d, want 201 or 409", code)
+		}
+	}
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	if len(ts.srv.wishlists) != 1 {
+		t.Errorf("store has %d wishlists, want 1", len(ts.srv.wishlists))
+	}
+}
+
+func TestIdempotencyRecordRemovedAfterPanic(t *testing.T) {
+	userID := uuid.New().String()
+	r := gin.New()
+	r.Use(gin.CustomRecovery(recoverPanic))
+	r.POST("/panic", func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Next()
+	}, idempotencyMiddleware, func(c *gin.Context) {
+		panic("handler failed")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/panic", nil)
+	req.Header.Set(idempotencyKeyHeader, "key-1")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	expectStatus(t, rec, http.StatusInternalServerError)
+
+	idempotencyMu.Lock()
+	defer idempotencyMu.Unlock()
+	for scope := range idempotencyRecords {
+		if scope == userID+" POST /panic key-1" {
+			t.Fatalf("record %q left after a panic", scope)
+		}
+	}
+}
+
+func TestSweepRemovesAbandonedIdempotencyRecords(t *testing.T) {
+	fake := useFakeClock(t)
+	store := NewMemStore()
+	scope := uuid.New().String() + " POST /api/v1/wishlists key-1"
+
+	idempotencyMu.Lock()
+	idempotencyRecords[scope] = idempotencyRecord{Pending: true, ExpiresAt: fake.Now().Add(idempotencyPendingTTL)}
+	idempotencyMu.Unlock()
+
+	if sweep := SweepExpired(store); sweep.IdempotencyKeys != 0 {
+		t.Fatalf("sweep removed %d keys before the pending deadline", sweep.IdempotencyKeys)
+	}
+
+	fake.Advance(idempotencyPendingTTL + time.Second)
+	if sweep := SweepExpired(store); sweep.IdempotencyKeys < 1 {
+		t.Fatalf("sweep removed %d keys, want the abandoned record", sweep.IdempotencyKeys)
+	}
+	idempotencyMu.Lock()
+	defer idempotencyMu.Unlock()
+	if _, exists := idempotencyRecords[scope]; exists {
+		t.Errorf("abandoned record %q is still stored", scope)
+	}
+}
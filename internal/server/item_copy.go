@@ -0,0 +1,190 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type itemTargetRequest struct {
+	TargetWishlistID string `json:"target_wishlist_id" binding:"required"`
+}
+
+func (s *Server) copyItem(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+	itemID := c.Param("item_id")
+
+	var request itemTargetRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Исходный список достаточно уметь просматривать
+	source, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if source.UserID != userID && !s.hasSharedAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, source, "access denied")
+		return
+	}
+
+	item, err := s.getItemInWishlist(wishlistID, itemID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// В целевой список нужно право на редактирование
+	target, exists := s.findWishlist(request.TargetWishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "target wishlist not found"})
+		return
+	}
+
+	if target.UserID != userID && !s.hasEditAccess(userID, target.ID) {
+		s.denyAccess(c, userID, target, "target wishlist not found", "access denied to target wishlist")
+		return
+	}
+
+	if target.Archived {
+		c.JSON(http.StatusConflict, gin.H{"error": "target wishlist is archived, unarchive it to edit items"})
+		return
+	}
+
+	if target.Locked {
+		abortWishlistLocked(c, "target wishlist is locked, unlock it to edit items")
+		return
+	}
+
+	if s.externalIDTaken(target, item, "") {
+		abortExternalIDConflict(c, "an item with this external_id already exists in the target wishlist")
+		return
+	}
+
+	if !s.canAddItems(target.ID, 1) {
+		abortQuotaExceeded(c, "item quota exceeded for target wishlist", maxItemsPerWishlist)
+		return
+	}
+
+	item.ID = uuid.New().String()
+	item.WishlistID = target.ID
+	item.AddedBy = userID
+	item.IsPurchased = false
+	item.ReservedBy = ""
+	item.Claimed = false
+	item.ClaimToken = ""
+	item.CreatedAt = clock.Now()
+	item.UpdatedAt = item.CreatedAt
+	item.Version = 1
+
+	if err := s.AddItem(item); err != nil {
+		abortItemStoreError(c, err)
+		return
+	}
+	s.touchItems(target.ID)
+	s.recordAudit(target.ID, userID, auditItemAdded, item.ID)
+	publishWishlistEvent(target.ID, auditItemAdded, item)
+
+	c.Header("Location", itemLocation(item))
+	c.JSON(http.StatusCreated, item)
+}
+
+func (s *Server) moveItem(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+	itemID := c.Param("item_id")
+
+	var request itemTargetRequest
+	if err := bindJSON(c, &request); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	if request.TargetWishlistID == wishlistID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "item is already in the target wishlist"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Перемещение меняет оба списка, поэтому право на редактирование нужно в каждом
+	source, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if source.UserID != userID && !s.hasEditAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, source, "access denied")
+		return
+	}
+
+	item, err := s.getItemInWishlist(wishlistID, itemID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	target, exists := s.findWishlist(request.TargetWishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "target wishlist not found"})
+		return
+	}
+
+	if target.UserID != userID && !s.hasEditAccess(userID, target.ID) {
+		s.denyAccess(c, userID, target, "target wishlist not found", "access denied to target wishlist")
+		return
+	}
+
+	if source.Archived || target.Archived {
+		c.JSON(http.StatusConflict, gin.H{"error": "wishlist is archived, unarchive it to edit items"})
+		return
+	}
+
+	if source.Locked || target.Locked {
+		abortWishlistLocked(c, "wishlist is locked, unlock it to edit items")
+		return
+	}
+
+	if s.externalIDTaken(target, item, item.ID) {
+		abortExternalIDConflict(c, "an item with this external_id already exists in the target wishlist")
+		return
+	}
+
+	if !s.canAddItems(target.ID, 1) {
+		abortQuotaExceeded(c, "item quota exceeded for target wishlist", maxItemsPerWishlist)
+		return
+	}
+
+	// Резерв и отметка гостя относились к исходному списку, после перемещения они снимаются
+	reservedBy := item.ReservedBy
+	item.WishlistID = target.ID
+	item.ReservedBy = ""
+	item.Claimed = false
+	item.ClaimToken = ""
+	item.UpdatedAt = clock.Now()
+	item.Version++
+
+	s.putItem(item)
+	if reservedBy != "" && reservedBy != userID {
+		s.notifyReservationCleared(reservedBy, item, "was cancelled because the item was moved to another wishlist")
+	}
+	s.touchItems(source.ID)
+	s.touchItems(target.ID)
+	s.recordAudit(source.ID, userID, auditItemMoved, item.ID)
+	s.recordAudit(target.ID, userID, auditItemMoved, item.ID)
+	publishWishlistEvent(source.ID, auditItemMoved, item)
+	publishWishlistEvent(target.ID, auditItemMoved, item)
+
+	c.JSON(http.StatusOK, item)
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+func TestCopyItem(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	source := ts.createWishlist(owner.ID, "Birthday")
+	target := ts.createWishlist(owner.ID, "New Year")
+	original := ts.addItem(owner.ID, source.ID, gin.H{"name": "Book", "price": "12.50", "category": "books"})
+
+	// Резерв гостя не должен попасть в копию
+	ts.share(owner.ID, source.ID, guest.ID, false)
+	expectStatus(t, ts.do(http.MethodPost, "/api/v1/wishlists/"+source.ID+"/items/"+original.ID+"/reserve", guest.ID, nil), http.StatusOK)
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+source.ID+"/items/"+original.ID+"/copy", owner.ID,
+		gin.H{"target_wishlist_id": target.ID})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var copied model.Item
+	decodeBody(t, rec, &copied)
+	if copied.ID == "" || copied.ID == original.ID {
+		t.Fatalf("copy id = %q, want a new id", copied.ID)
+	}
+	if copied.WishlistID != target.ID || copied.Name != "Book" || copied.Price != "12.50" || copied.Category != "books" {
+		t.Errorf("copy = %+v", copied)
+	}
+	if copied.ReservedBy != "" || copied.IsPurchased {
+		t.Errorf("copy keeps state: reserved_by = %q, purchased = %v", copied.ReservedBy, copied.IsPurchased)
+	}
+
+	// Оригинал остался на месте вместе с резервом
+	sourceItems := ts.items(owner.ID, source.ID)
+	if len(sourceItems) != 1 || sourceItems[0].ID != original.ID {
+		t.Fatalf("source items = %+v", sourceItems)
+	}
+	ts.srv.mu.RLock()
+	reservedBy := ts.srv.items[original.ID].ReservedBy
+	ts.srv.mu.RUnlock()
+	if reservedBy != guest.ID {
+		t.Errorf("original reserved_by = %q, want %q", reservedBy, guest.ID)
+	}
+
+	targetItems := ts.items(owner.ID, target.ID)
+	if len(targetItems) != 1 || targetItems[0].ID != copied.ID {
+		t.Errorf("target items = %+v", targetItems)
+	}
+}
+
+func TestCopyItemToReadOnlyWishlist(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	aliceList := ts.createWishlist(alice.ID, "Alice")
+	bobList := ts.createWishlist(bob.ID, "Bob")
+	item := ts.addItem(alice.ID, aliceList.ID, gin.H{"name": "Book"})
+
+	// Алиса видит список Боба, но не может его редактировать
+	ts.share(bob.ID, bobList.ID, alice.ID, false)
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+aliceList.ID+"/items/"+item.ID+"/copy", alice.ID,
+		gin.H{"target_wishlist_id": bobList.ID})
+	expectStatus(t, rec, http.StatusForbidden)
+
+	if items := ts.items(bob.ID, bobList.ID); len(items) != 0 {
+		t.Errorf("target has %d items after a rejected copy", len(items))
+	}
+}
+
+func TestMoveItemClearsReservation(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	source := ts.createWishlist(owner.ID, "Birthday")
+	target := ts.createWishlist(owner.ID, "New Year")
+	original := ts.addItem(owner.ID, source.ID, gin.H{"name": "Book", "price": "12.50", "description": "hardcover"})
+
+	ts.share(owner.ID, source.ID, guest.ID, false)
+	expectStatus(t, ts.do(http.MethodPost, "/api/v1/wishlists/"+source.ID+"/items/"+original.ID+"/reserve", guest.ID, nil), http.StatusOK)
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+source.ID+"/items/"+original.ID+"/move", owner.ID,
+		gin.H{"target_wishlist_id": target.ID})
+	expectStatus(t, rec, http.StatusOK)
+
+	var moved model.Item
+	decodeBody(t, rec, &moved)
+	if moved.ID != original.ID || moved.WishlistID != target.ID {
+		t.Fatalf("moved item = %+v, want id %s in %s", moved, original.ID, target.ID)
+	}
+	if moved.Name != "Book" || moved.Price != "12.50" || moved.Description != "hardcover" || !moved.CreatedAt.Equal(original.CreatedAt) {
+		t.Errorf("moved item lost fields: %+v", moved)
+	}
+	if moved.ReservedBy != "" || moved.Claimed {
+		t.Errorf("moved item keeps reservation: reserved_by = %q, claimed = %v", moved.ReservedBy, moved.Claimed)
+	}
+
+	// Гость узнает, что его резерв снят. Уведомления идут от новых к старым
+	inbox := ts.notifications(guest.ID)
+	if len(inbox.Notifications) == 0 || inbox.Notifications[0].Type != notificationReservationCleared {
+		t.Errorf("guest notifications = %+v", inbox.Notifications)
+	}
+}
+
+func TestMoveItemUpdatesWishlists(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	source := ts.createWishlist(owner.ID, "Birthday")
+	target := ts.createWishlist(owner.ID, "New Year")
+	item := ts.addItem(owner.ID, source.ID, gin.H{"name": "Book"})
+	kept := ts.addItem(owner.ID, source.ID, gin.H{"name": "Lamp"})
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+source.ID+"/items/"+item.ID+"/move", owner.ID,
+		gin.H{"target_wishlist_id": target.ID})
+	expectStatus(t, rec, http.StatusOK)
+	var moved model.Item
+	decodeBody(t, rec, &moved)
+
+	// Элемент есть только в целевом списке
+	if items := ts.items(owner.ID, source.ID); len(items) != 1 || items[0].ID != kept.ID {
+		t.Errorf("source items = %+v, want only %s", items, kept.ID)
+	}
+	if items := ts.items(owner.ID, target.ID); len(items) != 1 || items[0].ID != item.ID {
+		t.Errorf("target items = %+v, want only %s", items, item.ID)
+	}
+
+	// Изменять элемент теперь можно только через целевой список
+	update := gin.H{"name": "Book, 2nd edition", "version": moved.Version}
+	rec = ts.do(http.MethodPatch, "/api/v1/wishlists/"+source.ID+"/items/"+item.ID, owner.ID, update)
+	expectStatus(t, rec, http.StatusNotFound)
+	rec = ts.do(http.MethodPatch, "/api/v1/wishlists/"+target.ID+"/items/"+item.ID, owner.ID, update)
+	expectStatus(t, rec, http.StatusOK)
+
+	// Повторное перемещение из старого списка не находит элемент
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/"+source.ID+"/items/"+item.ID+"/move", owner.ID,
+		gin.H{"target_wishlist_id": target.ID})
+	expectStatus(t, rec, http.StatusNotFound)
+}
+
+func TestMoveItemAccessChecks(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	stranger := ts.addUser("stranger")
+	aliceList := ts.createWishlist(alice.ID, "Alice")
+	bobEditable := ts.createWishlist(bob.ID, "Bob editable")
+	bobReadOnly := ts.createWishlist(bob.ID, "Bob read-only")
+	aliceItem := ts.addItem(alice.ID, aliceList.ID, gin.H{"name": "Book"})
+	readOnlyItem := ts.addItem(bob.ID, bobReadOnly.ID, gin.H{"name": "Lamp"})
+	strangerList := ts.createWishlist(stranger.ID, "Stranger")
+
+	ts.share(bob.ID, bobEditable.ID, alice.ID, true)
+	ts.share(bob.ID, bobReadOnly.ID, alice.ID, false)
+
+	move := func(sourceID, itemID, targetID string) int {
+		return ts.do(http.MethodPost, "/api/v1/wishlists/"+sourceID+"/items/"+itemID+"/move", alice.ID,
+			gin.H{"target_wishlist_id": targetID}).Code
+	}
+
+	if code := move(aliceList.ID, aliceItem.ID, bobReadOnly.ID); code != http.StatusForbidden {
+		t.Errorf("move to read-only target: status = %d, want 403", code)
+	}
+	if code := move(bobReadOnly.ID, readOnlyItem.ID, aliceList.ID); code != http.StatusForbidden {
+		t.Errorf("move from read-only source: status = %d, want 403", code)
+	}
+	if code := move(aliceList.ID, aliceItem.ID, strangerList.ID); code != http.StatusNotFound {
+		t.Errorf("move to inaccessible target: status = %d, want 404", code)
+	}
+
+	// Отказы ничего не изменили
+	if items := ts.items(alice.ID, aliceList.ID); len(items) != 1 {
+		t.Errorf("alice has %d items, want 1", len(items))
+	}
+	if items := ts.items(bob.ID, bobReadOnly.ID); len(items) != 1 {
+		t.Errorf("bob read-only list has %d items, want 1", len(items))
+	}
+
+	// С правом редактирования в обоих списках перемещение в чужой список разрешено
+	if code := move(aliceList.ID, aliceItem.ID, bobEditable.ID); code != http.StatusOK {
+		t.Errorf("move to editable target: status = %d, want 200", code)
+	}
+}
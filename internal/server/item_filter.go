@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// itemFilter - условия отбора элементов из параметров запроса getItems
+type itemFilter struct {
+	Purchased *bool
+
+	// Границы цены в копейках. Если задана хотя бы одна граница,
+	// элементы без цены отбрасываются, пока не указан IncludeUnpriced.
+	MinPrice        *int64
+	MaxPrice        *int64
+	IncludeUnpriced bool
+
+	// Поиск по внешнему идентификатору товара и, при необходимости, его источнику
+	ExternalID     string
+	ExternalSource string
+}
+
+func parseItemFilter(c *gin.Context) (itemFilter, error) {
+	var filter itemFilter
+
+	if raw, ok := c.GetQuery("purchased"); ok {
+		purchased, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, errors.New("purchased must be true or false")
+		}
+		filter.Purchased = &purchased
+	}
+
+	var err error
+	if filter.MinPrice, err = parsePriceBound(c, "min_price"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parsePriceBound(c, "max_price"); err != nil {
+		return filter, err
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, errors.New("min_price must not exceed max_price")
+	}
+
+	if raw, ok := c.GetQuery("include_unpriced"); ok {
+		includeUnpriced, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, errors.New("include_unpriced must be true or false")
+		}
+		filter.IncludeUnpriced = includeUnpriced
+	}
+
+	filter.ExternalID = c.Query("external_id")
+	filter.ExternalSource = c.Query("external_source")
+
+	return filter, nil
+}
+
+func parsePriceBound(c *gin.Context, param string) (*int64, error) {
+	raw, ok := c.GetQuery(param)
+	if !ok {
+		return nil, nil
+	}
+
+	cents, err := parsePriceCents(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a non-negative decimal", param)
+	}
+	return &cents, nil
+}
+
+// matches сообщает, проходит ли элемент все заданные условия
+func (f itemFilter) matches(item model.Item) bool {
+	if f.Purchased != nil && item.IsPurchased != *f.Purchased {
+		return false
+	}
+
+	if f.ExternalID != "" && item.ExternalID != f.ExternalID {
+		return false
+	}
+	if f.ExternalSource != "" && item.ExternalSource != f.ExternalSource {
+		return false
+	}
+
+	if f.MinPrice != nil || f.MaxPrice != nil {
+		// Цены, которые не удается разобрать, считаются отсутствующими
+		cents, err := parsePriceCents(item.Price)
+		if err != nil {
+			return f.IncludeUnpriced
+		}
+		if f.MinPrice != nil && cents < *f.MinPrice {
+			return false
+		}
+		if f.MaxPrice != nil && cents > *f.MaxPrice {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseTimeBound читает границу по времени в формате RFC3339
+func parseTimeBound(c *gin.Context, param string) (*time.Time, error) {
+	raw, ok := c.GetQuery(param)
+	if !ok {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC3339 timestamp", param)
+	}
+	return &t, nil
+}
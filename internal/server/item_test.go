@@ -0,0 +1,511 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+func TestItemsGroupedByCategory(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Pan", "category": "kitchen"})
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Pot", "category": "kitchen"})
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Rug"})
+
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/items?group_by=category", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var page model.ItemGroupPage
+	decodeBody(t, rec, &page)
+	if page.Total != 3 || len(page.Groups) != 2 {
+		t.Fatalf("grouped page = %+v", page)
+	}
+	if len(page.Groups["kitchen"]) != 2 {
+		t.Errorf("kitchen group = %+v", page.Groups["kitchen"])
+	}
+	if rugs := page.Groups["uncategorized"]; len(rugs) != 1 || rugs[0].Name != "Rug" {
+		t.Errorf("uncategorized group = %+v", rugs)
+	}
+}
+
+func TestItemsFlatWithoutGroupBy(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Pan", "category": "kitchen"})
+
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/items", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var raw map[string]interface{}
+	decodeBody(t, rec, &raw)
+	if _, grouped := raw["groups"]; grouped {
+		t.Fatalf("flat response contains groups: %s", rec.Body.String())
+	}
+	items, _ := raw["items"].([]interface{})
+	if len(items) != 1 {
+		t.Errorf("items = %v", raw["items"])
+	}
+
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/items?group_by=price", owner.ID, nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestItemCategoryLength(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/items", owner.ID,
+		gin.H{"name": "Pan", "category": strings.Repeat("k", maxCategoryLength+1)})
+	expectFieldError(t, rec, "category")
+}
+
+func TestItemImageURL(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	path := "/api/v1/wishlists/" + wishlist.ID + "/items"
+
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp", "image_url": "https://img.example.com/lamp.png"})
+	if item.ImageURL != "https://img.example.com/lamp.png" {
+		t.Errorf("image_url = %q", item.ImageURL)
+	}
+
+	rec := ts.do(http.MethodPost, path, owner.ID, gin.H{"name": "Lamp", "image_url": "ftp://img.example.com/lamp.png"})
+	expectFieldError(t, rec, "image_url")
+
+	// Пустое значение допустимо: картинка необязательна
+	if item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Chair", "image_url": ""}); item.ImageURL != "" {
+		t.Errorf("image_url = %q, want empty", item.ImageURL)
+	}
+}
+
+func TestItemTimestamps(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+
+	created := fake.Now()
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	if !item.CreatedAt.Equal(created) || !item.UpdatedAt.Equal(created) {
+		t.Fatalf("new item timestamps = %v / %v, want %v", item.CreatedAt, item.UpdatedAt, created)
+	}
+
+	fake.Advance(time.Hour)
+	item.Name = "Desk lamp"
+	rec := ts.do(http.MethodPut, "/api/v1/wishlists/"+wishlist.ID+"/items/"+item.ID, owner.ID, item)
+	expectStatus(t, rec, http.StatusOK)
+	var updated model.Item
+	decodeBody(t, rec, &updated)
+	if !updated.CreatedAt.Equal(created) || !updated.UpdatedAt.Equal(fake.Now()) {
+		t.Errorf("updated item timestamps = %v / %v", updated.CreatedAt, updated.UpdatedAt)
+	}
+
+	// Пакетная отметка покупки тоже обновляет время изменения
+	fake.Advance(time.Hour)
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/items/purchase-all", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	got := ts.items(owner.ID, wishlist.ID)[0]
+	if !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(fake.Now()) {
+		t.Errorf("bulk-updated item timestamps = %v / %v", got.CreatedAt, got.UpdatedAt)
+	}
+}
+
+func TestItemsSortedByCreatedAt(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	for _, name := range []string{"first", "second", "third"} {
+		ts.addItem(owner.ID, wishlist.ID, gin.H{"name": name})
+		fake.Advance(time.Minute)
+	}
+
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/items?sort=-created_at", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var page model.ItemPage
+	decodeBody(t, rec, &page)
+
+	var names []string
+	for _, item := range page.Items {
+		names = append(names, item.Name)
+	}
+	if strings.Join(names, ",") != "third,second,first" {
+		t.Errorf("order = %v", names)
+	}
+}
+
+// itemNames возвращает названия элементов списка по запросу с параметрами query через запятую
+func itemNames(t *testing.T, ts *testServer, token, wishlistID, query string) string {
+	t.Helper()
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlistID+"/items"+query, token, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var page model.ItemPage
+	decodeBody(t, rec, &page)
+	names := make([]string, 0, len(page.Items))
+	for _, item := range page.Items {
+		names = append(names, item.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestItemsFilteredByPurchased(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	for _, name := range []string{"Pan", "Pot", "Rug"} {
+		item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": name, "category": "home"})
+		if name != "Pot" {
+			item.IsPurchased = true
+			rec := ts.do(http.MethodPut, "/api/v1/wishlists/"+wishlist.ID+"/items/"+item.ID, owner.ID, item)
+			expectStatus(t, rec, http.StatusOK)
+		}
+		fake.Advance(time.Minute)
+	}
+
+	for query, want := range map[string]string{
+		"":                                      "Pan,Pot,Rug",
+		"?purchased=true":                       "Pan,Rug",
+		"?purchased=false":                      "Pot",
+		"?purchased=true&sort=-created_at":      "Rug,Pan",
+		"?purchased=false&sort=created_at":      "Pot",
+		"?purchased=1&sort=-created_at&limit=1": "Rug",
+	} {
+		if got := itemNames(t, ts, owner.ID, wishlist.ID, query); got != want {
+			t.Errorf("items%s = %q, want %q", query, got, want)
+		}
+	}
+
+	// Фильтр применяется и к группировке
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/items?purchased=false&group_by=category", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var grouped model.ItemGroupPage
+	decodeBody(t, rec, &grouped)
+	if home := grouped.Groups["home"]; grouped.Total != 1 || len(home) != 1 || home[0].Name != "Pot" {
+		t.Errorf("grouped unpurchased items = %+v", grouped)
+	}
+
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/items?purchased=maybe", owner.ID, nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestItemsFilteredByPriceRange(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	for _, item := range []gin.H{
+		{"name": "Mug", "price": "5"},
+		{"name": "Book", "price": "12.50"},
+		{"name": "Lamp", "price": "40.00"},
+		{"name": "Surprise"},
+	} {
+		ts.addItem(owner.ID, wishlist.ID, item)
+		fake.Advance(time.Minute)
+	}
+
+	for query, want := range map[string]string{
+		"?min_price=5&max_price=12.50":        "Mug,Book",
+		"?min_price=10":                       "Book,Lamp",
+		"?max_price=12.49":                    "Mug",
+		"?min_price=12.5&max_price=12.5":      "Book",
+		"?max_price=10&include_unpriced=true": "Mug,Surprise",
+		"?include_unpriced=false":             "Mug,Book,Lamp,Surprise",
+	} {
+		if got := itemNames(t, ts, owner.ID, wishlist.ID, query); got != want {
+			t.Errorf("items%s = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestItemsPriceRangeValidation(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+
+	for _, query := range []string{
+		"?min_price=20&max_price=10",
+		"?min_price=-1",
+		"?max_price=cheap",
+		"?min_price=1&include_unpriced=sometimes",
+	} {
+		rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/items"+query, owner.ID, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("items%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestConcurrentEditorsItemVersion(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	editor := ts.addUser("editor")
+	wishlist := ts.createWishlist(owner.ID, "Group gift")
+	ts.share(owner.ID, wishlist.ID, editor.ID, true)
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Bike"})
+	path := "/api/v1/wishlists/" + wishlist.ID + "/items/" + item.ID
+
+	// Оба редактора загрузили версию 1, первым сохраняет приглашенный
+	editorCopy, ownerCopy := item, item
+	editorCopy.Price = "300.00"
+	rec := ts.do(http.MethodPut, path, editor.ID, editorCopy)
+	expectStatus(t, rec, http.StatusOK)
+	var saved model.Item
+	decodeBody(t, rec, &saved)
+	if saved.Version != item.Version+1 {
+		t.Fatalf("saved version = %d, want %d", saved.Version, item.Version+1)
+	}
+
+	// Изменение владельца по старой версии не затирает чужое
+	ownerCopy.Name = "Road bike"
+	rec = ts.do(http.MethodPut, path, owner.ID, ownerCopy)
+	expectStatus(t, rec, http.StatusConflict)
+
+	stored := ts.items(owner.ID, wishlist.ID)[0]
+	if stored.Name != "Bike" || stored.Price != "300.00" || stored.Version != saved.Version {
+		t.Errorf("stored item = %+v", stored)
+	}
+}
+
+func TestAddItemLocation(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/items", owner.ID, gin.H{"name": "Lamp"})
+	expectStatus(t, rec, http.StatusCreated)
+	var item model.Item
+	decodeBody(t, rec, &item)
+
+	if want := "/api/v1/wishlists/" + wishlist.ID + "/items/" + item.ID; rec.Header().Get("Location") != want {
+		t.Errorf("Location = %q, want %q", rec.Header().Get("Location"), want)
+	}
+}
+
+func TestItemUnderWrongWishlistNotFound(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	home := ts.createWishlist(owner.ID, "Home")
+	other := ts.createWishlist(owner.ID, "Other")
+	target := ts.createWishlist(owner.ID, "Target")
+	item := ts.addItem(owner.ID, home.ID, gin.H{"name": "Lamp"})
+	ts.share(owner.ID, other.ID, guest.ID, false)
+
+	// Элемент существует, и у пользователя есть доступ к обоим спискам, но элемент не из списка в адресе
+	wrong := "/api/v1/wishlists/" + other.ID + "/items/" + item.ID
+	update := item
+	update.Name = "Renamed"
+	patch := ts.newRequest(http.MethodPatch, wrong, owner.ID, `{"name": "Renamed", "version": 1}`)
+	patch.Header.Set("Content-Type", "application/merge-patch+json")
+
+	for name, rec := range map[string]*httptest.ResponseRecorder{
+		"PUT":      ts.do(http.MethodPut, wrong, owner.ID, update),
+		"PATCH":    ts.serve(patch),
+		"copy":     ts.do(http.MethodPost, wrong+"/copy", owner.ID, gin.H{"target_wishlist_id": target.ID}),
+		"move":     ts.do(http.MethodPost, wrong+"/move", owner.ID, gin.H{"target_wishlist_id": target.ID}),
+		"comments": ts.do(http.MethodGet, wrong+"/comments", owner.ID, nil),
+		"reserve":  ts.do(http.MethodPost, wrong+"/reserve", guest.ID, nil),
+		"DELETE":   ts.do(http.MethodDelete, wrong, owner.ID, nil),
+	} {
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404; body: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+
+	stored := ts.items(owner.ID, home.ID)
+	if len(stored) != 1 || stored[0].Name != "Lamp" || stored[0].Version != 1 || stored[0].ReservedBy != "" {
+		t.Errorf("item after requests under the wrong wishlist = %+v", stored)
+	}
+	if copied := ts.items(owner.ID, target.ID); len(copied) != 0 {
+		t.Errorf("target wishlist items = %+v", copied)
+	}
+}
+
+func TestItemsPaginated(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	for _, name := range []string{"one", "two", "three", "four", "five"} {
+		ts.addItem(owner.ID, wishlist.ID, gin.H{"name": name})
+		fake.Advance(time.Minute)
+	}
+	path := "/api/v1/wishlists/" + wishlist.ID + "/items"
+
+	for _, tc := range []struct {
+		query  string
+		names  string
+		limit  int
+		offset int
+	}{
+		{"?limit=2", "one,two", 2, 0},
+		{"?limit=2&offset=4", "five", 2, 4},
+		{"?limit=2&offset=10", "", 2, 10},
+		{"?limit=2&sort=-created_at", "five,four", 2, 0},
+		{"", "one,two,three,four,five", defaultPageLimit, 0},
+		{"?limit=1000", "one,two,three,four,five", maxPageLimit, 0},
+	} {
+		rec := ts.do(http.MethodGet, path+tc.query, owner.ID, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var page model.ItemPage
+		decodeBody(t, rec, &page)
+
+		names := make([]string, 0, len(page.Items))
+		for _, item := range page.Items {
+			names = append(names, item.Name)
+		}
+		if strings.Join(names, ",") != tc.names || page.Total != 5 || page.Limit != tc.limit || page.Offset != tc.offset {
+			t.Errorf("%q: page = %v total %d limit %d offset %d, want %s total 5 limit %d offset %d",
+				tc.query, names, page.Total, page.Limit, page.Offset, tc.names, tc.limit, tc.offset)
+		}
+	}
+
+	for _, query := range []string{"?limit=0", "?limit=abc", "?offset=-1"} {
+		expectStatus(t, ts.do(http.MethodGet, path+query, owner.ID, nil), http.StatusBadRequest)
+	}
+}
+
+func TestAddItemDedupe(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	lamp := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	path := "/api/v1/wishlists/" + wishlist.ID + "/items"
+
+	// Совпадение без учета регистра возвращает существующий элемент
+	rec := ts.do(http.MethodPost, path+"?dedupe=true", owner.ID, gin.H{"name": "LAMP", "price": "10"})
+	expectStatus(t, rec, http.StatusOK)
+	var existing model.Item
+	decodeBody(t, rec, &existing)
+	if existing.ID != lamp.ID || existing.Name != "Lamp" || existing.Price != "" {
+		t.Errorf("dedupe hit = %+v, want the stored lamp", existing)
+	}
+	if got := rec.Header().Get("Location"); got != itemLocation(lamp) {
+		t.Errorf("Location = %q, want %q", got, itemLocation(lamp))
+	}
+
+	rec = ts.do(http.MethodPost, path+"?dedupe=true", owner.ID, gin.H{"name": "Chair"})
+	expectStatus(t, rec, http.StatusCreated)
+
+	// Без флага повторное название разрешено
+	rec = ts.do(http.MethodPost, path, owner.ID, gin.H{"name": "Lamp"})
+	expectStatus(t, rec, http.StatusCreated)
+	var duplicate model.Item
+	decodeBody(t, rec, &duplicate)
+	if duplicate.ID == lamp.ID {
+		t.Error("default path returned the existing item")
+	}
+	if items := ts.items(owner.ID, wishlist.ID); len(items) != 3 {
+		t.Errorf("items = %+v, want lamp, chair and a second lamp", items)
+	}
+
+	expectStatus(t, ts.do(http.MethodPost, path+"?dedupe=maybe", owner.ID, gin.H{"name": "Lamp"}), http.StatusBadRequest)
+}
+
+func TestItemRecordsWhoAddedIt(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	editor := ts.addUser("editor")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	ts.share(owner.ID, wishlist.ID, editor.ID, true)
+
+	ownItem := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	fake.Advance(time.Minute)
+	// Автора нельзя подменить полем в запросе
+	added := ts.addItem(editor.ID, wishlist.ID, gin.H{"name": "Chair", "added_by": owner.ID, "added_by_username": "owner"})
+	if ownItem.AddedBy != owner.ID || added.AddedBy != editor.ID {
+		t.Errorf("added_by = %q / %q, want owner / editor", ownItem.AddedBy, added.AddedBy)
+	}
+
+	// Владелец правит добавленное редактором, автор при этом не меняется
+	added.Price = "25"
+	rec := ts.do(http.MethodPut, "/api/v1/wishlists/"+wishlist.ID+"/items/"+added.ID, owner.ID, added)
+	expectStatus(t, rec, http.StatusOK)
+
+	for _, token := range []string{owner.ID, editor.ID} {
+		items := ts.items(token, wishlist.ID)
+		if len(items) != 2 {
+			t.Fatalf("items = %+v", items)
+		}
+		for i, want := range []model.User{owner, editor} {
+			if items[i].AddedBy != want.ID || items[i].AddedByUsername != want.Username {
+				t.Errorf("%s added by %s (%s), want %s", items[i].Name, items[i].AddedByUsername, items[i].AddedBy, want.Username)
+			}
+		}
+		if items[1].Price != "25.00" {
+			t.Errorf("owner's edit was not applied: %+v", items[1])
+		}
+	}
+
+	// Импортированные элементы считаются добавленными импортировавшим
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/import", editor.ID, gin.H{
+		"wishlist": gin.H{"title": "Imported"},
+		"items":    []gin.H{{"name": "Mug", "added_by": owner.ID}},
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var result model.ImportResult
+	decodeBody(t, rec, &result)
+	if items := ts.items(editor.ID, result.ID); len(items) != 1 || items[0].AddedBy != editor.ID || items[0].AddedByUsername != "editor" {
+		t.Errorf("imported items = %+v", items)
+	}
+}
+
+func TestItemUpdateIfUnmodifiedSince(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	editor := ts.addUser("editor")
+	wishlist := ts.createWishlist(owner.ID, "Group gift")
+	ts.share(owner.ID, wishlist.ID, editor.ID, true)
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Bike"})
+	path := "/api/v1/wishlists/" + wishlist.ID + "/items/" + item.ID
+	conditionalPut := func(token string, update model.Item, since time.Time) *httptest.ResponseRecorder {
+		t.Helper()
+		// Версия не передается: условием служит только время изменения
+		update.Version = 0
+		req := ts.newRequest(http.MethodPut, path, token, update)
+		req.Header.Set("If-Unmodified-Since", since.Format(http.TimeFormat))
+		return ts.serve(req)
+	}
+
+	// Оба загрузили элемент сразу после создания, редактор сохраняет первым
+	loaded := item.UpdatedAt
+	editorCopy, ownerCopy := item, item
+	fake.Advance(time.Minute)
+	editorCopy.Price = "300.00"
+	expectStatus(t, conditionalPut(editor.ID, editorCopy, loaded), http.StatusOK)
+	saved := fake.Now()
+
+	// Изменение владельца по устаревшему времени отклоняется
+	fake.Advance(time.Minute)
+	ownerCopy.Name = "Road bike"
+	rec := conditionalPut(owner.ID, ownerCopy, loaded)
+	expectStatus(t, rec, http.StatusPreconditionFailed)
+	var body struct {
+		Code      string    `json:"code"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	decodeBody(t, rec, &body)
+	if body.Code != "PRECONDITION_FAILED" || !body.UpdatedAt.Equal(saved) {
+		t.Errorf("412 body = %+v", body)
+	}
+
+	stored := ts.items(owner.ID, wishlist.ID)[0]
+	if stored.Name != "Bike" || stored.Price != "300.00" || !stored.UpdatedAt.Equal(saved) {
+		t.Errorf("stored item = %+v", stored)
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) lockWishlist(c *gin.Context) {
+	s.setWishlistLocked(c, true)
+}
+
+func (s *Server) unlockWishlist(c *gin.Context) {
+	s.setWishlistLocked(c, false)
+}
+
+// setWishlistLocked закрывает список для изменения элементов или снова открывает его.
+// Закрытый список по-прежнему можно просматривать
+func (s *Server) setWishlistLocked(c *gin.Context, locked bool) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	// Закрывать и открывать список может только владелец
+	if wishlist.UserID != userID {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	wishlist.Locked = locked
+	wishlist.UpdatedAt = clock.Now()
+	wishlist.Version++
+	s.wishlists[wishlistID] = wishlist
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// abortWishlistLocked отвечает 409 с кодом WISHLIST_LOCKED на попытку изменить элементы закрытого списка
+func abortWishlistLocked(c *gin.Context, message string) {
+	c.JSON(http.StatusConflict, gin.H{"error": message, "code": "WISHLIST_LOCKED"})
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// expectWishlistLocked проверяет ответ 409 с кодом WISHLIST_LOCKED
+func expectWishlistLocked(t *testing.T, name string, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	var body map[string]string
+	if rec.Code == http.StatusConflict {
+		decodeBody(t, rec, &body)
+	}
+	if rec.Code != http.StatusConflict || body["code"] != "WISHLIST_LOCKED" {
+		t.Errorf("%s: status = %d, body %s, want 409 WISHLIST_LOCKED", name, rec.Code, rec.Body.String())
+	}
+}
+
+func TestLockedWishlistRejectsItemEdits(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	editor := ts.addUser("editor")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	ts.share(owner.ID, wishlist.ID, editor.ID, true)
+	path := "/api/v1/wishlists/" + wishlist.ID
+
+	// Закрыть список может только владелец
+	expectStatus(t, ts.do(http.MethodPost, path+"/lock", editor.ID, nil), http.StatusForbidden)
+	rec := ts.do(http.MethodPost, path+"/lock", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var locked model.Wishlist
+	decodeBody(t, rec, &locked)
+	if !locked.Locked || locked.Version != wishlist.Version+1 {
+		t.Errorf("locked wishlist = %+v", locked)
+	}
+
+	itemPath := path + "/items/" + item.ID
+	update := item
+	update.Name = "Desk lamp"
+	for name, rec := range map[string]*httptest.ResponseRecorder{
+		"add":          ts.do(http.MethodPost, path+"/items", editor.ID, gin.H{"name": "Chair"}),
+		"update":       ts.do(http.MethodPut, itemPath, editor.ID, update),
+		"owner update": ts.do(http.MethodPut, itemPath, owner.ID, update),
+		"reserve":      ts.do(http.MethodPost, itemPath+"/reserve", editor.ID, nil),
+		"purchase all": ts.do(http.MethodPost, path+"/items/purchase-all", owner.ID, nil),
+		"delete":       ts.do(http.MethodDelete, itemPath, editor.ID, nil),
+	} {
+		expectWishlistLocked(t, name, rec)
+	}
+
+	// Просмотр закрытого списка работает
+	expectStatus(t, ts.do(http.MethodGet, path, editor.ID, nil), http.StatusOK)
+	if items := ts.items(editor.ID, wishlist.ID); len(items) != 1 || items[0].Name != "Lamp" {
+		t.Errorf("items of the locked wishlist = %+v", items)
+	}
+
+	expectStatus(t, ts.do(http.MethodPost, path+"/unlock", editor.ID, nil), http.StatusForbidden)
+	expectStatus(t, ts.do(http.MethodPost, path+"/unlock", owner.ID, nil), http.StatusOK)
+	expectStatus(t, ts.do(http.MethodPut, itemPath, editor.ID, update), http.StatusOK)
+	expectStatus(t, ts.do(http.MethodPost, path+"/items", editor.ID, gin.H{"name": "Chair"}), http.StatusCreated)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"log"
+	"sync"
+)
+
+// Mailer отправляет письма пользователям
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// Сколько последних писем хранит logMailer
+const devOutboxSize = 100
+
+// SentMail - письмо, сохраненное logMailer
+type SentMail struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// logMailer выводит письма в лог вместо отправки. Используется при разработке.
+// Последние письма он хранит, чтобы их можно было прочитать через GET /debug/mail.
+type logMailer struct {
+	mu     sync.Mutex
+	outbox []SentMail
+}
+
+func (m *logMailer) Send(to, subject, body string) error {
+	log.Printf("mail to=%s subject=%q\n%s", to, subject, body)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outbox = append(m.outbox, SentMail{To: to, Subject: subject, Body: body})
+	if len(m.outbox) > devOutboxSize {
+		m.outbox = append([]SentMail(nil), m.outbox[len(m.outbox)-devOutboxSize:]...)
+	}
+	return nil
+}
+
+// sentTo возвращает сохраненные письма адресату в порядке отправки
+func (m *logMailer) sentTo(to string) []SentMail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mails := []SentMail{}
+	for _, mail := range m.outbox {
+		if mail.To == to {
+			mails = append(mails, mail)
+		}
+	}
+	return mails
+}
+
+// sendMail отправляет письмо и логирует ошибку отправки
+func sendMail(mailer Mailer, to, subject, body string) {
+	if err := mailer.Send(to, subject, body); err != nil {
+		log.Printf("could not send email to %s: %v", to, err)
+	}
+}
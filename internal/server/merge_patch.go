@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"wana/internal/model"
+)
+
+// Тип содержимого JSON Merge Patch (RFC 7386)
+const mergePatchContentType = "application/merge-patch+json"
+
+// isMergePatch сообщает, что тело запроса - JSON Merge Patch, а не полное представление записи
+func isMergePatch(c *gin.Context) bool {
+	return c.ContentType() == mergePatchContentType
+}
+
+// bindMergePatch читает тело запроса как JSON Merge Patch. Патч к записи должен быть объектом:
+// патч другого типа по RFC 7386 заменил бы запись целиком, что для элемента бессмысленно.
+func bindMergePatch(c *gin.Context) (map[string]interface{}, error) {
+	if c.Request.Body == nil {
+		return nil, errors.New("invalid request")
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, errors.New("could not read request body")
+	}
+
+	if err := checkJSONShape(body, maxJSONDepth, maxJSONArrayLength); err != nil {
+		return nil, err
+	}
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&patch); err != nil {
+		return nil, jsonBindError(err, int64(len(body)))
+	}
+	if patch == nil {
+		return nil, &BodyError{Message: "merge patch must be a JSON object"}
+	}
+	return patch, nil
+}
+
+// applyMergePatch применяет патч к документу по RFC 7386: null удаляет ключ, объект
+// применяется к вложенному объекту рекурсивно, любое другое значение заменяет прежнее,
+// а отсутствующие в патче ключи не меняются.
+func applyMergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{})
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			current, _ := target[key].(map[string]interface{})
+			target[key] = applyMergePatch(current, nested)
+			continue
+		}
+		target[key] = value
+	}
+	return target
+}
+
+// mergeItemPatch применяет патч к JSON-представлению элемента и разбирает результат так же строго,
+// как тело PUT: неизвестные поля и значения неверного типа возвращаются как *FieldError.
+func mergeItemPatch(item model.Item, patch map[string]interface{}) (model.Item, error) {
+	current, err := json.Marshal(item)
+	if err != nil {
+		return model.Item{}, err
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(current, &document); err != nil {
+		return model.Item{}, err
+	}
+
+	merged, err := json.Marshal(applyMergePatch(document, patch))
+	if err != nil {
+		return model.Item{}, err
+	}
+
+	var result model.Item
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return model.Item{}, jsonBindError(err, int64(len(merged)))
+	}
+	if err := binding.Validator.ValidateStruct(&result); err != nil {
+		return model.Item{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// patchItem отправляет элементу JSON Merge Patch
+func (ts *testServer) patchItem(token string, item model.Item, patch string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	req := ts.newRequest(http.MethodPatch, "/api/v1/wishlists/"+item.WishlistID+"/items/"+item.ID, token, patch)
+	req.Header.Set("Content-Type", mergePatchContentType)
+	return ts.serve(req)
+}
+
+func TestItemMergePatch(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{
+		"name":        "Lamp",
+		"description": "Warm light",
+		"price":       "10.00",
+		"link":        "https://shop.example.com/lamp",
+		"category":    "Light",
+	})
+
+	// null очищает поле, новое значение заменяет прежнее, отсутствующие ключи не меняются
+	rec := ts.patchItem(owner.ID, item, `{"description": null, "price": "25.00", "is_purchased": true, "version": 1}`)
+	expectStatus(t, rec, http.StatusOK)
+	var patched model.Item
+	decodeBody(t, rec, &patched)
+	if patched.Description != "" || patched.Price != "25.00" || !patched.IsPurchased {
+		t.Errorf("patched fields = %+v", patched)
+	}
+	if patched.Name != "Lamp" || patched.Link != "https://shop.example.com/lamp" || patched.Category != "Light" {
+		t.Errorf("absent fields changed: %+v", patched)
+	}
+	if patched.Version != 2 {
+		t.Errorf("version = %d, want 2", patched.Version)
+	}
+	if stored := ts.items(owner.ID, wishlist.ID)[0]; stored.Description != "" || stored.Price != "25.00" || stored.Name != "Lamp" {
+		t.Errorf("stored item = %+v", stored)
+	}
+
+	// Обычный PUT по-прежнему заменяет элемент целиком
+	rec = ts.do(http.MethodPut, "/api/v1/wishlists/"+wishlist.ID+"/items/"+item.ID, owner.ID, gin.H{"name": "Desk lamp", "version": 2})
+	expectStatus(t, rec, http.StatusOK)
+	var replaced model.Item
+	decodeBody(t, rec, &replaced)
+	if replaced.Name != "Desk lamp" || replaced.Price != "" || replaced.Link != "" || replaced.Category != "" {
+		t.Errorf("replaced item = %+v", replaced)
+	}
+}
+
+func TestItemMergePatchRejected(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Home")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp", "price": "10.00"})
+
+	for name, tc := range map[string]struct {
+		patch string
+		field string
+	}{
+		// Обязательное поле нельзя очистить; ошибка валидатора, как и у PUT, без имени поля
+		"required cleared": {`{"name": null, "version": 1}`, ""},
+		"wrong type":       {`{"price": 10, "version": 1}`, "price"},
+		"unknown field":    {`{"colour": "red", "version": 1}`, "colour"},
+	} {
+		rec := ts.patchItem(owner.ID, item, tc.patch)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400; body: %s", name, rec.Code, rec.Body.String())
+			continue
+		}
+		if tc.field != "" {
+			expectFieldError(t, rec, tc.field)
+		}
+	}
+	rec := ts.patchItem(owner.ID, item, `["name"]`)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	if stored := ts.items(owner.ID, wishlist.ID)[0]; stored.Name != "Lamp" || stored.Price != "10.00" || stored.Version != 1 {
+		t.Errorf("item after rejected patches = %+v", stored)
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestUnsupportedMethodReturns405(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	for _, tc := range []struct {
+		method, path, token string
+		allow               string
+	}{
+		{http.MethodPatch, "/api/v1/wishlists/" + wishlist.ID, owner.ID, "DELETE,GET,PUT"},
+		{http.MethodPatch, "/api/wishlists/" + wishlist.ID, owner.ID, "DELETE,GET,PUT"},
+		{http.MethodDelete, "/api/v1/stats", owner.ID, "GET"},
+		{http.MethodGet, "/auth/login", "", "POST"},
+		{http.MethodPut, "/auth/register", "", "POST"},
+	} {
+		rec := ts.do(tc.method, tc.path, tc.token, nil)
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: status = %d, want 405", tc.method, tc.path, rec.Code)
+			continue
+		}
+		var body map[string]string
+		decodeBody(t, rec, &body)
+		if body["code"] != "METHOD_NOT_ALLOWED" {
+			t.Errorf("%s %s: body = %v", tc.method, tc.path, body)
+		}
+
+		allowed := strings.Split(rec.Header().Get("Allow"), ", ")
+		sort.Strings(allowed)
+		if got := strings.Join(allowed, ","); got != tc.allow {
+			t.Errorf("%s %s: Allow = %q, want %s", tc.method, tc.path, rec.Header().Get("Allow"), tc.allow)
+		}
+	}
+
+	// Неизвестный путь по-прежнему отвечает 404
+	expectStatus(t, ts.do(http.MethodPatch, "/api/v1/no-such-route", owner.ID, nil), http.StatusNotFound)
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Маршруты пакетных операций, для которых действует увеличенный лимит тела запроса
+var batchRoutes = map[string]bool{
+	apiPrefix + "/wishlists/import": true,
+	"/api/wishlists/import":         true,
+}
+
+// Middleware для ограничения размера тела запроса.
+// Тело читается не больше лимита, при превышении запрос отклоняется с 413.
+func bodyLimitMiddleware(c *gin.Context) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		c.Next()
+		return
+	}
+
+	limit := maxBodyBytes
+	if batchRoutes[c.FullPath()] {
+		limit = maxBatchBodyBytes
+	}
+
+	if c.Request.ContentLength > limit {
+		abortPayloadTooLarge(c, limit)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+	c.Request.Body.Close()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "could not read request body"})
+		return
+	}
+	if int64(len(body)) > limit {
+		abortPayloadTooLarge(c, limit)
+		return
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Next()
+}
+
+func abortPayloadTooLarge(c *gin.Context, limit int64) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error": "request body is too large",
+		"limit": limit,
+	})
+}
+
+// configureTrustedProxies настраивает, от каких прокси принимается X-Forwarded-For.
+// При некорректном списке сервер не доверяет ни одному прокси.
+func configureTrustedProxies(r *gin.Engine, proxies []string) {
+	r.RemoteIPHeaders = []string{"X-Forwarded-For"}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		log.Printf("invalid TRUSTED_PROXIES: %v, trusting no proxies", err)
+		r.SetTrustedProxies(nil)
+	}
+}
+
+// clientIP возвращает адрес клиента. X-Forwarded-For учитывается,
+// только если запрос пришел от доверенного прокси.
+func clientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// Middleware для заголовков безопасности. Ставится первым, чтобы заголовки
+// попадали и в ответы с ошибками, включая 404 и ответы других middleware.
+func securityHeadersMiddleware(c *gin.Context) {
+	h := c.Writer.Header()
+	if securityHeaders {
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+	}
+	if contentSecurityPolicy != "off" {
+		h.Set("Content-Security-Policy", contentSecurityPolicy)
+	}
+	// HSTS имеет смысл только для ответов по HTTPS
+	if hstsMaxAge > 0 && c.Request.TLS != nil {
+		h.Set("Strict-Transport-Security", "max-age="+strconv.FormatInt(hstsMaxAge, 10))
+	}
+	c.Next()
+}
+
+// Заголовок с идентификатором запроса: принимается от клиента или прокси и возвращается в ответе
+const requestIDHeader = "X-Request-ID"
+
+// Идентификатор от клиента принимается, только если он короткий и не содержит лишних символов,
+// иначе в лог и ответ попадала бы произвольная строка
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// requestIDMiddleware назначает запросу идентификатор для сопоставления ответа с записями в логе
+func requestIDMiddleware(c *gin.Context) {
+	id := c.GetHeader(requestIDHeader)
+	if !requestIDPattern.MatchString(id) {
+		id = uuid.New().String()
+	}
+	c.Set("requestID", id)
+	c.Header(requestIDHeader, id)
+	c.Next()
+}
+
+// recoverPanic отвечает на запрос, обработчик которого запаниковал: 500 с кодом INTERNAL
+// и идентификатором запроса. Стек gin уже записал в лог, в ответ он не попадает
+func recoverPanic(c *gin.Context, err any) {
+	requestID := c.GetString("requestID")
+	log.Printf("panic in %s %s (request %s): %v", c.Request.Method, c.FullPath(), requestID, err)
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+		"error":      "internal server error",
+		"code":       "INTERNAL",
+		"request_id": requestID,
+	})
+}
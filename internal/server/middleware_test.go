@@ -0,0 +1,165 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useBodyLimits задает лимиты тела запроса до конца теста
+func useBodyLimits(t *testing.T, regular, batch int64) {
+	t.Helper()
+	previousRegular, previousBatch := maxBodyBytes, maxBatchBodyBytes
+	maxBodyBytes, maxBatchBodyBytes = regular, batch
+	t.Cleanup(func() { maxBodyBytes, maxBatchBodyBytes = previousRegular, previousBatch })
+}
+
+func TestOversizedBodyRejected(t *testing.T) {
+	useBodyLimits(t, 256, 1024)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	body := `{"title": "` + strings.Repeat("a", 300) + `"}`
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, body)
+	expectStatus(t, rec, http.StatusRequestEntityTooLarge)
+
+	// Клиент может не сообщать длину тела заранее, лимит проверяется и при чтении
+	req := ts.newRequest(http.MethodPost, "/api/v1/wishlists", owner.ID, body)
+	req.ContentLength = -1
+	expectStatus(t, ts.serve(req), http.StatusRequestEntityTooLarge)
+
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	if len(ts.srv.wishlists) != 0 {
+		t.Errorf("store has %d wishlists after rejected requests", len(ts.srv.wishlists))
+	}
+}
+
+func TestBatchRouteHasHigherBodyLimit(t *testing.T) {
+	useBodyLimits(t, 256, 1024)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	items := make([]gin.H, 6)
+	for i := range items {
+		items[i] = gin.H{"name": "Item with a reasonably long name"}
+	}
+	bundle := gin.H{"wishlist": gin.H{"title": "Imported"}, "items": items}
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/import", owner.ID, bundle)
+	expectStatus(t, rec, http.StatusCreated)
+
+	// Тот же объем в обычный маршрут не проходит
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, gin.H{"title": "List", "description": strings.Repeat("a", 300)})
+	expectStatus(t, rec, http.StatusRequestEntityTooLarge)
+
+	// Лимит пакетного маршрута тоже ограничен
+	for len(items) < 40 {
+		items = append(items, items[0])
+	}
+	bundle["items"] = items
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/import", owner.ID, bundle)
+	expectStatus(t, rec, http.StatusRequestEntityTooLarge)
+}
+
+// useSecurityHeaders задает настройки заголовков безопасности до конца теста
+func useSecurityHeaders(t *testing.T, enabled bool, csp string, hsts int64) {
+	t.Helper()
+	previousEnabled, previousCSP, previousHSTS := securityHeaders, contentSecurityPolicy, hstsMaxAge
+	securityHeaders, contentSecurityPolicy, hstsMaxAge = enabled, csp, hsts
+	t.Cleanup(func() {
+		securityHeaders, contentSecurityPolicy, hstsMaxAge = previousEnabled, previousCSP, previousHSTS
+	})
+}
+
+func TestSecurityHeadersPresent(t *testing.T) {
+	useSecurityHeaders(t, true, "default-src 'none'", 3600)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	for name, rec := range map[string]*httptest.ResponseRecorder{
+		"success":       ts.do(http.MethodGet, "/api/v1/wishlists", owner.ID, nil),
+		"unauthorized":  ts.do(http.MethodGet, "/api/v1/wishlists", "", nil),
+		"unknown route": ts.do(http.MethodGet, "/no-such-route", "", nil),
+	} {
+		for header, want := range map[string]string{
+			"X-Content-Type-Options":  "nosniff",
+			"X-Frame-Options":         "DENY",
+			"Content-Security-Policy": "default-src 'none'",
+		} {
+			if got := rec.Header().Get(header); got != want {
+				t.Errorf("%s: %s = %q, want %q", name, header, got, want)
+			}
+		}
+		// Запрос пришел без TLS, поэтому HSTS не отправляется
+		if got := rec.Header().Get("Strict-Transport-Security"); got != "" {
+			t.Errorf("%s: Strict-Transport-Security = %q over plain HTTP", name, got)
+		}
+	}
+
+	req := ts.newRequest(http.MethodGet, "/api/v1/wishlists", owner.ID, nil)
+	req.TLS = &tls.ConnectionState{}
+	if got := ts.serve(req).Header().Get("Strict-Transport-Security"); got != "max-age=3600" {
+		t.Errorf("Strict-Transport-Security over TLS = %q, want max-age=3600", got)
+	}
+}
+
+func TestSecurityHeadersDisabled(t *testing.T) {
+	useSecurityHeaders(t, false, "off", 0)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	req := ts.newRequest(http.MethodGet, "/api/v1/wishlists", owner.ID, nil)
+	req.TLS = &tls.ConnectionState{}
+	rec := ts.serve(req)
+	expectStatus(t, rec, http.StatusOK)
+	for _, header := range []string{"X-Content-Type-Options", "X-Frame-Options", "Content-Security-Policy", "Strict-Transport-Security"} {
+		if got := rec.Header().Get(header); got != "" {
+			t.Errorf("%s = %q with security headers disabled", header, got)
+		}
+	}
+}
+
+func TestPanicRecoveredWithRequestID(t *testing.T) {
+	// Стек пишется в лог ошибок gin, который recovery запоминает при создании роутера
+	var errorLog bytes.Buffer
+	previousWriter := gin.DefaultErrorWriter
+	gin.DefaultErrorWriter = &errorLog
+	t.Cleanup(func() { gin.DefaultErrorWriter = previousWriter })
+
+	ts := newTestServer(t)
+	ts.router.GET("/api/v1/explode", func(c *gin.Context) {
+		panic("handler exploded")
+	})
+
+	for name, sentID := range map[string]string{"client id": "trace-123", "generated id": ""} {
+		req := ts.newRequest(http.MethodGet, "/api/v1/explode", "", nil)
+		if sentID != "" {
+			req.Header.Set(requestIDHeader, sentID)
+		}
+		rec := ts.serve(req)
+		expectStatus(t, rec, http.StatusInternalServerError)
+
+		var body map[string]string
+		decodeBody(t, rec, &body)
+		requestID := rec.Header().Get(requestIDHeader)
+		if requestID == "" || (sentID != "" && requestID != sentID) {
+			t.Errorf("%s: %s header = %q", name, requestIDHeader, requestID)
+		}
+		if len(body) != 3 || body["code"] != "INTERNAL" || body["error"] != "internal server error" || body["request_id"] != requestID {
+			t.Errorf("%s: body = %v, want the INTERNAL envelope with request_id %q", name, body, requestID)
+		}
+		if strings.Contains(rec.Body.String(), "exploded") || strings.Contains(rec.Body.String(), "goroutine") {
+			t.Errorf("%s: body leaks panic details: %s", name, rec.Body.String())
+		}
+	}
+
+	if logged := errorLog.String(); !strings.Contains(logged, "handler exploded") || !strings.Contains(logged, "middleware_test.go") {
+		t.Errorf("panic stack was not logged: %q", logged)
+	}
+}
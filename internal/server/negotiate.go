@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"wana/internal/model"
+)
+
+// Форматы ответа, которые можно запросить заголовком Accept. JSON используется по умолчанию.
+var negotiatedFormats = []string{binding.MIMEJSON, binding.MIMEXML, binding.MIMEXML2}
+
+// negotiateFormat выбирает формат ответа по заголовку Accept.
+// Если ни один формат не подходит, отвечает 406 и возвращает false.
+func negotiateFormat(c *gin.Context) (string, bool) {
+	c.Writer.Header().Add("Vary", "Accept")
+
+	format := c.NegotiateFormat(negotiatedFormats...)
+	if format == "" {
+		c.JSON(http.StatusNotAcceptable, gin.H{
+			"error":     "unsupported Accept type",
+			"supported": negotiatedFormats,
+		})
+		return "", false
+	}
+	return format, true
+}
+
+// render отправляет данные в выбранном формате. Для XML можно передать
+// отдельное представление, если данные нельзя закодировать в XML напрямую.
+func render(c *gin.Context, format string, status int, data, xmlData interface{}) {
+	if format == binding.MIMEJSON {
+		c.JSON(status, data)
+		return
+	}
+	if xmlData == nil {
+		xmlData = data
+	}
+	c.XML(status, xmlData)
+}
+
+// XML-представления списков элементов: encoding/xml не умеет кодировать
+// срезы без корневого элемента и словари
+type itemListXML struct {
+	XMLName xml.Name     `xml:"items"`
+	Total   int          `xml:"total,attr"`
+	Limit   int          `xml:"limit,attr"`
+	Offset  int          `xml:"offset,attr"`
+	Items   []model.Item `xml:"item"`
+}
+
+type itemGroupXML struct {
+	Category string       `xml:"category,attr"`
+	Items    []model.Item `xml:"item"`
+}
+
+type itemGroupsXML struct {
+	XMLName xml.Name       `xml:"groups"`
+	Total   int            `xml:"total,attr"`
+	Limit   int            `xml:"limit,attr"`
+	Offset  int            `xml:"offset,attr"`
+	Groups  []itemGroupXML `xml:"group"`
+}
+
+// groupsToXML переводит страницу сгруппированных элементов в XML-представление, упорядочивая группы по категории
+func groupsToXML(page model.ItemGroupPage) itemGroupsXML {
+	result := itemGroupsXML{
+		Total:  page.Total,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+		Groups: make([]itemGroupXML, 0, len(page.Groups)),
+	}
+	for category, list := range page.Groups {
+		result.Groups = append(result.Groups, itemGroupXML{Category: category, Items: list})
+	}
+	sort.Slice(result.Groups, func(i, j int) bool {
+		return result.Groups[i].Category < result.Groups[j].Category
+	})
+	return result
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// getAccepting запрашивает path с заголовком Accept
+func (ts *testServer) getAccepting(path, token, accept string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	req := ts.newRequest(http.MethodGet, path, token, nil)
+	req.Header.Set("Accept", accept)
+	return ts.serve(req)
+}
+
+func TestWishlistXMLMatchesJSON(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	path := "/api/v1/wishlists/" + wishlist.ID
+
+	rec := ts.getAccepting(path, owner.ID, "application/json")
+	expectStatus(t, rec, http.StatusOK)
+	var fromJSON model.Wishlist
+	decodeBody(t, rec, &fromJSON)
+
+	rec = ts.getAccepting(path, owner.ID, "application/xml")
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "application/xml; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	var fromXML model.Wishlist
+	if err := xml.Unmarshal(rec.Body.Bytes(), &fromXML); err != nil {
+		t.Fatalf("decode XML %q: %v", rec.Body.String(), err)
+	}
+
+	fromXML.XMLName = fromJSON.XMLName
+	if !reflect.DeepEqual(fromJSON, fromXML) {
+		t.Errorf("XML wishlist = %+v\nJSON wishlist = %+v", fromXML, fromJSON)
+	}
+}
+
+func TestItemsXMLMatchesJSON(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp", "price": "10", "category": "home"})
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Book & notes"})
+	path := "/api/v1/wishlists/" + wishlist.ID + "/items"
+
+	rec := ts.getAccepting(path, owner.ID, "application/json")
+	expectStatus(t, rec, http.StatusOK)
+	var fromJSON model.ItemPage
+	decodeBody(t, rec, &fromJSON)
+
+	rec = ts.getAccepting(path, owner.ID, "text/xml")
+	expectStatus(t, rec, http.StatusOK)
+	var fromXML itemListXML
+	if err := xml.Unmarshal(rec.Body.Bytes(), &fromXML); err != nil {
+		t.Fatalf("decode XML %q: %v", rec.Body.String(), err)
+	}
+
+	if fromXML.Total != fromJSON.Total || len(fromXML.Items) != len(fromJSON.Items) || len(fromJSON.Items) != 2 {
+		t.Fatalf("XML page total %d with %d items, JSON page total %d with %d items",
+			fromXML.Total, len(fromXML.Items), fromJSON.Total, len(fromJSON.Items))
+	}
+	for i := range fromJSON.Items {
+		fromXML.Items[i].XMLName = fromJSON.Items[i].XMLName
+		if !reflect.DeepEqual(fromJSON.Items[i], fromXML.Items[i]) {
+			t.Errorf("XML item %d = %+v\nJSON item = %+v", i, fromXML.Items[i], fromJSON.Items[i])
+		}
+	}
+}
+
+func TestUnsupportedAcceptType(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	for _, path := range []string{"/api/v1/wishlists/" + wishlist.ID, "/api/v1/wishlists/" + wishlist.ID + "/items"} {
+		rec := ts.getAccepting(path, owner.ID, "text/csv")
+		expectStatus(t, rec, http.StatusNotAcceptable)
+		var body struct {
+			Supported []string `json:"supported"`
+		}
+		decodeBody(t, rec, &body)
+		if len(body.Supported) == 0 {
+			t.Errorf("GET %s: 406 body lists no supported types: %s", path, rec.Body.String())
+		}
+	}
+
+	// Без Accept ответ - JSON
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID, owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if !json.Valid(rec.Body.Bytes()) {
+		t.Errorf("default response is not JSON: %s", rec.Body.String())
+	}
+}
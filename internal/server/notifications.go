@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Максимальное число хранимых уведомлений на пользователя, старые вытесняются
+const maxNotificationsPerUser = 200
+
+// Типы уведомлений
+const (
+	notificationWishlistShared      = "wishlist.shared"
+	notificationWishlistTransferred = "wishlist.transferred"
+	notificationCommentAdded        = "comment.added"
+	notificationItemReserved        = "item.reserved"
+	notificationReservationCleared  = "reservation.cleared"
+)
+
+type Notification struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Type      string    `json:"type"`
+	Message   string    `json:"message"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// notify добавляет уведомление во входящие пользователя.
+// Вызывается под блокировкой mu.
+func (s *MemStore) notify(userID, notificationType, message string) {
+	inbox := append(s.notifications[userID], Notification{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Type:      notificationType,
+		Message:   message,
+		CreatedAt: clock.Now(),
+	})
+	if len(inbox) > maxNotificationsPerUser {
+		inbox = append([]Notification(nil), inbox[len(inbox)-maxNotificationsPerUser:]...)
+	}
+	s.notifications[userID] = inbox
+}
+
+// unreadNotifications считает непрочитанные уведомления пользователя.
+// Вызывается под блокировкой mu.
+func (s *Server) unreadNotifications(userID string) int {
+	unread := 0
+	for _, n := range s.notifications[userID] {
+		if !n.Read {
+			unread++
+		}
+	}
+	return unread
+}
+
+func (s *Server) getNotifications(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Отдаем уведомления от новых к старым
+	inbox := s.notifications[userID]
+	start, end := pageBounds(len(inbox), limit, offset)
+	page := make([]Notification, 0, end-start)
+	for i := len(inbox) - 1 - start; i >= len(inbox)-end; i-- {
+		page = append(page, inbox[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": page,
+		"total":         len(inbox),
+		"unread":        s.unreadNotifications(userID),
+		"limit":         limit,
+		"offset":        offset,
+	})
+}
+
+func (s *Server) getUnreadNotificationCount(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c.JSON(http.StatusOK, gin.H{"unread": s.unreadNotifications(userID)})
+}
+
+func (s *Server) markNotificationRead(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	notificationID := c.Param("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inbox := s.notifications[userID]
+	for i := range inbox {
+		if inbox[i].ID == notificationID {
+			inbox[i].Read = true
+			c.JSON(http.StatusOK, inbox[i])
+			return
+		}
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+}
+
+func (s *Server) markAllNotificationsRead(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inbox := s.notifications[userID]
+	for i := range inbox {
+		inbox[i].Read = true
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": 0})
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type notificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	Total         int            `json:"total"`
+	Unread        int            `json:"unread"`
+}
+
+// notifications возвращает первую страницу уведомлений пользователя
+func (ts *testServer) notifications(token string) notificationPage {
+	ts.t.Helper()
+	rec := ts.do(http.MethodGet, "/api/v1/notifications", token, nil)
+	expectStatus(ts.t, rec, http.StatusOK)
+
+	var page notificationPage
+	decodeBody(ts.t, rec, &page)
+	return page
+}
+
+func TestShareNotifiesGrantee(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	ts.share(owner.ID, wishlist.ID, guest.ID, false)
+
+	page := ts.notifications(guest.ID)
+	if page.Total != 1 || page.Unread != 1 || len(page.Notifications) != 1 {
+		t.Fatalf("guest notifications = %+v", page)
+	}
+	n := page.Notifications[0]
+	if n.Type != notificationWishlistShared || n.UserID != guest.ID || n.Read {
+		t.Errorf("notification = %+v", n)
+	}
+	if want := `owner shared the wishlist "Birthday" with you`; n.Message != want {
+		t.Errorf("message = %q, want %q", n.Message, want)
+	}
+
+	// Владелец о своем действии уведомления не получает
+	if page := ts.notifications(owner.ID); page.Total != 0 {
+		t.Errorf("owner notifications = %+v", page.Notifications)
+	}
+}
+
+func TestReservationNotifiesOwner(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Book"})
+	ts.share(owner.ID, wishlist.ID, guest.ID, false)
+
+	path := "/api/v1/wishlists/" + wishlist.ID + "/items/" + item.ID + "/reserve"
+	expectStatus(t, ts.do(http.MethodPost, path, guest.ID, nil), http.StatusOK)
+	// Повторный резерв тем же гостем нового уведомления не создает
+	expectStatus(t, ts.do(http.MethodPost, path, guest.ID, nil), http.StatusOK)
+
+	page := ts.notifications(owner.ID)
+	if page.Total != 1 || page.Notifications[0].Type != notificationItemReserved {
+		t.Fatalf("owner notifications = %+v", page)
+	}
+	if want := `Someone reserved "Book" in "Birthday"`; page.Notifications[0].Message != want {
+		t.Errorf("message = %q, want %q", page.Notifications[0].Message, want)
+	}
+}
+
+func TestMarkNotificationRead(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	for _, title := range []string{"Birthday", "Wedding", "New Year"} {
+		ts.share(owner.ID, ts.createWishlist(owner.ID, title).ID, guest.ID, false)
+	}
+
+	page := ts.notifications(guest.ID)
+	if page.Unread != 3 {
+		t.Fatalf("unread = %d, want 3", page.Unread)
+	}
+	target := page.Notifications[1]
+
+	rec := ts.do(http.MethodPost, "/api/v1/notifications/"+target.ID+"/read", guest.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var read Notification
+	decodeBody(t, rec, &read)
+	if read.ID != target.ID || !read.Read {
+		t.Errorf("marked notification = %+v", read)
+	}
+
+	page = ts.notifications(guest.ID)
+	for _, n := range page.Notifications {
+		if n.Read != (n.ID == target.ID) {
+			t.Errorf("notification %s read = %v", n.ID, n.Read)
+		}
+	}
+
+	rec = ts.do(http.MethodGet, "/api/v1/notifications/unread-count", guest.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var count struct {
+		Unread int `json:"unread"`
+	}
+	decodeBody(t, rec, &count)
+	if count.Unread != 2 {
+		t.Errorf("unread count = %d, want 2", count.Unread)
+	}
+
+	// Чужое уведомление отметить нельзя
+	expectStatus(t, ts.do(http.MethodPost, "/api/v1/notifications/"+target.ID+"/read", owner.ID, nil), http.StatusNotFound)
+
+	expectStatus(t, ts.do(http.MethodPost, "/api/v1/notifications/read-all", guest.ID, nil), http.StatusOK)
+	if page := ts.notifications(guest.ID); page.Unread != 0 {
+		t.Errorf("unread after read-all = %d", page.Unread)
+	}
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// routeDoc описывает маршрут в OpenAPI-документе.
+// Request и Response - примеры типов тела запроса и ответа, схемы строятся по их полям.
+type routeDoc struct {
+	Summary  string
+	Request  interface{}
+	Response interface{}
+	Status   int
+}
+
+// Описания маршрутов: "МЕТОД путь" -> описание.
+// Сам список маршрутов берется из роутера, поэтому новый маршрут попадает в документ
+// даже без описания, а описание без маршрута игнорируется.
+var routeDocs = map[string]routeDoc{
+	"POST /auth/register": {Summary: "Register a new user", Request: model.RegisterRequest{}, Response: model.UserResponse{}, Status: http.StatusCreated},
+	"POST /auth/login":    {Summary: "Log in by username or email"},
+	"GET /auth/verify":    {Summary: "Confirm email address by token"},
+
+	"GET /api/v1/wishlists":                {Summary: "List own wishlists", Response: []model.WishlistSummary{}},
+	"GET /api/v1/wishlists/trash":          {Summary: "List deleted wishlists", Response: []model.Wishlist{}},
+	"POST /api/v1/wishlists":               {Summary: "Create a wishlist", Request: model.Wishlist{}, Response: model.Wishlist{}, Status: http.StatusCreated},
+	"POST /api/v1/wishlists/import":        {Summary: "Import a wishlist bundle", Request: WishlistBundle{}, Response: model.ImportResult{}, Status: http.StatusCreated},
+	"GET /api/v1/wishlists/:id":            {Summary: "Get a wishlist", Response: model.Wishlist{}},
+	"PUT /api/v1/wishlists/:id":            {Summary: "Update a wishlist", Request: model.Wishlist{}, Response: model.Wishlist{}},
+	"DELETE /api/v1/wishlists/:id":         {Summary: "Move a wishlist to trash", Status: http.StatusNoContent},
+	"POST /api/v1/wishlists/:id/duplicate": {Summary: "Duplicate a wishlist", Response: model.Wishlist{}, Status: http.StatusCreated},
+	"POST /api/v1/wishlists/:id/archive":   {Summary: "Archive a wishlist", Response: model.Wishlist{}},
+	"POST /api/v1/wishlists/:id/unarchive": {Summary: "Unarchive a wishlist", Response: model.Wishlist{}},
+	"POST /api/v1/wishlists/:id/restore":   {Summary: "Restore a wishlist from trash", Response: model.Wishlist{}},
+	"GET /api/v1/wishlists/:id/export":     {Summary: "Export a wishlist with items", Response: WishlistBundle{}},
+
+	"GET /api/v1/wishlists/:id/items":                                  {Summary: "List wishlist items", Response: model.ItemPage{}},
+	"POST /api/v1/wishlists/:id/items":                                 {Summary: "Add an item", Request: model.Item{}, Response: model.Item{}, Status: http.StatusCreated},
+	"POST /api/v1/wishlists/:id/items/purchase-all":                    {Summary: "Mark all items in a wishlist as purchased", Response: model.BulkPurchaseResult{}},
+	"POST /api/v1/wishlists/:id/items/unpurchase-all":                  {Summary: "Mark all items in a wishlist as not purchased", Response: model.BulkPurchaseResult{}},
+	"PUT /api/v1/wishlists/:id/items/:item_id":                         {Summary: "Update an item", Request: model.Item{}, Response: model.Item{}},
+	"PATCH /api/v1/wishlists/:id/items/:item_id":                       {Summary: "Update item fields with JSON Merge Patch", Response: model.Item{}},
+	"DELETE /api/v1/wishlists/:id/items/:item_id":                      {Summary: "Delete an item", Status: http.StatusNoContent},
+	"POST /api/v1/wishlists/:id/items/:item_id/copy":                   {Summary: "Copy an item to another wishlist", Request: itemTargetRequest{}, Response: model.Item{}, Status: http.StatusCreated},
+	"POST /api/v1/wishlists/:id/items/:item_id/move":                   {Summary: "Move an item to another wishlist", Request: itemTargetRequest{}, Response: model.Item{}},
+	"GET /api/v1/wishlists/:id/items/:item_id/comments":                {Summary: "List item comments", Response: []Comment{}},
+	"POST /api/v1/wishlists/:id/items/:item_id/comments":               {Summary: "Comment on an item", Response: Comment{}, Status: http.StatusCreated},
+	"DELETE /api/v1/wishlists/:id/items/:item_id/comments/:comment_id": {Summary: "Delete a comment", Status: http.StatusNoContent},
+	"POST /api/v1/wishlists/:id/items/:item_id/reserve":                {Summary: "Reserve an item in a shared wishlist", Response: model.Item{}},
+	"DELETE /api/v1/wishlists/:id/items/:item_id/reserve":              {Summary: "Release an item reservation", Response: model.Item{}},
+	"GET /api/v1/wishlists/:id/audit":                                  {Summary: "Get wishlist change log"},
+	"GET /api/v1/wishlists/:id/events":                                 {Summary: "Stream wishlist changes (SSE)", Response: StreamEvent{}},
+	"POST /api/v1/wishlists/:id/favorite":                              {Summary: "Add a wishlist to favorites"},
+	"DELETE /api/v1/wishlists/:id/favorite":                            {Summary: "Remove a wishlist from favorites"},
+
+	"POST /api/v1/items/preview": {Summary: "Fetch link preview", Response: LinkPreview{}},
+	"GET /api/v1/items/search":   {Summary: "Search accessible items"},
+
+	"POST /api/v1/wishlists/:id/public-links":          {Summary: "Create a public link", Response: PublicLink{}, Status: http.StatusCreated},
+	"GET /api/v1/wishlists/:id/public-links":           {Summary: "List public links", Response: []PublicLink{}},
+	"DELETE /api/v1/wishlists/:id/public-links/:token": {Summary: "Revoke a public link", Status: http.StatusNoContent},
+	"GET /public/:token":                               {Summary: "View a wishlist by public link", Response: model.PublicWishlist{}},
+	"GET /public/:token/view":                          {Summary: "View a wishlist by public link as an HTML page"},
+	"POST /public/:token/items/:item_id/claim":         {Summary: "Anonymously claim an item", Response: model.PublicItem{}},
+	"DELETE /public/:token/items/:item_id/claim":       {Summary: "Release an anonymous claim", Response: model.PublicItem{}},
+	"GET /api/v1/account":                              {Summary: "Get own profile", Response: model.UserResponse{}},
+	"PATCH /api/v1/account":                            {Summary: "Update own profile", Response: model.UserResponse{}},
+	"DELETE /api/v1/account":                           {Summary: "Delete own account", Status: http.StatusNoContent},
+	"POST /api/v1/account/password":                    {Summary: "Change password"},
+	"PUT /api/v1/account/webhook":                      {Summary: "Configure webhook"},
+	"DELETE /api/v1/account/webhook":                   {Summary: "Remove webhook", Status: http.StatusNoContent},
+	"POST /api/v1/wishlists/:id/share":                 {Summary: "Share a wishlist", Response: model.SharedWishlist{}, Status: http.StatusCreated},
+	"DELETE /api/v1/wishlists/:id/share/:user_id":      {Summary: "Revoke a user's access to a wishlist", Status: http.StatusNoContent},
+	"POST /api/v1/wishlists/:id/transfer":              {Summary: "Transfer wishlist ownership", Response: model.Wishlist{}},
+	"GET /api/v1/shared":                               {Summary: "List wishlists shared with me"},
+	"GET /api/v1/users/search":                         {Summary: "Find users to share with by username or email prefix"},
+	"POST /api/v1/shared/:wishlist_id/clone":           {Summary: "Clone a shared wishlist into own account", Response: model.Wishlist{}, Status: http.StatusCreated},
+
+	"GET /api/v1/notifications":              {Summary: "List notifications"},
+	"GET /api/v1/notifications/unread-count": {Summary: "Count unread notifications"},
+	"POST /api/v1/notifications/read-all":    {Summary: "Mark all notifications read"},
+	"POST /api/v1/notifications/:id/read":    {Summary: "Mark a notification read", Response: Notification{}},
+}
+
+// serveOpenAPI отдает OpenAPI-документ, построенный по маршрутам роутера
+func serveOpenAPI(r *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, buildOpenAPI(r.Routes()))
+	}
+}
+
+func buildOpenAPI(routes gin.RoutesInfo) gin.H {
+	schemas := gin.H{}
+	paths := gin.H{}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	for _, route := range routes {
+		// Устаревший псевдоним /api в документ не попадает
+		if !strings.HasPrefix(route.Path, apiPrefix+"/") && !strings.HasPrefix(route.Path, "/auth/") && !strings.HasPrefix(route.Path, "/public/") {
+			continue
+		}
+
+		doc := routeDocs[route.Method+" "+route.Path]
+		path, params := openAPIPath(route.Path)
+
+		status := doc.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := gin.H{"description": http.StatusText(status)}
+		if doc.Response != nil {
+			response["content"] = gin.H{
+				"application/json": gin.H{"schema": schemaFor(reflect.TypeOf(doc.Response), schemas)},
+			}
+		}
+
+		operation := gin.H{
+			"summary":   doc.Summary,
+			"responses": gin.H{strconv.Itoa(status): response},
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if doc.Request != nil {
+			operation["requestBody"] = gin.H{
+				"required": true,
+				"content": gin.H{
+					"application/json": gin.H{"schema": schemaFor(reflect.TypeOf(doc.Request), schemas)},
+				},
+			}
+		}
+		if strings.HasPrefix(route.Path, apiPrefix+"/") {
+			operation["security"] = []gin.H{{"bearerAuth": []string{}}}
+		}
+
+		item, _ := paths[path].(gin.H)
+		if item == nil {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":   "Wishlist API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": gin.H{
+			"schemas": schemas,
+			"securitySchemes": gin.H{
+				"bearerAuth": gin.H{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// openAPIPath переводит путь gin (:id) в шаблон OpenAPI ({id}) и описывает параметры пути
+func openAPIPath(ginPath string) (string, []gin.H) {
+	segments := strings.Split(ginPath, "/")
+	var params []gin.H
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, gin.H{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   gin.H{"type": "string"},
+			})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor строит JSON Schema по типу Go с учетом тегов json.
+// Именованные структуры выносятся в components/schemas и подставляются ссылкой.
+func schemaFor(t reflect.Type, schemas gin.H) gin.H {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return gin.H{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return gin.H{"type": "string"}
+	case reflect.Bool:
+		return gin.H{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return gin.H{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return gin.H{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return gin.H{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return gin.H{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		if _, exists := schemas[t.Name()]; !exists {
+			// Регистрируем имя заранее, чтобы рекурсивные типы не зацикливались
+			schemas[t.Name()] = gin.H{}
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return gin.H{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return gin.H{}
+	}
+}
+
+func structSchema(t reflect.Type, schemas gin.H) gin.H {
+	properties := gin.H{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Поля встроенных структур без тега json поднимаются на уровень выше
+		if field.Anonymous && name == "" {
+			embedded := structSchema(field.Type, schemas)
+			for key, value := range embedded["properties"].(gin.H) {
+				properties[key] = value
+			}
+			if req, ok := embedded["required"].([]string); ok {
+				required = append(required, req...)
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaFor(field.Type, schemas)
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			required = append(required, name)
+		}
+	}
+
+	schema := gin.H{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
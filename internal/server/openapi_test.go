@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type openAPIDocument struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas         map[string]json.RawMessage `json:"schemas"`
+		SecuritySchemes map[string]struct {
+			Type   string `json:"type"`
+			Scheme string `json:"scheme"`
+		} `json:"securitySchemes"`
+	} `json:"components"`
+}
+
+func (ts *testServer) openAPI() (openAPIDocument, string) {
+	ts.t.Helper()
+	rec := ts.do(http.MethodGet, "/openapi.json", "", nil)
+	expectStatus(ts.t, rec, http.StatusOK)
+	if !json.Valid(rec.Body.Bytes()) {
+		ts.t.Fatalf("document is not valid JSON: %s", rec.Body.String())
+	}
+
+	var doc openAPIDocument
+	decodeBody(ts.t, rec, &doc)
+	return doc, rec.Body.String()
+}
+
+func TestOpenAPIDocumentDescribesWishlists(t *testing.T) {
+	ts := newTestServer(t)
+	doc, raw := ts.openAPI()
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", doc.OpenAPI)
+	}
+	if scheme := doc.Components.SecuritySchemes["bearerAuth"]; scheme.Type != "http" || scheme.Scheme != "bearer" {
+		t.Errorf("bearerAuth scheme = %+v", scheme)
+	}
+
+	for path, methods := range map[string][]string{
+		"/api/v1/wishlists":                      {"get", "post"},
+		"/api/v1/wishlists/{id}":                 {"get", "put", "delete"},
+		"/api/v1/wishlists/{id}/items":           {"get", "post"},
+		"/api/v1/wishlists/{id}/items/{item_id}": {"put", "patch", "delete"},
+	} {
+		for _, method := range methods {
+			if _, ok := doc.Paths[path][method]; !ok {
+				t.Errorf("document has no %s %s", strings.ToUpper(method), path)
+			}
+		}
+	}
+	if _, ok := doc.Paths["/api/wishlists"]; ok {
+		t.Error("document describes the deprecated /api alias")
+	}
+
+	// Все ссылки на схемы разрешаются
+	for _, ref := range strings.Split(raw, `"$ref":"#/components/schemas/`)[1:] {
+		name, _, _ := strings.Cut(ref, `"`)
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schema %q is referenced but not defined", name)
+		}
+	}
+	if _, ok := doc.Components.Schemas["Wishlist"]; !ok {
+		t.Error("document has no Wishlist schema")
+	}
+}
+
+func TestOpenAPIDocumentMatchesRoutes(t *testing.T) {
+	ts := newTestServer(t)
+	doc, _ := ts.openAPI()
+
+	routes := map[string]bool{}
+	for _, route := range ts.router.Routes() {
+		routes[route.Method+" "+route.Path] = true
+		if !strings.HasPrefix(route.Path, apiPrefix+"/") {
+			continue
+		}
+		path, _ := openAPIPath(route.Path)
+		if _, ok := doc.Paths[path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("route %s %s is missing from the document", route.Method, route.Path)
+		}
+	}
+
+	// Описание без маршрута означает, что маршрут переименовали, а описание забыли
+	for key := range routeDocs {
+		if !routes[key] {
+			t.Errorf("routeDocs has %q, but the router has no such route", key)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Параметры постраничной выдачи по умолчанию
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// parsePagination читает параметры limit и offset из запроса
+func parsePagination(c *gin.Context) (limit, offset int, err error) {
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit)))
+	if err != nil || limit < 1 {
+		return 0, 0, errors.New("limit must be a positive integer")
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		return 0, 0, errors.New("offset must be a non-negative integer")
+	}
+
+	return limit, offset, nil
+}
+
+// pageBounds возвращает границы среза для страницы из total элементов
+func pageBounds(total, limit, offset int) (start, end int) {
+	if offset > total {
+		offset = total
+	}
+	end = offset + limit
+	if end > total {
+		end = total
+	}
+	return offset, end
+}
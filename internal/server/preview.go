@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/html"
+)
+
+// Ограничения на загрузку страницы для предпросмотра
+const (
+	previewTimeout  = 5 * time.Second
+	previewMaxBytes = 1 << 20
+)
+
+var errForbiddenAddress = errors.New("destination address is not allowed")
+
+// LinkPreview - данные, извлеченные со страницы товара
+type LinkPreview struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Image string `json:"image,omitempty"`
+	Price string `json:"price,omitempty"`
+}
+
+// Транспорт для исходящих запросов по адресам, заданным пользователями.
+// Адрес проверяется при каждом соединении, включая редиректы,
+// поэтому подмена DNS не позволяет обратиться к внутренней сети.
+var externalTransport = &http.Transport{
+	Proxy: nil,
+	DialContext: (&net.Dialer{
+		Timeout: previewTimeout,
+		Control: denyPrivateAddress,
+	}).DialContext,
+}
+
+var previewClient = &http.Client{
+	Timeout:   previewTimeout,
+	Transport: externalTransport,
+}
+
+func previewItem(c *gin.Context) {
+	var request struct {
+		URL string `json:"url" binding:"required"`
+	}
+
+	if err := bindJSON(c, &request); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	if err := validateURL("url", request.URL); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	preview, err := fetchLinkPreview(c.Request.Context(), request.URL)
+	if errors.Is(err, errForbiddenAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url points to a forbidden address"})
+		return
+	}
+	if c.Request.Context().Err() != nil {
+		// Время запроса истекло, ответ отправит timeoutMiddleware
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "could not fetch url"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+func fetchLinkPreview(ctx context.Context, rawURL string) (LinkPreview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return LinkPreview{}, err
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := previewClient.Do(req)
+	if err != nil {
+		return LinkPreview{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return LinkPreview{}, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	preview := parseLinkPreview(io.LimitReader(resp.Body, previewMaxBytes))
+	preview.URL = rawURL
+	return preview, nil
+}
+
+// parseLinkPreview извлекает <title> и OpenGraph-метатеги из HTML
+func parseLinkPreview(r io.Reader) LinkPreview {
+	var preview LinkPreview
+	var ogTitle string
+
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if preview.Title == "" {
+				preview.Title = ogTitle
+			}
+			return preview
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				if preview.Title == "" && tokenizer.Next() == html.TextToken {
+					preview.Title = strings.TrimSpace(string(tokenizer.Text()))
+				}
+			case "meta":
+				property, content := metaAttributes(token)
+				switch property {
+				case "og:title":
+					ogTitle = content
+				case "og:image":
+					preview.Image = content
+				case "og:price:amount", "product:price:amount":
+					preview.Price = content
+				}
+			}
+		}
+	}
+}
+
+func metaAttributes(token html.Token) (property, content string) {
+	for _, attr := range token.Attr {
+		switch attr.Key {
+		case "property", "name":
+			property = strings.ToLower(attr.Val)
+		case "content":
+			content = strings.TrimSpace(attr.Val)
+		}
+	}
+	return property, content
+}
+
+// denyPrivateAddress запрещает соединения с локальными и частными адресами
+func denyPrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || isPrivateIP(ip) {
+		return errForbiddenAddress
+	}
+	return nil
+}
+
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
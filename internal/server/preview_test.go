@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const productPage = `<!doctype html>
+<html><head>
+<title> Espresso Machine </title>
+<meta property="og:image" content="https://shop.example.com/espresso.jpg">
+<meta property="product:price:amount" content="249.00">
+</head><body>...</body></html>`
+
+func TestPreviewItemParsesPage(t *testing.T) {
+	shop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, productPage)
+	}))
+	defer shop.Close()
+
+	// Тестовый сервер слушает loopback, который внешний транспорт запрещает
+	previous := previewClient
+	previewClient = shop.Client()
+	defer func() { previewClient = previous }()
+
+	ts := newTestServer(t)
+	user := ts.addUser("shopper")
+
+	rec := ts.do(http.MethodPost, "/api/v1/items/preview", user.ID, gin.H{"url": shop.URL + "/espresso"})
+	expectStatus(t, rec, http.StatusOK)
+
+	var preview LinkPreview
+	decodeBody(t, rec, &preview)
+	want := LinkPreview{
+		URL:   shop.URL + "/espresso",
+		Title: "Espresso Machine",
+		Image: "https://shop.example.com/espresso.jpg",
+		Price: "249.00",
+	}
+	if preview != want {
+		t.Errorf("preview = %+v, want %+v", preview, want)
+	}
+}
+
+func TestPreviewItemRefusesPrivateAddress(t *testing.T) {
+	fetched := false
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = true
+	}))
+	defer internal.Close()
+
+	ts := newTestServer(t)
+	user := ts.addUser("shopper")
+
+	rec := ts.do(http.MethodPost, "/api/v1/items/preview", user.ID, gin.H{"url": internal.URL})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	var body map[string]string
+	decodeBody(t, rec, &body)
+	if body["error"] != "url points to a forbidden address" {
+		t.Errorf("error = %q", body["error"])
+	}
+	if fetched {
+		t.Errorf("private address was requested")
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resolveClientIP прогоняет запрос через движок с заданными доверенными прокси
+// и возвращает адрес клиента, который видят обработчики
+func resolveClientIP(t *testing.T, proxies []string, remoteAddr, forwardedFor string) string {
+	t.Helper()
+	r := gin.New()
+	configureTrustedProxies(r, proxies)
+	r.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, clientIP(c)) })
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec.Body.String()
+}
+
+func TestClientIPHonorsTrustedProxies(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		proxies      []string
+		remoteAddr   string
+		forwardedFor string
+		want         string
+	}{
+		{"no proxies trusted", nil, "10.0.0.7:4000", "203.0.113.5", "10.0.0.7"},
+		{"trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.7:4000", "203.0.113.5", "203.0.113.5"},
+		{"trusted single address", []string{"10.0.0.7"}, "10.0.0.7:4000", "203.0.113.5", "203.0.113.5"},
+		{"untrusted proxy", []string{"10.0.0.0/8"}, "192.0.2.1:4000", "203.0.113.5", "192.0.2.1"},
+		{"no header", []string{"10.0.0.0/8"}, "10.0.0.7:4000", "", "10.0.0.7"},
+		// Адрес, подставленный клиентом в начало цепочки, не принимается:
+		// берется первый справа адрес, не принадлежащий доверенным прокси
+		{"spoofed chain", []string{"10.0.0.0/8"}, "10.0.0.7:4000", "198.51.100.9, 203.0.113.5, 10.0.0.8", "203.0.113.5"},
+		{"invalid config", []string{"not-a-network"}, "10.0.0.7:4000", "203.0.113.5", "10.0.0.7"},
+	} {
+		if got := resolveClientIP(t, tc.proxies, tc.remoteAddr, tc.forwardedFor); got != tc.want {
+			t.Errorf("%s: client IP = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRateLimitKeyUsesForwardedClient(t *testing.T) {
+	previousProxies, previousRate, previousBurst := trustedProxies, apiRatePerMinute, apiRateBurst
+	trustedProxies, apiRatePerMinute, apiRateBurst = []string{"10.0.0.0/8"}, 1, 1
+	t.Cleanup(func() { trustedProxies, apiRatePerMinute, apiRateBurst = previousProxies, previousRate, previousBurst })
+	ts := newTestServer(t)
+
+	viewPublic := func(remoteAddr, forwardedFor string) int {
+		req := ts.newRequest(http.MethodGet, "/public/unknown-token", "", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		return ts.serve(req).Code
+	}
+
+	// Клиенты за доверенным прокси ограничиваются по отдельности
+	if code := viewPublic("10.0.0.7:4000", "203.0.113.5"); code == http.StatusTooManyRequests {
+		t.Fatal("first request is rate limited")
+	}
+	if code := viewPublic("10.0.0.7:4000", "203.0.113.6"); code == http.StatusTooManyRequests {
+		t.Error("second client behind the proxy shares the first client's limit")
+	}
+	if code := viewPublic("10.0.0.7:4000", "203.0.113.5"); code != http.StatusTooManyRequests {
+		t.Errorf("repeated client: status = %d, want 429", code)
+	}
+
+	// Недоверенный адрес не может сменить ключ лимита подделанным заголовком
+	if code := viewPublic("192.0.2.1:4000", "203.0.113.7"); code == http.StatusTooManyRequests {
+		t.Fatal("first direct request is rate limited")
+	}
+	if code := viewPublic("192.0.2.1:4000", "203.0.113.8"); code != http.StatusTooManyRequests {
+		t.Errorf("spoofed X-Forwarded-For: status = %d, want 429", code)
+	}
+}
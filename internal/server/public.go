@@ -0,0 +1,356 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// Cookie с анонимным токеном гостя, отметившего подарок по публичной ссылке
+const (
+	claimantCookie       = "wana_claimant"
+	claimantCookieMaxAge = 365 * 24 * 60 * 60
+)
+
+// PublicLink - ссылка, по которой список можно посмотреть без аккаунта.
+// Ссылка без ExpiresAt действует, пока ее не отзовут.
+type PublicLink struct {
+	Token      string     `json:"token"`
+	WishlistID string     `json:"wishlist_id"`
+	URL        string     `json:"url"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// publicLinkActive сообщает, что срок действия ссылки не истек
+func publicLinkActive(link PublicLink) bool {
+	return link.ExpiresAt == nil || clock.Now().Before(*link.ExpiresAt)
+}
+
+// newRandomToken возвращает случайный токен в шестнадцатеричном виде
+func newRandomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (s *Server) createPublicLink(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	// Тело необязательно: без него ссылка бессрочная
+	var linkRequest struct {
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := bindStrictJSON(c, &linkRequest); err != nil {
+			c.JSON(http.StatusBadRequest, validationError(err))
+			return
+		}
+	}
+	if linkRequest.ExpiresAt != nil && !linkRequest.ExpiresAt.After(clock.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future", "field": "expires_at"})
+		return
+	}
+
+	token, err := newRandomToken(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not create public link"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID {
+		s.denyWishlistAccess(c, userID, wishlist, "only owner can share wishlist")
+		return
+	}
+
+	// Публичная ссылка - тоже способ поделиться списком
+	verified := s.users[userID].EmailVerified
+	if !verified {
+		c.JSON(http.StatusForbidden, gin.H{"error": "email must be verified to share wishlists"})
+		return
+	}
+
+	if s.publicLinkCount(wishlistID) >= maxPublicLinksPerWishlist {
+		abortShareLimitExceeded(c, "too many public links for this wishlist, revoke unused ones first", maxPublicLinksPerWishlist)
+		return
+	}
+
+	link := PublicLink{
+		Token:      token,
+		WishlistID: wishlistID,
+		URL:        baseURL + "/public/" + token,
+		CreatedAt:  clock.Now(),
+		ExpiresAt:  linkRequest.ExpiresAt,
+	}
+	s.publicLinks[token] = link
+
+	c.JSON(http.StatusCreated, link)
+}
+
+func (s *Server) getPublicLinks(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	links := []PublicLink{}
+	for _, link := range s.publicLinks {
+		if link.WishlistID == wishlistID {
+			links = append(links, link)
+		}
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+func (s *Server) deletePublicLink(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+	token := c.Param("token")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	link, exists := s.publicLinks[token]
+	if !exists || link.WishlistID != wishlistID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "public link not found"})
+		return
+	}
+
+	delete(s.publicLinks, token)
+	c.Status(http.StatusNoContent)
+}
+
+// findPublicWishlist возвращает список по токену публичной ссылки.
+// Истекшая ссылка считается несуществующей, как и отозванная.
+// Вызывается под блокировкой mu.
+func (s *Server) findPublicWishlist(token string) (model.Wishlist, bool) {
+	link, exists := s.publicLinks[token]
+	if !exists || !publicLinkActive(link) {
+		return model.Wishlist{}, false
+	}
+	return s.findWishlist(link.WishlistID)
+}
+
+// publicItem переводит элемент в публичное представление: кто зарезервировал
+// или отметил подарок, не раскрывается
+func publicItem(item model.Item, claimant string) model.PublicItem {
+	return model.PublicItem{
+		ID:           item.ID,
+		Name:         item.Name,
+		Description:  item.Description,
+		Price:        item.Price,
+		Link:         item.Link,
+		ImageURL:     item.ImageURL,
+		Category:     item.Category,
+		IsPurchased:  item.IsPurchased,
+		Reserved:     item.ReservedBy != "",
+		Claimed:      item.Claimed,
+		ClaimedByYou: item.Claimed && claimantMatches(item, claimant),
+	}
+}
+
+// claimantCookieSecure сообщает, что cookie гостя передается только по HTTPS: запрос пришел по TLS
+// или сервис открыт снаружи по https (BASE_URL), например за прокси, который снимает TLS
+func claimantCookieSecure(c *gin.Context) bool {
+	return c.Request.TLS != nil || strings.HasPrefix(baseURL, "https://")
+}
+
+// claimantMatches сообщает, принадлежит ли отметка подарка гостю с этим токеном
+func claimantMatches(item model.Item, claimant string) bool {
+	return claimant != "" && subtle.ConstantTimeCompare([]byte(item.ClaimToken), []byte(claimant)) == 1
+}
+
+// getPublicWishlist показывает список по публичной ссылке без аутентификации
+func (s *Server) getPublicWishlist(c *gin.Context) {
+	claimant, _ := c.Cookie(claimantCookie)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	response, exists := s.publicWishlist(c.Param("token"), claimant)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// publicWishlist собирает публичное представление списка по токену ссылки.
+// Вызывается под блокировкой mu.
+func (s *Server) publicWishlist(token, claimant string) (model.PublicWishlist, bool) {
+	wishlist, exists := s.findPublicWishlist(token)
+	if !exists {
+		return model.PublicWishlist{}, false
+	}
+
+	var items []model.Item
+	for _, item := range s.items {
+		if item.WishlistID == wishlist.ID {
+			items = append(items, item)
+		}
+	}
+	sortItemsByCreatedAt(items, false)
+
+	response := model.PublicWishlist{
+		Title:       wishlist.Title,
+		Description: wishlist.Description,
+		Items:       make([]model.PublicItem, 0, len(items)),
+	}
+	for _, item := range items {
+		response.Items = append(response.Items, publicItem(item, claimant))
+	}
+	return response, true
+}
+
+// claimItem позволяет гостю без аккаунта отметить, что он купит подарок.
+// Гость получает анонимный токен в cookie, по которому сможет снять отметку.
+// Владелец видит только сам факт отметки.
+func (s *Server) claimItem(c *gin.Context) {
+	claimant, err := c.Cookie(claimantCookie)
+	if err != nil || claimant == "" {
+		if claimant, err = newRandomToken(16); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "could not claim item"})
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findPublicWishlist(c.Param("token"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.Archived {
+		c.JSON(http.StatusConflict, gin.H{"error": "wishlist is archived"})
+		return
+	}
+
+	if wishlist.Locked {
+		abortWishlistLocked(c, "wishlist is locked")
+		return
+	}
+
+	item, err := s.getItemInWishlist(wishlist.ID, c.Param("item_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if item.IsPurchased {
+		c.JSON(http.StatusConflict, gin.H{"error": "item is already purchased"})
+		return
+	}
+
+	if item.Claimed || item.ReservedBy != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "item is already claimed"})
+		return
+	}
+
+	item.Claimed = true
+	item.ClaimToken = claimant
+	item.UpdatedAt = clock.Now()
+	item.Version++
+
+	s.putItem(item)
+	s.touchItems(wishlist.ID)
+	s.recordAudit(wishlist.ID, "", auditItemClaimed, item.ID)
+	publishWishlistEvent(wishlist.ID, auditItemClaimed, item)
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(claimantCookie, claimant, claimantCookieMaxAge, "/public", "", claimantCookieSecure(c), true)
+	c.JSON(http.StatusOK, publicItem(item, claimant))
+}
+
+// unclaimItem снимает отметку гостя. Снять ее можно только с тем же токеном из cookie.
+func (s *Server) unclaimItem(c *gin.Context) {
+	claimant, _ := c.Cookie(claimantCookie)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findPublicWishlist(c.Param("token"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.Locked {
+		abortWishlistLocked(c, "wishlist is locked")
+		return
+	}
+
+	item, err := s.getItemInWishlist(wishlist.ID, c.Param("item_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !item.Claimed {
+		c.JSON(http.StatusOK, publicItem(item, claimant))
+		return
+	}
+
+	if !claimantMatches(item, claimant) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "item was claimed by someone else"})
+		return
+	}
+
+	item.Claimed = false
+	item.ClaimToken = ""
+	item.UpdatedAt = clock.Now()
+	item.Version++
+
+	s.putItem(item)
+	s.touchItems(wishlist.ID)
+	s.recordAudit(wishlist.ID, "", auditItemUnclaimed, item.ID)
+	publishWishlistEvent(wishlist.ID, auditItemUnclaimed, item)
+
+	c.JSON(http.StatusOK, publicItem(item, claimant))
+}
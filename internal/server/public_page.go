@@ -0,0 +1,70 @@
+package server
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Страница списка по публичной ссылке для тех, кто открывает ссылку в браузере.
+// html/template экранирует весь пользовательский текст, а в href пропускает только безопасные адреса
+var publicPageTemplate = template.Must(template.New("public").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{if .Items}}<ul>
+{{range .Items}}<li>
+{{if .Link}}<a href="{{.Link}}" rel="noopener noreferrer nofollow">{{.Name}}</a>{{else}}{{.Name}}{{end}}
+{{if .Price}} - {{.Price}}{{end}}
+{{if .IsPurchased}} (purchased){{else if or .Reserved .Claimed}} (taken){{end}}
+{{if .Description}}<div>{{.Description}}</div>{{end}}
+</li>
+{{end}}</ul>{{else}}<p>This wishlist is empty.</p>{{end}}
+</body>
+</html>
+`))
+
+// Страница для отозванной, истекшей или несуществующей ссылки
+const publicPageNotFound = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Wishlist not found</title>
+</head>
+<body>
+<h1>Wishlist not found</h1>
+<p>This link is no longer valid. Ask the owner of the wishlist for a new one.</p>
+</body>
+</html>
+`
+
+// viewPublicWishlist показывает список по публичной ссылке в виде HTML-страницы только для чтения
+func (s *Server) viewPublicWishlist(c *gin.Context) {
+	s.mu.RLock()
+	wishlist, exists := s.publicWishlist(c.Param("token"), "")
+	s.mu.RUnlock()
+
+	if !exists {
+		c.Data(http.StatusNotFound, "text/html; charset=utf-8", []byte(publicPageNotFound))
+		return
+	}
+
+	// Страница собирается в буфер, чтобы при ошибке шаблона не отдать клиенту ее половину
+	var page bytes.Buffer
+	if err := publicPageTemplate.Execute(&page, wishlist); err != nil {
+		log.Printf("render public wishlist page: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not render wishlist"})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPublicPageRendersItems(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, `Birthday & "friends"`)
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp", "price": "10.00", "link": "https://shop.example.com/lamp?a=1&b=2"})
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": `<script>alert("x")</script>`, "description": "<b>bold</b>"})
+	link := ts.publicLink(owner.ID, wishlist.ID)
+
+	rec := ts.guestDo(http.MethodGet, "/public/"+link.Token+"/view", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	page := rec.Body.String()
+	for _, want := range []string{
+		"<h1>Birthday &amp; &#34;friends&#34;</h1>",
+		`<a href="https://shop.example.com/lamp?a=1&amp;b=2" rel="noopener noreferrer nofollow">Lamp</a>`,
+		" - 10.00",
+		"&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;",
+		"&lt;b&gt;bold&lt;/b&gt;",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page has no %s:\n%s", want, page)
+		}
+	}
+	// Пользовательский текст не попадает на страницу как разметка
+	if strings.Contains(page, "<script>") || strings.Contains(page, "<b>") {
+		t.Errorf("page contains unescaped user content:\n%s", page)
+	}
+}
+
+func TestPublicPageRevokedLinkNotFound(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	link := ts.publicLink(owner.ID, wishlist.ID)
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+wishlist.ID+"/public-links/"+link.Token, owner.ID, nil), http.StatusNoContent)
+
+	for _, path := range []string{"/public/" + link.Token + "/view", "/public/unknown/view"} {
+		rec := ts.guestDo(http.MethodGet, path, nil)
+		expectStatus(t, rec, http.StatusNotFound)
+		if !strings.Contains(rec.Body.String(), "<h1>Wishlist not found</h1>") {
+			t.Errorf("%s: body = %s", path, rec.Body.String())
+		}
+	}
+}
+
+func TestExpiredPublicLinkNotFound(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/public-links", owner.ID, gin.H{"expires_at": fake.Now().Add(time.Hour)})
+	expectStatus(t, rec, http.StatusCreated)
+	var link PublicLink
+	decodeBody(t, rec, &link)
+	if link.ExpiresAt == nil || !link.ExpiresAt.Equal(fake.Now().Add(time.Hour)) {
+		t.Fatalf("link expires_at = %v", link.ExpiresAt)
+	}
+	public := "/public/" + link.Token
+	claim := public + "/items/" + item.ID + "/claim"
+
+	// До истечения ссылка работает как обычно
+	expectStatus(t, ts.guestDo(http.MethodGet, public, nil), http.StatusOK)
+	expectStatus(t, ts.guestDo(http.MethodGet, public+"/view", nil), http.StatusOK)
+	cookie := claimantCookieFrom(t, ts.guestDo(http.MethodPost, claim, nil))
+
+	fake.Advance(time.Hour)
+	expectStatus(t, ts.guestDo(http.MethodGet, public, nil), http.StatusNotFound)
+	rec = ts.guestDo(http.MethodGet, public+"/view", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+	if !strings.Contains(rec.Body.String(), "<h1>Wishlist not found</h1>") {
+		t.Errorf("view of expired link = %s", rec.Body.String())
+	}
+	expectStatus(t, ts.guestDo(http.MethodDelete, claim, cookie), http.StatusNotFound)
+	expectStatus(t, ts.guestDo(http.MethodPost, claim, nil), http.StatusNotFound)
+
+	// Владелец по-прежнему видит ссылку и может ее отозвать
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/public-links", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var links []PublicLink
+	decodeBody(t, rec, &links)
+	if len(links) != 1 || links[0].Token != link.Token {
+		t.Errorf("owner links = %+v", links)
+	}
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// publicLink создает публичную ссылку на список от имени владельца
+func (ts *testServer) publicLink(owner, wishlistID string) PublicLink {
+	ts.t.Helper()
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlistID+"/public-links", owner, nil)
+	expectStatus(ts.t, rec, http.StatusCreated)
+
+	var link PublicLink
+	decodeBody(ts.t, rec, &link)
+	return link
+}
+
+// guestDo выполняет запрос гостя без аккаунта с cookie отметки подарка, если она есть
+func (ts *testServer) guestDo(method, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	req := ts.newRequest(method, path, "", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	return ts.serve(req)
+}
+
+// claimantCookieFrom возвращает cookie гостя, выставленную в ответе
+func claimantCookieFrom(t *testing.T, rec *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == claimantCookie {
+			return cookie
+		}
+	}
+	t.Fatalf("response has no %s cookie: %v", claimantCookie, rec.Header())
+	return nil
+}
+
+func TestPublicClaimAndUnclaim(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	link := ts.publicLink(owner.ID, wishlist.ID)
+	claimPath := "/public/" + link.Token + "/items/" + item.ID + "/claim"
+
+	rec := ts.guestDo(http.MethodPost, claimPath, nil)
+	expectStatus(t, rec, http.StatusOK)
+	cookie := claimantCookieFrom(t, rec)
+	if !cookie.HttpOnly || cookie.Path != "/public" {
+		t.Errorf("cookie = %+v", cookie)
+	}
+	var claimed model.PublicItem
+	decodeBody(t, rec, &claimed)
+	if !claimed.Claimed || !claimed.ClaimedByYou {
+		t.Errorf("claimed item = %+v", claimed)
+	}
+
+	// Другой гость видит отметку, но не свою, и не может ни перехватить, ни снять ее
+	rec = ts.guestDo(http.MethodGet, "/public/"+link.Token, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var view model.PublicWishlist
+	decodeBody(t, rec, &view)
+	if len(view.Items) != 1 || !view.Items[0].Claimed || view.Items[0].ClaimedByYou {
+		t.Errorf("other guest view = %+v", view.Items)
+	}
+	expectStatus(t, ts.guestDo(http.MethodPost, claimPath, nil), http.StatusConflict)
+	expectStatus(t, ts.guestDo(http.MethodPost, claimPath, cookie), http.StatusConflict)
+	expectStatus(t, ts.guestDo(http.MethodDelete, claimPath, nil), http.StatusForbidden)
+	stranger := &http.Cookie{Name: claimantCookie, Value: "not-the-claimant"}
+	expectStatus(t, ts.guestDo(http.MethodDelete, claimPath, stranger), http.StatusForbidden)
+
+	rec = ts.guestDo(http.MethodDelete, claimPath, cookie)
+	expectStatus(t, rec, http.StatusOK)
+	var unclaimed model.PublicItem
+	decodeBody(t, rec, &unclaimed)
+	if unclaimed.Claimed || unclaimed.ClaimedByYou {
+		t.Errorf("unclaimed item = %+v", unclaimed)
+	}
+
+	// После снятия отметки подарок может отметить кто угодно
+	expectStatus(t, ts.guestDo(http.MethodPost, claimPath, nil), http.StatusOK)
+}
+
+func TestClaimCookieSecureOverHTTPS(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	link := ts.publicLink(owner.ID, wishlist.ID)
+	claim := func(overTLS bool) *http.Cookie {
+		t.Helper()
+		item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+		req := ts.newRequest(http.MethodPost, "/public/"+link.Token+"/items/"+item.ID+"/claim", "", nil)
+		if overTLS {
+			req.TLS = &tls.ConnectionState{}
+		}
+		rec := ts.serve(req)
+		expectStatus(t, rec, http.StatusOK)
+		return claimantCookieFrom(t, rec)
+	}
+
+	if cookie := claim(false); cookie.Secure {
+		t.Error("cookie over plain HTTP is Secure")
+	}
+	if cookie := claim(true); !cookie.Secure {
+		t.Error("cookie over TLS is not Secure")
+	}
+
+	// За прокси, который снимает TLS, признаком служит внешний адрес сервиса
+	previous := baseURL
+	baseURL = "https://wishes.example.com"
+	t.Cleanup(func() { baseURL = previous })
+	if cookie := claim(false); !cookie.Secure {
+		t.Error("cookie behind an HTTPS BASE_URL is not Secure")
+	}
+}
+
+func TestOwnerSeesClaimWithoutClaimant(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	link := ts.publicLink(owner.ID, wishlist.ID)
+
+	rec := ts.guestDo(http.MethodPost, "/public/"+link.Token+"/items/"+item.ID+"/claim", nil)
+	expectStatus(t, rec, http.StatusOK)
+	cookie := claimantCookieFrom(t, rec)
+
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/items", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if body := rec.Body.String(); strings.Contains(body, cookie.Value) || strings.Contains(body, "claim_token") {
+		t.Errorf("owner items reveal the claimant: %s", body)
+	}
+	var page model.ItemPage
+	decodeBody(t, rec, &page)
+	if len(page.Items) != 1 || !page.Items[0].Claimed || page.Items[0].ReservedBy != "" {
+		t.Errorf("owner items = %+v", page.Items)
+	}
+}
+
+func TestClaimReservedItemConflicts(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	item := ts.reservedItem(owner, guest)
+	link := ts.publicLink(owner.ID, item.WishlistID)
+
+	expectStatus(t, ts.guestDo(http.MethodPost, "/public/"+link.Token+"/items/"+item.ID+"/claim", nil), http.StatusConflict)
+	expectStatus(t, ts.guestDo(http.MethodPost, "/public/unknown-token/items/"+item.ID+"/claim", nil), http.StatusNotFound)
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+func (s *Server) purchaseAllItems(c *gin.Context) {
+	s.setAllItemsPurchased(c, true)
+}
+
+func (s *Server) unpurchaseAllItems(c *gin.Context) {
+	s.setAllItemsPurchased(c, false)
+}
+
+// setAllItemsPurchased меняет отметку о покупке у всех элементов списка за одну блокировку.
+// Отвечает числом элементов, у которых отметка действительно изменилась.
+func (s *Server) setAllItemsPurchased(c *gin.Context, purchased bool) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID && !s.hasEditAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	if wishlist.Archived {
+		c.JSON(http.StatusConflict, gin.H{"error": "wishlist is archived, unarchive it to edit items"})
+		return
+	}
+
+	if wishlist.Locked {
+		abortWishlistLocked(c, "wishlist is locked, unlock it to edit items")
+		return
+	}
+
+	updated := 0
+	for itemID, item := range s.items {
+		if item.WishlistID != wishlistID || item.IsPurchased == purchased {
+			continue
+		}
+
+		item.IsPurchased = purchased
+		item.UpdatedAt = clock.Now()
+		item.Version++
+
+		// Как и при покупке одного элемента, резерв и отметка гостя снимаются
+		reservedBy := item.ReservedBy
+		if purchased {
+			item.ReservedBy = ""
+			item.Claimed = false
+			item.ClaimToken = ""
+		}
+
+		s.putItem(item)
+		updated++
+
+		s.recordAudit(wishlistID, userID, auditItemUpdated, itemID)
+		publishWishlistEvent(wishlistID, auditItemUpdated, item)
+		if purchased {
+			s.recordAudit(wishlistID, userID, auditItemPurchased, itemID)
+			publishWishlistEvent(wishlistID, auditItemPurchased, item)
+			s.emitWebhook(wishlist.UserID, "item.purchased", item)
+
+			if reservedBy != "" && reservedBy != userID {
+				s.notifyReservationCleared(reservedBy, item, "was cleared because the item was purchased")
+			}
+		}
+	}
+
+	if updated > 0 {
+		s.touchItems(wishlistID)
+	}
+
+	c.JSON(http.StatusOK, model.BulkPurchaseResult{
+		WishlistID: wishlistID,
+		Updated:    updated,
+	})
+}
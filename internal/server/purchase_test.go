@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// setAllPurchased вызывает purchase-all или unpurchase-all и возвращает ответ
+func (ts *testServer) setAllPurchased(token, wishlistID, action string) model.BulkPurchaseResult {
+	ts.t.Helper()
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlistID+"/items/"+action, token, nil)
+	expectStatus(ts.t, rec, http.StatusOK)
+
+	var result model.BulkPurchaseResult
+	decodeBody(ts.t, rec, &result)
+	return result
+}
+
+func TestPurchaseAllItems(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	other := ts.createWishlist(owner.ID, "Other")
+	lamp := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Book"})
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Mug"})
+	ts.addItem(owner.ID, other.ID, gin.H{"name": "Pen"})
+	ts.share(owner.ID, wishlist.ID, guest.ID, false)
+	expectStatus(t, ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/items/"+lamp.ID+"/reserve", guest.ID, nil), http.StatusOK)
+
+	// Уже купленный элемент не считается измененным
+	for _, item := range ts.items(owner.ID, wishlist.ID) {
+		if item.Name == "Book" {
+			item.IsPurchased = true
+			expectStatus(t, ts.do(http.MethodPut, "/api/v1/wishlists/"+wishlist.ID+"/items/"+item.ID, owner.ID, item), http.StatusOK)
+		}
+	}
+
+	result := ts.setAllPurchased(owner.ID, wishlist.ID, "purchase-all")
+	if result.WishlistID != wishlist.ID || result.Updated != 2 {
+		t.Errorf("purchase-all result = %+v, want 2 updated", result)
+	}
+	for _, item := range ts.items(owner.ID, wishlist.ID) {
+		if !item.IsPurchased || item.ReservedBy != "" {
+			t.Errorf("item after purchase-all = %+v", item)
+		}
+	}
+	if pens := ts.items(owner.ID, other.ID); pens[0].IsPurchased {
+		t.Error("purchase-all changed an item of another wishlist")
+	}
+	inbox := ts.notifications(guest.ID)
+	if len(inbox.Notifications) == 0 || inbox.Notifications[0].Type != notificationReservationCleared {
+		t.Errorf("guest notifications = %+v", inbox.Notifications)
+	}
+
+	result = ts.setAllPurchased(owner.ID, wishlist.ID, "unpurchase-all")
+	if result.Updated != 3 {
+		t.Errorf("unpurchase-all result = %+v, want 3 updated", result)
+	}
+	for _, item := range ts.items(owner.ID, wishlist.ID) {
+		if item.IsPurchased {
+			t.Errorf("item after unpurchase-all = %+v", item)
+		}
+	}
+
+	// Повторный вызов ничего не меняет
+	if result := ts.setAllPurchased(owner.ID, wishlist.ID, "unpurchase-all"); result.Updated != 0 {
+		t.Errorf("repeated unpurchase-all result = %+v, want 0 updated", result)
+	}
+}
+
+func TestPurchaseAllRequiresEditAccess(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	viewer := ts.addUser("viewer")
+	editor := ts.addUser("editor")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	ts.share(owner.ID, wishlist.ID, viewer.ID, false)
+	ts.share(owner.ID, wishlist.ID, editor.ID, true)
+
+	expectStatus(t, ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/items/purchase-all", viewer.ID, nil), http.StatusForbidden)
+	if items := ts.items(owner.ID, wishlist.ID); items[0].IsPurchased {
+		t.Fatal("viewer marked items purchased")
+	}
+
+	if result := ts.setAllPurchased(editor.ID, wishlist.ID, "purchase-all"); result.Updated != 1 {
+		t.Errorf("editor purchase-all result = %+v", result)
+	}
+}
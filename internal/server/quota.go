@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// wishlistCount возвращает число списков пользователя.
+// Списки в корзине не учитываются: удаление освобождает квоту.
+func (s *Server) wishlistCount(userID string) int64 {
+	var count int64
+	for _, wishlist := range s.wishlists {
+		if wishlist.UserID == userID && wishlist.DeletedAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// itemCount возвращает число элементов в списке по индексу элементов списков
+func (s *Server) itemCount(wishlistID string) int64 {
+	return int64(len(s.itemsByWishlist[wishlistID]))
+}
+
+// canAddWishlists проверяет, что пользователь может создать еще n списков
+func (s *Server) canAddWishlists(userID string, n int64) bool {
+	return s.wishlistCount(userID)+n <= maxWishlistsPerUser
+}
+
+// canAddItems проверяет, что в список можно добавить еще n элементов
+func (s *Server) canAddItems(wishlistID string, n int64) bool {
+	return s.itemCount(wishlistID)+n <= maxItemsPerWishlist
+}
+
+// abortQuotaExceeded отвечает 403 с кодом QUOTA_EXCEEDED и значением лимита
+func abortQuotaExceeded(c *gin.Context, message string, limit int64) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": message,
+		"code":  "QUOTA_EXCEEDED",
+		"limit": limit,
+	})
+}
+
+// activeShareCount возвращает число записей о доступе к списку.
+// Истекший доступ не учитывается.
+func (s *Server) activeShareCount(wishlistID string) int64 {
+	var count int64
+	for _, share := range s.sharedWishlists {
+		if share.WishlistID == wishlistID && shareActive(share) {
+			count++
+		}
+	}
+	return count
+}
+
+// publicLinkCount возвращает число действующих публичных ссылок списка.
+// Отозванные ссылки удаляются, истекшие не учитываются.
+func (s *Server) publicLinkCount(wishlistID string) int64 {
+	var count int64
+	for _, link := range s.publicLinks {
+		if link.WishlistID == wishlistID && publicLinkActive(link) {
+			count++
+		}
+	}
+	return count
+}
+
+// abortShareLimitExceeded отвечает 409 с кодом SHARE_LIMIT_EXCEEDED и значением лимита
+func abortShareLimitExceeded(c *gin.Context, message string, limit int64) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error": message,
+		"code":  "SHARE_LIMIT_EXCEEDED",
+		"limit": limit,
+	})
+}
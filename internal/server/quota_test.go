@@ -0,0 +1,185 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useQuotas задает квоты на списки и элементы до конца теста
+func useQuotas(t *testing.T, wishlists, items int64) {
+	t.Helper()
+	previousWishlists, previousItems := maxWishlistsPerUser, maxItemsPerWishlist
+	maxWishlistsPerUser, maxItemsPerWishlist = wishlists, items
+	t.Cleanup(func() { maxWishlistsPerUser, maxItemsPerWishlist = previousWishlists, previousItems })
+}
+
+func expectQuotaExceeded(t *testing.T, rec *httptest.ResponseRecorder, limit int64) {
+	t.Helper()
+	expectStatus(t, rec, http.StatusForbidden)
+	var body struct {
+		Code  string `json:"code"`
+		Limit int64  `json:"limit"`
+	}
+	decodeBody(t, rec, &body)
+	if body.Code != "QUOTA_EXCEEDED" || body.Limit != limit {
+		t.Fatalf("quota error = %+v, want QUOTA_EXCEEDED with limit %d", body, limit)
+	}
+}
+
+func TestWishlistQuota(t *testing.T) {
+	useQuotas(t, 2, 100)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	other := ts.addUser("other")
+
+	first := ts.createWishlist(owner.ID, "First")
+	ts.createWishlist(owner.ID, "Second")
+	expectQuotaExceeded(t, ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, gin.H{"title": "Third"}), 2)
+	expectQuotaExceeded(t, ts.do(http.MethodPost, "/api/v1/wishlists/"+first.ID+"/duplicate", owner.ID, nil), 2)
+
+	// Квота считается для каждого пользователя отдельно
+	ts.createWishlist(other.ID, "Other")
+
+	// Список в корзине квоту не занимает, а восстановить его можно, только если есть место
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+first.ID, owner.ID, nil), http.StatusNoContent)
+	ts.createWishlist(owner.ID, "Third")
+	expectQuotaExceeded(t, ts.do(http.MethodPost, "/api/v1/wishlists/"+first.ID+"/restore", owner.ID, nil), 2)
+}
+
+func TestItemQuota(t *testing.T) {
+	useQuotas(t, 100, 2)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	full := ts.createWishlist(owner.ID, "Full")
+	spare := ts.createWishlist(owner.ID, "Spare")
+	itemsPath := "/api/v1/wishlists/" + full.ID + "/items"
+
+	first := ts.addItem(owner.ID, full.ID, gin.H{"name": "Lamp"})
+	ts.addItem(owner.ID, full.ID, gin.H{"name": "Book"})
+	expectQuotaExceeded(t, ts.do(http.MethodPost, itemsPath, owner.ID, gin.H{"name": "Mug"}), 2)
+
+	// Копирование и перенос в заполненный список тоже отклоняются
+	extra := ts.addItem(owner.ID, spare.ID, gin.H{"name": "Pen"})
+	extraPath := "/api/v1/wishlists/" + spare.ID + "/items/" + extra.ID
+	expectQuotaExceeded(t, ts.do(http.MethodPost, extraPath+"/copy", owner.ID, gin.H{"target_wishlist_id": full.ID}), 2)
+	expectQuotaExceeded(t, ts.do(http.MethodPost, extraPath+"/move", owner.ID, gin.H{"target_wishlist_id": full.ID}), 2)
+
+	// Импорт пакета больше квоты отклоняется целиком
+	items := []gin.H{{"name": "A"}, {"name": "B"}, {"name": "C"}}
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/import", owner.ID, gin.H{"wishlist": gin.H{"title": "Imported"}, "items": items})
+	expectQuotaExceeded(t, rec, 2)
+
+	// Удаление элемента освобождает место
+	expectStatus(t, ts.do(http.MethodDelete, itemsPath+"/"+first.ID, owner.ID, nil), http.StatusNoContent)
+	ts.addItem(owner.ID, full.ID, gin.H{"name": "Mug"})
+	if stored := ts.items(owner.ID, full.ID); len(stored) != 2 {
+		t.Errorf("full wishlist has %d items, want 2", len(stored))
+	}
+}
+
+// useShareLimits задает лимиты записей о доступе и публичных ссылок на список до конца теста
+func useShareLimits(t *testing.T, shares, links int64) {
+	t.Helper()
+	previousShares, previousLinks := maxSharesPerWishlist, maxPublicLinksPerWishlist
+	maxSharesPerWishlist, maxPublicLinksPerWishlist = shares, links
+	t.Cleanup(func() { maxSharesPerWishlist, maxPublicLinksPerWishlist = previousShares, previousLinks })
+}
+
+func expectShareLimitExceeded(t *testing.T, rec *httptest.ResponseRecorder, limit int64) {
+	t.Helper()
+	expectStatus(t, rec, http.StatusConflict)
+	var body struct {
+		Code  string `json:"code"`
+		Limit int64  `json:"limit"`
+	}
+	decodeBody(t, rec, &body)
+	if body.Code != "SHARE_LIMIT_EXCEEDED" || body.Limit != limit {
+		t.Fatalf("share limit error = %+v, want SHARE_LIMIT_EXCEEDED with limit %d", body, limit)
+	}
+}
+
+func TestShareLimit(t *testing.T) {
+	fake := useFakeClock(t)
+	useShareLimits(t, 2, 100)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	path := "/api/v1/wishlists/" + wishlist.ID + "/share"
+	shareWith := func(username string, expiresAt interface{}) *httptest.ResponseRecorder {
+		return ts.do(http.MethodPost, path, owner.ID, gin.H{"shared_user_id": ts.addUser(username).ID, "expires_at": expiresAt})
+	}
+
+	first := ts.addUser("first")
+	ts.share(owner.ID, wishlist.ID, first.ID, false)
+	expectStatus(t, shareWith("temporary", fake.Now().Add(time.Hour)), http.StatusCreated)
+	expectShareLimitExceeded(t, shareWith("third", nil), 2)
+
+	// Истекший доступ место не занимает
+	fake.Advance(2 * time.Hour)
+	expectStatus(t, shareWith("fourth", nil), http.StatusCreated)
+	expectShareLimitExceeded(t, shareWith("fifth", nil), 2)
+
+	// Отзыв доступа освобождает место
+	expectStatus(t, ts.do(http.MethodDelete, path+"/"+first.ID, owner.ID, nil), http.StatusNoContent)
+	expectStatus(t, shareWith("sixth", nil), http.StatusCreated)
+}
+
+func TestPublicLinkLimit(t *testing.T) {
+	fake := useFakeClock(t)
+	useShareLimits(t, 100, 2)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	other := ts.createWishlist(owner.ID, "Other")
+	path := "/api/v1/wishlists/" + wishlist.ID + "/public-links"
+
+	first := ts.publicLink(owner.ID, wishlist.ID)
+	expectFieldError(t, ts.do(http.MethodPost, path, owner.ID, gin.H{"expires_at": fake.Now()}), "expires_at")
+	expectStatus(t, ts.do(http.MethodPost, path, owner.ID, gin.H{"expires_at": fake.Now().Add(time.Hour)}), http.StatusCreated)
+	expectShareLimitExceeded(t, ts.do(http.MethodPost, path, owner.ID, nil), 2)
+	// Лимит считается для каждого списка отдельно
+	ts.publicLink(owner.ID, other.ID)
+
+	// Истекшая ссылка место не занимает
+	fake.Advance(2 * time.Hour)
+	ts.publicLink(owner.ID, wishlist.ID)
+	expectShareLimitExceeded(t, ts.do(http.MethodPost, path, owner.ID, nil), 2)
+
+	// Отзыв ссылки освобождает место
+	expectStatus(t, ts.do(http.MethodDelete, path+"/"+first.Token, owner.ID, nil), http.StatusNoContent)
+	ts.publicLink(owner.ID, wishlist.ID)
+}
+
+func TestTransferKeepAccessRespectsShareLimit(t *testing.T) {
+	useShareLimits(t, 1, 100)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	heir := ts.addUser("heir")
+	guest := ts.addUser("guest")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	path := "/api/v1/wishlists/" + wishlist.ID + "/transfer"
+	ts.share(owner.ID, wishlist.ID, guest.ID, false)
+
+	// Единственное место занято гостем: оставить себе доступ при передаче нельзя
+	rec := ts.do(http.MethodPost, path, owner.ID, gin.H{"user_id": heir.ID, "keep_access": true})
+	expectShareLimitExceeded(t, rec, 1)
+	ts.srv.mu.RLock()
+	stillOwner := ts.srv.wishlists[wishlist.ID].UserID == owner.ID
+	ts.srv.mu.RUnlock()
+	if !stillOwner {
+		t.Fatal("rejected transfer changed the owner")
+	}
+
+	// Запись нового владельца при передаче удаляется, поэтому ее место может занять прежний владелец
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+wishlist.ID+"/share/"+guest.ID, owner.ID, nil), http.StatusNoContent)
+	ts.share(owner.ID, wishlist.ID, heir.ID, true)
+	rec = ts.do(http.MethodPost, path, owner.ID, gin.H{"user_id": heir.ID, "keep_access": true})
+	expectStatus(t, rec, http.StatusOK)
+	if holders := ts.shareHolders(wishlist.ID); len(holders) != 1 || holders[owner.ID] != 1 {
+		t.Errorf("share holders after transfer = %v", holders)
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Максимальное число отслеживаемых клиентов в одном ограничителе
+const maxRateLimitKeys = 10000
+
+// rateLimiter - ограничитель частоты запросов по алгоритму token bucket.
+// У каждого клиента своя корзина объемом burst, которая пополняется со скоростью rate токенов в секунду.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter создает ограничитель на perMinute запросов в минуту с пиком до burst запросов.
+// Если perMinute не положителен, ограничитель пропускает все запросы.
+func newRateLimiter(perMinute, burst int64) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow списывает токен из корзины клиента. Если токенов нет, возвращает false
+// и время, через которое появится следующий.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	if l.rate <= 0 {
+		return true, 0
+	}
+
+	now := clock.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, exists := l.buckets[key]
+	if !exists {
+		if len(l.buckets) >= maxRateLimitKeys {
+			l.evict(now)
+		}
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// evict освобождает место для нового клиента. Сначала удаляются корзины, которые
+// успели наполниться полностью: для таких клиентов новая корзина ничем не отличается
+// от старой. Если таких нет, удаляется произвольная корзина. Вызывается под l.mu.
+func (l *rateLimiter) evict(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+	if len(l.buckets) < maxRateLimitKeys {
+		return
+	}
+	for key := range l.buckets {
+		delete(l.buckets, key)
+		break
+	}
+}
+
+// rateLimitMiddleware ограничивает частоту запросов. Для аутентифицированных запросов
+// ключом служит пользователь, для остальных - адрес клиента, поэтому в группах API
+// middleware ставится после authMiddleware.
+func rateLimitMiddleware(l *rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + clientIP(c)
+		if userID, ok := c.Get("userID"); ok {
+			key = "user:" + userID.(string)
+		}
+
+		allowed, wait := l.allow(key)
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "too many requests",
+				"code":  "RATE_LIMITED",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useAPIRateLimit задает лимит группы /api/v1 до конца теста
+func useAPIRateLimit(t *testing.T, perMinute, burst int64) {
+	t.Helper()
+	previousRate, previousBurst := apiRatePerMinute, apiRateBurst
+	apiRatePerMinute, apiRateBurst = perMinute, burst
+	t.Cleanup(func() { apiRatePerMinute, apiRateBurst = previousRate, previousBurst })
+}
+
+func TestRateLimitBurstExceeded(t *testing.T) {
+	fake := useFakeClock(t)
+	useAPIRateLimit(t, 60, 3)
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+
+	for i := 0; i < 3; i++ {
+		expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists", alice.ID, nil), http.StatusOK)
+	}
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists", alice.ID, nil)
+	expectStatus(t, rec, http.StatusTooManyRequests)
+	var body map[string]string
+	decodeBody(t, rec, &body)
+	if body["code"] != "RATE_LIMITED" || body["error"] == "" {
+		t.Errorf("body = %v", body)
+	}
+	// Один токен в секунду: следующий запрос возможен через секунду
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	fake.Advance(time.Second)
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists", alice.ID, nil), http.StatusOK)
+}
+
+func TestRateLimitPerUser(t *testing.T) {
+	useFakeClock(t)
+	useAPIRateLimit(t, 60, 1)
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists", alice.ID, nil), http.StatusOK)
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists", alice.ID, nil), http.StatusTooManyRequests)
+
+	// Корзина у каждого пользователя своя, а лимит группы API не трогает вход
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists", bob.ID, nil), http.StatusOK)
+	expectStatus(t, ts.login("alice", testPassword), http.StatusOK)
+}
+
+func TestRateLimiterBoundsBuckets(t *testing.T) {
+	fake := useFakeClock(t)
+	limiter := newRateLimiter(60, 1)
+
+	for i := 0; i < maxRateLimitKeys+10; i++ {
+		limiter.allow(fmt.Sprintf("ip:%d", i))
+	}
+	if n := len(limiter.buckets); n > maxRateLimitKeys {
+		t.Fatalf("buckets = %d, want at most %d", n, maxRateLimitKeys)
+	}
+
+	// Наполнившиеся корзины вытесняются первыми, и новый клиент получает полную корзину
+	fake.Advance(time.Second)
+	if allowed, _ := limiter.allow("ip:new"); !allowed {
+		t.Error("new client is rate limited after eviction")
+	}
+	if n := len(limiter.buckets); n != 1 {
+		t.Errorf("buckets after refill eviction = %d, want 1", n)
+	}
+}
+
+func TestRegistrationThrottledPerIP(t *testing.T) {
+	useFakeClock(t)
+	previousRate, previousBurst := registerRatePerMinute, registerRateBurst
+	registerRatePerMinute, registerRateBurst = 6, 2
+	t.Cleanup(func() { registerRatePerMinute, registerRateBurst = previousRate, previousBurst })
+	ts := newTestServer(t)
+
+	register := func(remoteAddr, username string) *httptest.ResponseRecorder {
+		req := ts.newRequest(http.MethodPost, "/auth/register", "", gin.H{
+			"username": username,
+			"email":    username + "@example.com",
+			"password": testPassword,
+		})
+		req.RemoteAddr = remoteAddr
+		return ts.serve(req)
+	}
+
+	expectStatus(t, register("192.0.2.1:4000", "spam1"), http.StatusCreated)
+	expectStatus(t, register("192.0.2.1:4001", "spam2"), http.StatusCreated)
+	rec := register("192.0.2.1:4002", "spam3")
+	expectStatus(t, rec, http.StatusTooManyRequests)
+	// Шесть регистраций в минуту: следующая возможна через 10 секунд
+	if got := rec.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("Retry-After = %q, want 10", got)
+	}
+
+	// Другой адрес ограничивается отдельно, а вход с того же адреса - по своему лимиту
+	expectStatus(t, register("198.51.100.7:4000", "honest"), http.StatusCreated)
+	req := ts.newRequest(http.MethodPost, "/auth/login", "", gin.H{"identifier": "spam1", "password": testPassword})
+	req.RemoteAddr = "192.0.2.1:4003"
+	expectStatus(t, ts.serve(req), http.StatusOK)
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// reserveItem отмечает элемент как выбранный пользователем, чтобы другие гости
+// не купили тот же подарок. Резервировать могут только пользователи с доступом
+// к чужому списку, владелец свои элементы не резервирует.
+func (s *Server) reserveItem(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	item, owner, reserved := s.storeReservation(c, userID, c.Param("id"), c.Param("item_id"))
+	if !reserved {
+		return
+	}
+
+	// Вебхук владельцу ставится в очередь после освобождения мьютекса
+	queueWebhook(owner, "item.reserved", item)
+
+	c.JSON(http.StatusOK, item)
+}
+
+// storeReservation резервирует элемент за пользователем под блокировкой mu и возвращает
+// элемент и копию владельца списка. Если новый резерв не создан (ошибка или элемент уже
+// зарезервирован этим пользователем), ответ уже отправлен и возвращается false.
+func (s *Server) storeReservation(c *gin.Context, userID, wishlistID, itemID string) (model.Item, model.User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return model.Item{}, model.User{}, false
+	}
+
+	if wishlist.UserID == userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "owner cannot reserve items in own wishlist"})
+		return model.Item{}, model.User{}, false
+	}
+
+	if !s.hasSharedAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return model.Item{}, model.User{}, false
+	}
+
+	if wishlist.Archived {
+		c.JSON(http.StatusConflict, gin.H{"error": "wishlist is archived, unarchive it to edit items"})
+		return model.Item{}, model.User{}, false
+	}
+
+	if wishlist.Locked {
+		abortWishlistLocked(c, "wishlist is locked, unlock it to edit items")
+		return model.Item{}, model.User{}, false
+	}
+
+	item, err := s.getItemInWishlist(wishlistID, itemID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return model.Item{}, model.User{}, false
+	}
+
+	if item.IsPurchased {
+		c.JSON(http.StatusConflict, gin.H{"error": "item is already purchased"})
+		return model.Item{}, model.User{}, false
+	}
+
+	// Повторное резервирование тем же пользователем ничего не меняет
+	if item.ReservedBy == userID {
+		c.JSON(http.StatusOK, item)
+		return model.Item{}, model.User{}, false
+	}
+
+	if item.ReservedBy != "" || item.Claimed {
+		c.JSON(http.StatusConflict, gin.H{"error": "item is already reserved"})
+		return model.Item{}, model.User{}, false
+	}
+
+	item.ReservedBy = userID
+	item.UpdatedAt = clock.Now()
+	item.Version++
+
+	s.putItem(item)
+	s.touchItems(wishlistID)
+	s.recordAudit(wishlistID, userID, auditItemReserved, itemID)
+	publishWishlistEvent(wishlistID, auditItemReserved, item)
+
+	// Владельцу не сообщаем, кто зарезервировал подарок, чтобы не испортить сюрприз
+	s.notify(wishlist.UserID, notificationItemReserved, "Someone reserved \""+item.Name+"\" in \""+wishlist.Title+"\"")
+
+	return item, s.users[wishlist.UserID], true
+}
+
+// unreserveItem снимает резерв. Снять его может зарезервировавший пользователь или владелец списка.
+func (s *Server) unreserveItem(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+	itemID := c.Param("item_id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID && !s.hasSharedAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	if wishlist.Locked {
+		abortWishlistLocked(c, "wishlist is locked, unlock it to edit items")
+		return
+	}
+
+	item, err := s.getItemInWishlist(wishlistID, itemID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if item.ReservedBy == "" {
+		c.JSON(http.StatusOK, item)
+		return
+	}
+
+	if item.ReservedBy != userID && wishlist.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "item is reserved by another user"})
+		return
+	}
+
+	reservedBy := item.ReservedBy
+	item.ReservedBy = ""
+	item.UpdatedAt = clock.Now()
+	item.Version++
+
+	s.putItem(item)
+	s.touchItems(wishlistID)
+	s.recordAudit(wishlistID, userID, auditItemUnreserved, itemID)
+	publishWishlistEvent(wishlistID, auditItemUnreserved, item)
+
+	if reservedBy != userID {
+		s.notifyReservationCleared(reservedBy, item, "was released by the wishlist owner")
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// notifyReservationCleared сообщает пользователю, что его резерв на элемент снят.
+// Вызывается под блокировкой mu.
+func (s *Server) notifyReservationCleared(reservedBy string, item model.Item, reason string) {
+	s.notify(reservedBy, notificationReservationCleared, "Your reservation of \""+item.Name+"\" "+reason)
+}
+
+// hideReserver убирает из элемента, который видит владелец списка, кто его зарезервировал:
+// резерв нужен гостям, чтобы не купить один подарок дважды, а владельцу не раскрывается.
+// Так элементы отдаются владельцу в списке элементов и в потоке событий.
+func hideReserver(item model.Item) model.Item {
+	item.ReservedBy = ""
+	return item
+}
+
+// ownerItemView убирает из элемента отметки гостей: кто его зарезервировал и отмечен ли
+// он по публичной ссылке. Такие отметки - состояние гостей, а не данные владельца,
+// поэтому в выгрузку владельца они не попадают.
+func ownerItemView(item model.Item) model.Item {
+	item.ReservedBy = ""
+	item.Claimed = false
+	item.ClaimToken = ""
+	return item
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// reservedItem создает список с элементом, открывает его гостю и резервирует элемент от имени гостя
+func (ts *testServer) reservedItem(owner, guest model.User) model.Item {
+	ts.t.Helper()
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	ts.share(owner.ID, wishlist.ID, guest.ID, false)
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/items/"+item.ID+"/reserve", guest.ID, nil)
+	expectStatus(ts.t, rec, http.StatusOK)
+	decodeBody(ts.t, rec, &item)
+	if item.ReservedBy != guest.ID {
+		ts.t.Fatalf("reserved item = %+v", item)
+	}
+	return item
+}
+
+// expectReservationCleared проверяет последнее уведомление гостя о снятом резерве
+func (ts *testServer) expectReservationCleared(guest model.User, reason string) {
+	ts.t.Helper()
+	inbox := ts.notifications(guest.ID)
+	if len(inbox.Notifications) == 0 || inbox.Notifications[0].Type != notificationReservationCleared {
+		ts.t.Fatalf("guest notifications = %+v, want %s first", inbox.Notifications, notificationReservationCleared)
+	}
+	if want := `Your reservation of "Lamp" ` + reason; inbox.Notifications[0].Message != want {
+		ts.t.Errorf("message = %q, want %q", inbox.Notifications[0].Message, want)
+	}
+}
+
+func TestDeleteReservedItemNotifiesReserver(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	item := ts.reservedItem(owner, guest)
+
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+item.WishlistID+"/items/"+item.ID, owner.ID, nil), http.StatusNoContent)
+
+	if items := ts.items(owner.ID, item.WishlistID); len(items) != 0 {
+		t.Errorf("items after delete = %+v", items)
+	}
+	ts.expectReservationCleared(guest, "was cancelled because the item was removed")
+}
+
+func TestPurchaseClearsReservation(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	item := ts.reservedItem(owner, guest)
+
+	item.IsPurchased = true
+	rec := ts.do(http.MethodPut, "/api/v1/wishlists/"+item.WishlistID+"/items/"+item.ID, owner.ID, item)
+	expectStatus(t, rec, http.StatusOK)
+	var purchased model.Item
+	decodeBody(t, rec, &purchased)
+	if !purchased.IsPurchased || purchased.ReservedBy != "" {
+		t.Errorf("purchased item = %+v", purchased)
+	}
+
+	if stored := ts.items(guest.ID, item.WishlistID); len(stored) != 1 || stored[0].ReservedBy != "" {
+		t.Errorf("stored items = %+v", stored)
+	}
+	inbox := ts.notifications(guest.ID)
+	if len(inbox.Notifications) == 0 || inbox.Notifications[0].Type != notificationReservationCleared {
+		t.Errorf("guest notifications = %+v", inbox.Notifications)
+	}
+}
+
+func TestDeleteOwnReservationIsSilent(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	editor := ts.addUser("editor")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Lamp"})
+	ts.share(owner.ID, wishlist.ID, editor.ID, true)
+	path := "/api/v1/wishlists/" + wishlist.ID + "/items/" + item.ID
+	expectStatus(t, ts.do(http.MethodPost, path+"/reserve", editor.ID, nil), http.StatusOK)
+
+	// Редактор удаляет элемент, который сам зарезервировал: уведомлять некого
+	expectStatus(t, ts.do(http.MethodDelete, path, editor.ID, nil), http.StatusNoContent)
+	for _, n := range ts.notifications(editor.ID).Notifications {
+		if n.Type == notificationReservationCleared {
+			t.Errorf("editor was notified about own deletion: %+v", n)
+		}
+	}
+}
+
+func TestOwnerExportOmitsReservations(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	item := ts.reservedItem(owner, guest)
+	path := "/api/v1/wishlists/" + item.WishlistID + "/export"
+
+	rec := ts.do(http.MethodGet, path, owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if strings.Contains(rec.Body.String(), "reserved_by") {
+		t.Errorf("owner export contains reservations: %s", rec.Body.String())
+	}
+
+	// Гость, которому открыт список, видит в выгрузке свой резерв, как и в списке элементов
+	rec = ts.do(http.MethodGet, path, guest.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var bundle WishlistBundle
+	decodeBody(t, rec, &bundle)
+	if len(bundle.Items) != 1 || bundle.Items[0].ReservedBy != guest.ID {
+		t.Errorf("guest export items = %+v", bundle.Items)
+	}
+
+	rec = ts.do(http.MethodGet, "/api/v1/export/all", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var line exportItemLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("decode line %q: %v", scanner.Text(), err)
+		}
+		if line.Type == "item" && (line.ReservedBy != "" || line.Claimed) {
+			t.Errorf("account export item = %+v", line.Item)
+		}
+	}
+
+	// Резерв в хранилище выгрузка не снимает
+	if stored := ts.items(guest.ID, item.WishlistID); len(stored) != 1 || stored[0].ReservedBy != guest.ID {
+		t.Errorf("stored items after export = %+v", stored)
+	}
+}
+
+func TestOwnerItemsHideReserver(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	guest := ts.addUser("guest")
+	item := ts.reservedItem(owner, guest)
+
+	// Владелец не видит, кто зарезервировал подарок, гость видит свой резерв
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists/"+item.WishlistID+"/items", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if strings.Contains(rec.Body.String(), "reserved_by") || strings.Contains(rec.Body.String(), guest.ID) {
+		t.Errorf("owner items expose the reserver: %s", rec.Body.String())
+	}
+	if stored := ts.items(guest.ID, item.WishlistID); len(stored) != 1 || stored[0].ReservedBy != guest.ID {
+		t.Errorf("guest items = %+v", stored)
+	}
+}
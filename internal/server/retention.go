@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RetentionSweep - сколько истекших записей удалено за один проход
+type RetentionSweep struct {
+	Wishlists       int
+	Shares          int
+	PublicLinks     int
+	Verifications   int
+	IdempotencyKeys int
+}
+
+// RunRetentionSweeper каждые RETENTION_SWEEP_INTERVAL удаляет из хранилища истекшие данные,
+// пока не будет отменен ctx
+func RunRetentionSweeper(ctx context.Context, store Store) {
+	s := newServer(store)
+
+	ticker := time.NewTicker(retentionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sweep := s.sweepExpired()
+			if sweep != (RetentionSweep{}) {
+				log.Printf("retention sweep: removed %d wishlists, %d shares, %d public links, %d verifications, %d idempotency keys",
+					sweep.Wishlists, sweep.Shares, sweep.PublicLinks, sweep.Verifications, sweep.IdempotencyKeys)
+			}
+		}
+	}
+}
+
+// SweepExpired один раз удаляет истекшие данные, не дожидаясь планировщика
+func SweepExpired(store Store) RetentionSweep {
+	return newServer(store).sweepExpired()
+}
+
+// sweepExpired удаляет списки с истекшим сроком хранения в корзине, истекший доступ к спискам
+// и публичные ссылки, просроченные токены подтверждения email и ключи идемпотентности. Действующие записи не трогает.
+// Мьютексы захватывает сам.
+func (s *Server) sweepExpired() RetentionSweep {
+	var sweep RetentionSweep
+	now := clock.Now()
+
+	s.mu.Lock()
+	sweep.Wishlists = s.sweepTrash()
+	for shareID, share := range s.sharedWishlists {
+		if !shareActive(share) {
+			delete(s.sharedWishlists, shareID)
+			sweep.Shares++
+		}
+	}
+
+	for token, link := range s.publicLinks {
+		if !publicLinkActive(link) {
+			delete(s.publicLinks, token)
+			sweep.PublicLinks++
+		}
+	}
+
+	for token, verification := range s.verifications {
+		if !now.Before(verification.ExpiresAt) {
+			delete(s.verifications, token)
+			sweep.Verifications++
+		}
+	}
+	s.mu.Unlock()
+
+	// Ключи идемпотентности хранятся отдельно, их мьютекс берется без других
+	idempotencyMu.Lock()
+	for scope, record := range idempotencyRecords {
+		// Истекшая запись выполняемого запроса осталась от брошенного запроса
+		if !now.Before(record.ExpiresAt) {
+			delete(idempotencyRecords, scope)
+			sweep.IdempotencyKeys++
+		}
+	}
+	idempotencyMu.Unlock()
+
+	return sweep
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSweepExpiredKeepsLiveRecords(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	old := ts.createWishlist(owner.ID, "Old")
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+old.ID, owner.ID, nil), http.StatusNoContent)
+
+	kept := ts.createWishlist(owner.ID, "Kept")
+	sharePath := "/api/v1/wishlists/" + kept.ID + "/share"
+	linkPath := "/api/v1/wishlists/" + kept.ID + "/public-links"
+	soon := fake.Now().Add(time.Hour)
+	expectStatus(t, ts.do(http.MethodPost, sharePath, owner.ID, gin.H{"shared_user_id": ts.addUser("temporary").ID, "expires_at": soon}), http.StatusCreated)
+	permanent := ts.share(owner.ID, kept.ID, ts.addUser("permanent").ID, false)
+	expectStatus(t, ts.do(http.MethodPost, linkPath, owner.ID, gin.H{"expires_at": soon}), http.StatusCreated)
+	link := ts.publicLink(owner.ID, kept.ID)
+
+	ts.srv.mu.Lock()
+	ts.srv.verifications["expired"] = EmailVerification{Token: "expired", UserID: owner.ID, ExpiresAt: soon}
+	ts.srv.verifications["live"] = EmailVerification{Token: "live", UserID: owner.ID, ExpiresAt: soon.Add(2 * trashRetention)}
+	ts.srv.mu.Unlock()
+
+	// Пока ничего не истекло, очистка ничего не удаляет
+	if sweep := SweepExpired(ts.srv.MemStore); sweep.Wishlists+sweep.Shares+sweep.PublicLinks+sweep.Verifications != 0 {
+		t.Fatalf("sweep before expiry = %+v", sweep)
+	}
+
+	fake.Advance(trashRetention + time.Minute)
+	recent := ts.createWishlist(owner.ID, "Recent")
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+recent.ID, owner.ID, nil), http.StatusNoContent)
+
+	sweep := SweepExpired(ts.srv.MemStore)
+	if sweep.Wishlists != 1 || sweep.Shares != 1 || sweep.PublicLinks != 1 || sweep.Verifications != 1 {
+		t.Errorf("sweep = %+v, want one record of each kind", sweep)
+	}
+
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	if _, exists := ts.srv.wishlists[old.ID]; exists {
+		t.Error("expired trashed wishlist is still stored")
+	}
+	if _, exists := ts.srv.wishlists[recent.ID]; !exists {
+		t.Error("recently trashed wishlist was removed")
+	}
+	if len(ts.srv.sharedWishlists) != 1 || ts.srv.sharedWishlists[permanent.ID].UserID != permanent.UserID {
+		t.Errorf("shares after sweep = %+v", ts.srv.sharedWishlists)
+	}
+	if _, exists := ts.srv.publicLinks[link.Token]; !exists || len(ts.srv.publicLinks) != 1 {
+		t.Errorf("public links after sweep = %+v", ts.srv.publicLinks)
+	}
+	if _, exists := ts.srv.verifications["live"]; !exists || len(ts.srv.verifications) != 1 {
+		t.Errorf("verifications after sweep = %+v", ts.srv.verifications)
+	}
+}
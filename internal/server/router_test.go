@@ -0,0 +1,68 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wana/internal/model"
+	"wana/internal/server"
+)
+
+// Тест пользуется только экспортированным API пакета, как внешний код:
+// роутер над хранилищем в памяти, запросы через httptest.NewRecorder
+func TestRouterWishlistRoundTrip(t *testing.T) {
+	var store server.Store = server.NewMemStore()
+	router := server.NewRouter(store)
+
+	send := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder, status int, out interface{}) {
+		t.Helper()
+		if rec.Code != status {
+			t.Fatalf("status = %d, want %d; body: %s", rec.Code, status, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("decode %q: %v", rec.Body.String(), err)
+		}
+	}
+
+	var user model.UserResponse
+	decode(send(http.MethodPost, "/auth/register", "", model.RegisterRequest{
+		Username: "alice",
+		Email:    "alice@example.com",
+		Password: "s3cret-Password",
+	}), http.StatusCreated, &user)
+
+	var created model.Wishlist
+	decode(send(http.MethodPost, "/api/v1/wishlists", user.ID, model.Wishlist{Title: "Birthday", Description: "30th"}),
+		http.StatusCreated, &created)
+	if created.ID == "" || created.UserID != user.ID || created.Version != 1 {
+		t.Fatalf("created wishlist = %+v", created)
+	}
+
+	var fetched model.Wishlist
+	decode(send(http.MethodGet, "/api/v1/wishlists/"+created.ID, user.ID, nil), http.StatusOK, &fetched)
+	if fetched.ID != created.ID || fetched.Title != "Birthday" || fetched.Description != "30th" {
+		t.Errorf("fetched wishlist = %+v, want %+v", fetched, created)
+	}
+
+	if rec := send(http.MethodGet, "/api/v1/wishlists/"+created.ID, "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET without token: status = %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"wana/internal/model"
+)
+
+// ItemSearchResult - найденный элемент с названием списка, в котором он находится
+type ItemSearchResult struct {
+	model.Item
+	WishlistTitle string `json:"wishlist_title"`
+}
+
+// foldSearchText приводит текст к виду для сравнения при поиске: раскладывает символы (NFKD),
+// удаляет диакритические знаки и приводит регистр, так что "Café" совпадает с "cafe", а "Ёлка" - с "елка".
+// Цепочка преобразований хранит состояние, поэтому создается на каждый вызов
+func foldSearchText(text string) string {
+	folder := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), cases.Fold())
+	folded, _, err := transform.String(folder, text)
+	if err != nil {
+		return strings.ToLower(text)
+	}
+	return folded
+}
+
+func (s *Server) searchItems(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	query := foldSearchText(strings.TrimSpace(c.Query("q")))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Ищем только в списках, которые пользователь может просматривать
+	visible := s.visibleWishlists(userID)
+
+	results := []ItemSearchResult{}
+	for _, item := range s.items {
+		wishlist, ok := visible[item.WishlistID]
+		if !ok {
+			continue
+		}
+		if strings.Contains(foldSearchText(item.Name), query) ||
+			strings.Contains(foldSearchText(item.Description), query) {
+			results = append(results, ItemSearchResult{Item: item, WishlistTitle: wishlist.Title})
+		}
+	}
+
+	// Элементы хранятся в map, поэтому для стабильных страниц сортируем выдачу
+	sort.Slice(results, func(i, j int) bool {
+		if !results[i].CreatedAt.Equal(results[j].CreatedAt) {
+			return results[i].CreatedAt.Before(results[j].CreatedAt)
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	start, end := pageBounds(len(results), limit, offset)
+	c.JSON(http.StatusOK, gin.H{
+		"items":  results[start:end],
+		"total":  len(results),
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// visibleWishlists возвращает списки, которыми пользователь владеет
+// или к которым ему открыт доступ. Вызывается под блокировкой mu.
+func (s *Server) visibleWishlists(userID string) map[string]model.Wishlist {
+	visible := make(map[string]model.Wishlist)
+	for _, w := range s.wishlists {
+		if w.UserID == userID && w.DeletedAt == nil {
+			visible[w.ID] = w
+		}
+	}
+	for _, share := range s.sharedWishlists {
+		if share.UserID != userID || !shareActive(share) {
+			continue
+		}
+		if w, exists := s.findWishlist(share.WishlistID); exists {
+			visible[w.ID] = w
+		}
+	}
+	return visible
+}
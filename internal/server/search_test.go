@@ -0,0 +1,142 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type searchPage struct {
+	Items []ItemSearchResult `json:"items"`
+	Total int                `json:"total"`
+}
+
+func searchItems(t *testing.T, ts *testServer, token, query string) searchPage {
+	t.Helper()
+	rec := ts.do(http.MethodGet, "/api/v1/items/search"+query, token, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var page searchPage
+	decodeBody(t, rec, &page)
+	return page
+}
+
+func TestSearchItemsAcrossWishlists(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	stranger := ts.addUser("stranger")
+
+	home := ts.createWishlist(alice.ID, "Home")
+	office := ts.createWishlist(alice.ID, "Office")
+	bobList := ts.createWishlist(bob.ID, "Bob")
+	hidden := ts.createWishlist(stranger.ID, "Hidden")
+	ts.share(bob.ID, bobList.ID, alice.ID, false)
+
+	for _, add := range []struct {
+		wishlistID, token string
+		item              gin.H
+	}{
+		{home.ID, alice.ID, gin.H{"name": "Floor LAMP"}},
+		{office.ID, alice.ID, gin.H{"name": "Desk", "description": "with a lamp mount"}},
+		{bobList.ID, bob.ID, gin.H{"name": "Lamp shade"}},
+		{hidden.ID, stranger.ID, gin.H{"name": "Secret lamp"}},
+		{home.ID, alice.ID, gin.H{"name": "Rug"}},
+	} {
+		ts.addItem(add.token, add.wishlistID, add.item)
+		fake.Advance(time.Minute)
+	}
+
+	page := searchItems(t, ts, alice.ID, "?q=lamp")
+	if page.Total != 3 || len(page.Items) != 3 {
+		t.Fatalf("search returned %d of %d items: %+v", len(page.Items), page.Total, page.Items)
+	}
+
+	// Результаты идут в порядке создания и подписаны своими списками
+	want := []struct{ name, wishlistID, title string }{
+		{"Floor LAMP", home.ID, "Home"},
+		{"Desk", office.ID, "Office"},
+		{"Lamp shade", bobList.ID, "Bob"},
+	}
+	for i, result := range page.Items {
+		if result.Name != want[i].name || result.WishlistID != want[i].wishlistID || result.WishlistTitle != want[i].title {
+			t.Errorf("result %d = %s in %s (%q), want %+v", i, result.Name, result.WishlistID, result.WishlistTitle, want[i])
+		}
+	}
+
+	// Пагинация применяется после отбора
+	page = searchItems(t, ts, alice.ID, "?q=lamp&limit=1&offset=2")
+	if page.Total != 3 || len(page.Items) != 1 || page.Items[0].Name != "Lamp shade" {
+		t.Errorf("second page = %+v (total %d)", page.Items, page.Total)
+	}
+}
+
+func TestSearchItemsExcludesInaccessibleWishlists(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	bobList := ts.createWishlist(bob.ID, "Bob")
+	ts.addItem(bob.ID, bobList.ID, gin.H{"name": "Lamp"})
+
+	if page := searchItems(t, ts, alice.ID, "?q=lamp"); page.Total != 0 || len(page.Items) != 0 {
+		t.Fatalf("search without access returned %+v", page.Items)
+	}
+
+	// После отзыва доступа элементы снова не видны
+	ts.share(bob.ID, bobList.ID, alice.ID, false)
+	if page := searchItems(t, ts, alice.ID, "?q=lamp"); page.Total != 1 {
+		t.Fatalf("search with access returned %d items, want 1", page.Total)
+	}
+	rec := ts.do(http.MethodDelete, "/api/v1/wishlists/"+bobList.ID+"/share/"+alice.ID, bob.ID, nil)
+	expectStatus(t, rec, http.StatusNoContent)
+	if page := searchItems(t, ts, alice.ID, "?q=lamp"); page.Total != 0 {
+		t.Errorf("search after unshare returned %d items, want 0", page.Total)
+	}
+
+	rec = ts.do(http.MethodGet, "/api/v1/items/search?q=%20", alice.ID, nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestSearchIgnoresAccentsAndCase(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	wishlist := ts.createWishlist(alice.ID, "Home")
+	for _, item := range []gin.H{
+		{"name": "Café table"},
+		{"name": "Mug", "description": "for crème brûlée"},
+		{"name": "ЁЛКА"},
+		{"name": "Straße map"},
+		{"name": "Coffee ﬁlter"},
+	} {
+		ts.addItem(alice.ID, wishlist.ID, item)
+		fake.Advance(time.Minute)
+	}
+
+	for query, want := range map[string]string{
+		"cafe":       "Café table",
+		"CAFÉ":       "Café table",
+		"cafe\u0301": "Café table", // e с отдельным знаком ударения
+		"CREME":      "Mug",
+		"brulee":     "Mug",
+		"елка":       "ЁЛКА",
+		"Ёлка":       "ЁЛКА",
+		"strasse":    "Straße map",
+		"filter":     "Coffee ﬁlter",
+		"tea":        "",
+	} {
+		page := searchItems(t, ts, alice.ID, "?q="+url.QueryEscape(query))
+		names := make([]string, 0, len(page.Items))
+		for _, item := range page.Items {
+			names = append(names, item.Name)
+		}
+		if got := strings.Join(names, ","); got != want {
+			t.Errorf("q=%q found %q, want %q", query, got, want)
+		}
+	}
+}
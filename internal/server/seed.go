@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+
+	"wana/internal/model"
+)
+
+// Начальные данные для демонстрационного запуска. Списки вложены в пользователей,
+// которым они принадлежат, элементы - в списки.
+type seedData struct {
+	Users []seedUser `json:"users"`
+}
+
+type seedUser struct {
+	Username  string         `json:"username"`
+	Email     string         `json:"email"`
+	Password  string         `json:"password"`
+	Verified  bool           `json:"email_verified"`
+	Wishlists []seedWishlist `json:"wishlists"`
+}
+
+type seedWishlist struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Tags        []string     `json:"tags"`
+	Items       []model.Item `json:"items"`
+}
+
+// LoadSeed загружает начальные данные из файла в хранилище, если оно пустое.
+// Возвращает false, если в хранилище уже есть данные.
+func LoadSeed(store Store, path string) (bool, error) {
+	return newServer(store).loadSeed(path)
+}
+
+// loadSeed читает файл с начальными данными и заполняет ими хранилища,
+// если в них еще нет ни пользователей, ни списков. Возвращает false, если хранилища не пусты.
+func (s *Server) loadSeed(path string) (bool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	var data seedData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return false, fmt.Errorf("parse seed file: %w", err)
+	}
+
+	return s.applySeed(data)
+}
+
+func (s *Server) applySeed(data seedData) (bool, error) {
+	// Проверяем данные и хэшируем пароли до захвата блокировок, так как bcrypt работает медленно
+	var newUsers []model.User
+	var newWishlists []model.Wishlist
+	var newItems []model.Item
+	seen := make(map[string]bool)
+
+	for i, su := range data.Users {
+		if su.Username == "" || su.Email == "" {
+			return false, fmt.Errorf("seed user %d: username and email are required", i)
+		}
+		username, err := normalizeUsername("username", su.Username)
+		if err != nil {
+			return false, fmt.Errorf("seed user %q: %w", su.Username, err)
+		}
+		su.Username = username
+		if seen["u:"+usernameKey(su.Username)] || seen["e:"+su.Email] {
+			return false, fmt.Errorf("seed user %q: duplicate username or email", su.Username)
+		}
+		seen["u:"+usernameKey(su.Username)] = true
+		seen["e:"+su.Email] = true
+		if su.Password == "" {
+			return false, fmt.Errorf("seed user %q: password is required", su.Username)
+		}
+
+		hashedPassword, err := hashPassword(su.Password)
+		if err != nil {
+			return false, fmt.Errorf("seed user %q: %w", su.Username, err)
+		}
+
+		user := model.User{
+			ID:            uuid.New().String(),
+			Username:      su.Username,
+			Email:         su.Email,
+			Password:      hashedPassword,
+			EmailVerified: su.Verified,
+		}
+		newUsers = append(newUsers, user)
+
+		for _, sw := range su.Wishlists {
+			wishlist := model.Wishlist{
+				ID:          uuid.New().String(),
+				UserID:      user.ID,
+				Title:       sw.Title,
+				Description: sw.Description,
+				CreatedAt:   clock.Now(),
+				UpdatedAt:   clock.Now(),
+				Version:     1,
+			}
+			if err := normalizeWishlist(&wishlist); err != nil {
+				return false, fmt.Errorf("seed user %q: %w", su.Username, err)
+			}
+			if wishlist.Tags, err = normalizeTags(sw.Tags); err != nil {
+				return false, fmt.Errorf("seed wishlist %q: %w", wishlist.Title, err)
+			}
+			newWishlists = append(newWishlists, wishlist)
+
+			for _, item := range sw.Items {
+				if item.Name == "" {
+					return false, fmt.Errorf("seed wishlist %q: item name is required", wishlist.Title)
+				}
+				if err := normalizeItem(&item); err != nil {
+					return false, fmt.Errorf("seed item %q: %w", item.Name, err)
+				}
+				item.ID = uuid.New().String()
+				item.WishlistID = wishlist.ID
+				item.AddedBy = user.ID
+				item.AddedByUsername = ""
+				item.ReservedBy = ""
+				item.Claimed = false
+				item.ClaimToken = ""
+				item.CreatedAt = clock.Now()
+				item.UpdatedAt = item.CreatedAt
+				item.Version = 1
+				newItems = append(newItems, item)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Начальные данные загружаются только в пустое хранилище
+	if len(s.users) > 0 || len(s.wishlists) > 0 {
+		return false, nil
+	}
+
+	for _, user := range newUsers {
+		s.users[user.ID] = user
+		s.indexUser(user)
+	}
+	for _, wishlist := range newWishlists {
+		s.wishlists[wishlist.ID] = wishlist
+	}
+	for _, item := range newItems {
+		s.putItem(item)
+	}
+
+	log.Printf("seed: created %d users, %d wishlists, %d items", len(newUsers), len(newWishlists), len(newItems))
+	return true, nil
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"wana/internal/model"
+)
+
+const testSeed = `{
+	"users": [
+		{
+			"username": "demo",
+			"email": "demo@example.com",
+			"password": "demo-Password1",
+			"email_verified": true,
+			"wishlists": [
+				{
+					"title": "  Birthday ",
+					"tags": ["Gifts", "gifts"],
+					"items": [
+						{"name": "Lamp", "price": "10"},
+						{"name": "Book", "reserved_by": "someone"}
+					]
+				}
+			]
+		},
+		{"username": "friend", "email": "friend@example.com", "password": "friend-Password1"}
+	]
+}`
+
+// writeSeed сохраняет начальные данные во временный файл и возвращает путь к нему
+func writeSeed(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "seed.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write seed: %v", err)
+	}
+	return path
+}
+
+// storeSize возвращает число пользователей, списков и элементов в хранилище
+func (ts *testServer) storeSize() (users, wishlists, items int) {
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	return len(ts.srv.users), len(ts.srv.wishlists), len(ts.srv.items)
+}
+
+func TestSeedEmptyStore(t *testing.T) {
+	ts := newTestServer(t)
+
+	loaded, err := ts.srv.loadSeed(writeSeed(t, testSeed))
+	if err != nil || !loaded {
+		t.Fatalf("loadSeed = %v, %v; want true, nil", loaded, err)
+	}
+	if users, wishlists, items := ts.storeSize(); users != 2 || wishlists != 1 || items != 2 {
+		t.Fatalf("store has %d users, %d wishlists, %d items; want 2, 1, 2", users, wishlists, items)
+	}
+
+	// Пароль сохранен в виде хэша, и по нему можно войти
+	rec := ts.login("demo", "demo-Password1")
+	expectStatus(t, rec, http.StatusOK)
+	var login struct {
+		Token string `json:"token"`
+	}
+	decodeBody(t, rec, &login)
+	ts.srv.mu.RLock()
+	stored := ts.srv.users[login.Token].Password
+	ts.srv.mu.RUnlock()
+	if stored == "demo-Password1" {
+		t.Error("seed password is stored in plain text")
+	}
+
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists", login.Token, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var summaries []model.WishlistSummary
+	decodeBody(t, rec, &summaries)
+	if len(summaries) != 1 {
+		t.Fatalf("demo has %d wishlists, want 1", len(summaries))
+	}
+	wishlist := summaries[0]
+	if wishlist.Title != "Birthday" || len(wishlist.Tags) != 1 || wishlist.Tags[0] != "gifts" {
+		t.Errorf("seeded wishlist is not normalized: %+v", wishlist)
+	}
+
+	items := ts.items(login.Token, wishlist.ID)
+	if len(items) != 2 {
+		t.Fatalf("seeded wishlist has %d items, want 2", len(items))
+	}
+	for _, item := range items {
+		if item.ReservedBy != "" || item.Version != 1 {
+			t.Errorf("seeded item %+v", item)
+		}
+	}
+}
+
+func TestSeedPopulatedStoreIsNoOp(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	ts.createWishlist(owner.ID, "Home")
+
+	loaded, err := ts.srv.loadSeed(writeSeed(t, testSeed))
+	if err != nil || loaded {
+		t.Fatalf("loadSeed = %v, %v; want false, nil", loaded, err)
+	}
+	if users, wishlists, items := ts.storeSize(); users != 1 || wishlists != 1 || items != 0 {
+		t.Errorf("store has %d users, %d wishlists, %d items after skipped seed", users, wishlists, items)
+	}
+	expectStatus(t, ts.login("demo", "demo-Password1"), http.StatusUnauthorized)
+}
+
+func TestSeedInvalidDataChangesNothing(t *testing.T) {
+	ts := newTestServer(t)
+
+	for name, content := range map[string]string{
+		"malformed":         `{"users": [`,
+		"no password":       `{"users": [{"username": "demo", "email": "demo@example.com"}]}`,
+		"duplicate email":   `{"users": [{"username": "one", "email": "x@example.com", "password": "p-Password1"}, {"username": "two", "email": "x@example.com", "password": "p-Password1"}]}`,
+		"item without name": `{"users": [{"username": "demo", "email": "demo@example.com", "password": "p-Password1", "wishlists": [{"title": "Home", "items": [{"price": "5"}]}]}]}`,
+	} {
+		if loaded, err := ts.srv.loadSeed(writeSeed(t, content)); err == nil || loaded {
+			t.Errorf("%s: loadSeed = %v, %v; want an error", name, loaded, err)
+		}
+	}
+	if users, wishlists, items := ts.storeSize(); users+wishlists+items != 0 {
+		t.Errorf("store has %d users, %d wishlists, %d items after invalid seeds", users, wishlists, items)
+	}
+}
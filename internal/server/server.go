@@ -0,0 +1,1779 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"wana/internal/model"
+)
+
+// NewRouter создает роутер со всеми маршрутами сервиса, работающими с переданным хранилищем
+func NewRouter(store Store) *gin.Engine {
+	s := newServer(store)
+	log.Printf("using bcrypt cost %d", bcryptCost)
+
+	cors, err := newCORSPolicy(corsAllowedOrigins, corsAllowCredentials, int64(corsMaxAge.Seconds()))
+	if err != nil {
+		log.Fatalf("invalid CORS configuration: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(gin.Logger(), requestIDMiddleware, gin.CustomRecovery(recoverPanic))
+	configureTrustedProxies(r, trustedProxies)
+	// Запрос к существующему адресу с неподдерживаемым методом получает 405, а не 404.
+	// Заголовок Allow со списком методов маршрута gin выставляет сам
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(methodNotAllowed)
+	r.Use(securityHeadersMiddleware, corsMiddleware(cors), gzipMiddleware, bodyLimitMiddleware, timeoutMiddleware)
+
+	// Группа маршрутов для аутентификации
+	auth := r.Group("/auth", rateLimitMiddleware(newRateLimiter(authRatePerMinute, authRateBurst)))
+	{
+		auth.POST("/register", rateLimitMiddleware(newRateLimiter(registerRatePerMinute, registerRateBurst)), s.register)
+		auth.POST("/login", s.login)
+		auth.GET("/verify", s.verifyEmail)
+	}
+
+	// Маршруты API регистрируются под /api/v1. Прежний префикс /api остается
+	// псевдонимом v1 на время перехода и помечается устаревшим.
+	// Лимит общий для обоих префиксов, чтобы его нельзя было обойти, чередуя их
+	apiLimit := rateLimitMiddleware(newRateLimiter(apiRatePerMinute, apiRateBurst))
+	s.registerAPIRoutes(r.Group(apiPrefix, s.authMiddleware, apiLimit))
+	s.registerAPIRoutes(r.Group("/api", deprecatedAliasMiddleware, s.authMiddleware, apiLimit))
+
+	// Просмотр по публичной ссылке доступен без аккаунта, лимит считается по адресу клиента
+	public := r.Group("/public", rateLimitMiddleware(newRateLimiter(apiRatePerMinute, apiRateBurst)))
+	{
+		public.GET("/:token", s.getPublicWishlist)
+		public.GET("/:token/view", s.viewPublicWishlist)
+		public.POST("/:token/items/:item_id/claim", s.claimItem)
+		public.DELETE("/:token/items/:item_id/claim", s.unclaimItem)
+	}
+
+	// Документ строится по зарегистрированным маршрутам, поэтому не расходится с ними
+	r.GET("/openapi.json", serveOpenAPI(r))
+
+	// Отладочные маршруты не входят в API и закрыты отдельной проверкой доступа
+	debug := r.Group("/debug", debugAccessMiddleware)
+	{
+		debug.GET("/info", s.getDebugInfo)
+		debug.GET("/mail", s.getDevMail)
+		if pprofEnabled {
+			registerPprofRoutes(debug)
+		}
+	}
+
+	return r
+}
+
+// Текущая версия API
+const apiPrefix = "/api/v1"
+
+// registerAPIRoutes регистрирует маршруты для работы со списками желаний в группе
+func (s *Server) registerAPIRoutes(api *gin.RouterGroup) {
+	api.GET("/wishlists", s.getWishlists)
+	api.GET("/wishlists/trash", s.getTrash)
+	api.POST("/wishlists", idempotencyMiddleware, s.createWishlist)
+	api.POST("/wishlists/import", idempotencyMiddleware, s.importWishlist)
+	api.GET("/wishlists/:id", s.getWishlist)
+	api.PUT("/wishlists/:id", s.updateWishlist)
+	api.DELETE("/wishlists/:id", s.deleteWishlist)
+	api.POST("/wishlists/:id/duplicate", s.duplicateWishlist)
+	api.POST("/wishlists/:id/archive", s.archiveWishlist)
+	api.POST("/wishlists/:id/unarchive", s.unarchiveWishlist)
+	api.POST("/wishlists/:id/lock", s.lockWishlist)
+	api.POST("/wishlists/:id/unlock", s.unlockWishlist)
+	api.POST("/wishlists/:id/restore", s.restoreWishlist)
+	api.GET("/wishlists/:id/export", s.exportWishlist)
+
+	api.GET("/wishlists/:id/items", s.getItems)
+	api.POST("/wishlists/:id/items", idempotencyMiddleware, s.addItem)
+	api.POST("/wishlists/:id/items/purchase-all", s.purchaseAllItems)
+	api.POST("/wishlists/:id/items/unpurchase-all", s.unpurchaseAllItems)
+	api.PUT("/wishlists/:id/items/:item_id", s.updateItem)
+	api.PATCH("/wishlists/:id/items/:item_id", s.updateItem)
+	api.DELETE("/wishlists/:id/items/:item_id", s.deleteItem)
+	api.POST("/wishlists/:id/items/:item_id/copy", s.copyItem)
+	api.POST("/wishlists/:id/items/:item_id/move", s.moveItem)
+	api.GET("/wishlists/:id/items/:item_id/comments", s.getComments)
+	api.POST("/wishlists/:id/items/:item_id/comments", s.addComment)
+	api.DELETE("/wishlists/:id/items/:item_id/comments/:comment_id", s.deleteComment)
+	api.POST("/wishlists/:id/items/:item_id/reserve", s.reserveItem)
+	api.DELETE("/wishlists/:id/items/:item_id/reserve", s.unreserveItem)
+	api.GET("/wishlists/:id/audit", s.getAuditLog)
+	api.GET("/wishlists/:id/events", s.streamWishlistEvents)
+	api.POST("/wishlists/:id/public-links", s.createPublicLink)
+	api.GET("/wishlists/:id/public-links", s.getPublicLinks)
+	api.DELETE("/wishlists/:id/public-links/:token", s.deletePublicLink)
+	api.POST("/wishlists/:id/favorite", s.addFavorite)
+	api.DELETE("/wishlists/:id/favorite", s.removeFavorite)
+
+	api.GET("/stats", s.getStats)
+	api.GET("/export/all", s.exportAll)
+
+	api.POST("/items/preview", previewItem)
+	api.GET("/items/search", s.searchItems)
+
+	api.GET("/account", s.getAccount)
+	api.PATCH("/account", s.updateAccount)
+	api.DELETE("/account", s.deleteAccount)
+	api.POST("/account/password", s.changePassword)
+	api.PUT("/account/webhook", s.setWebhook)
+	api.DELETE("/account/webhook", s.deleteWebhook)
+
+	api.POST("/wishlists/:id/share", s.shareWishlist)
+	api.DELETE("/wishlists/:id/share/:user_id", s.unshareWishlist)
+	api.POST("/wishlists/:id/transfer", s.transferWishlist)
+	api.GET("/shared", s.getSharedWishlists)
+	api.GET("/users/search", s.searchUsers)
+	api.POST("/shared/:wishlist_id/clone", s.cloneSharedWishlist)
+
+	api.GET("/notifications", s.getNotifications)
+	api.GET("/notifications/unread-count", s.getUnreadNotificationCount)
+	api.POST("/notifications/read-all", s.markAllNotificationsRead)
+	api.POST("/notifications/:id/read", s.markNotificationRead)
+}
+
+// methodNotAllowed отвечает на запрос с методом, которого нет у маршрута
+func methodNotAllowed(c *gin.Context) {
+	c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "method not allowed", "code": "METHOD_NOT_ALLOWED"})
+}
+
+// deprecatedAliasMiddleware помечает ответы на запросы по устаревшему префиксу /api
+// и указывает адрес того же ресурса в актуальной версии
+func deprecatedAliasMiddleware(c *gin.Context) {
+	successor := canonicalAPIPath(c.Request.URL.Path)
+	c.Header("Deprecation", "true")
+	c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+	c.Next()
+}
+
+// canonicalAPIPath переводит адрес по устаревшему префиксу /api в адрес того же ресурса под /api/v1
+func canonicalAPIPath(path string) string {
+	if path == apiPrefix || strings.HasPrefix(path, apiPrefix+"/") {
+		return path
+	}
+	return apiPrefix + strings.TrimPrefix(path, "/api")
+}
+
+// Middleware для проверки аутентификации
+func (s *Server) authMiddleware(c *gin.Context) {
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	token = strings.Split(token, " ")[1]
+
+	// В реальном приложении здесь должна быть проверка JWT токена
+	// Для упрощения просто проверяем, что пользователь существует
+	s.mu.RLock()
+	_, exists := s.users[token]
+	s.mu.RUnlock()
+
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	c.Set("userID", token)
+	c.Next()
+}
+
+// Хэлпер-функции
+func hashPassword(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	return string(bytes), err
+}
+
+func checkPasswordHash(password, hash string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+// needsRehash сообщает, что хэш создан с меньшей стоимостью, чем настроенная
+func needsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < bcryptCost
+}
+
+// upgradePasswordHash пересчитывает хэш пароля с текущей стоимостью после успешного входа.
+// Хэш заменяется, только если пароль не сменили, пока шло хэширование; ошибка не мешает входу
+func (s *Server) upgradePasswordHash(userID, password, oldHash string) {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		log.Printf("rehash password for user %s: %v", userID, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[userID]
+	if !exists || user.Password != oldHash {
+		return
+	}
+	user.Password = hashedPassword
+	s.users[userID] = user
+}
+
+// Обработчики маршрутов
+func (s *Server) register(c *gin.Context) {
+	var request model.RegisterRequest
+	if err := bindStrictJSON(c, &request); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	username, err := normalizeUsername("username", request.Username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+	request.Username = username
+
+	if err := validatePassword("password", request.Password); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	// Хэшируем пароль до захвата блокировки, так как bcrypt работает медленно
+	hashedPassword, err := hashPassword(request.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not hash password"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Проверяем, существует ли пользователь
+	if s.credentialsTaken(request.Username, request.Email, "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username or email already exists"})
+		return
+	}
+
+	// Создаем пользователя
+	user := model.User{
+		ID:       uuid.New().String(),
+		Username: request.Username,
+		Email:    request.Email,
+		Password: hashedPassword,
+	}
+	s.users[user.ID] = user
+	s.indexUser(user)
+
+	// Отправляем письмо для подтверждения email
+	if err := s.issueVerification(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not issue verification token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, userResponse(user))
+}
+
+func (s *Server) login(c *gin.Context) {
+	// Identifier принимает имя пользователя или email,
+	// поле username оставлено для совместимости
+	var credentials struct {
+		Identifier string `json:"identifier"`
+		Username   string `json:"username"`
+		Password   string `json:"password" binding:"required"`
+	}
+
+	if err := bindJSON(c, &credentials); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	identifier := credentials.Identifier
+	if identifier == "" {
+		identifier = credentials.Username
+	}
+	if identifier == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "identifier or username is required"})
+		return
+	}
+
+	// Ищем пользователя сначала по имени без учета регистра, затем по email
+	s.mu.RLock()
+	userID, exists := s.usersByUsername[usernameKey(identifier)]
+	if !exists {
+		userID = s.usersByEmail[identifier]
+	}
+	foundUser, exists := s.users[userID]
+	s.mu.RUnlock()
+
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	// Проверяем пароль
+	if !checkPasswordHash(credentials.Password, foundUser.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	// Пароль верный: если стоимость bcrypt с тех пор повысили, обновляем хэш
+	if rehashOnLogin && needsRehash(foundUser.Password) {
+		s.upgradePasswordHash(foundUser.ID, credentials.Password, foundUser.Password)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token": foundUser.ID,
+		"user":  userResponse(foundUser),
+	})
+}
+
+func (s *Server) createWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	var wishlist model.Wishlist
+	if err := bindStrictJSON(c, &wishlist); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	if err := normalizeWishlist(&wishlist); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	tags, err := normalizeTags(wishlist.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.canAddWishlists(userID, 1) {
+		abortQuotaExceeded(c, "wishlist quota exceeded", maxWishlistsPerUser)
+		return
+	}
+
+	wishlist.ID = uuid.New().String()
+	wishlist.UserID = userID
+	wishlist.Tags = tags
+	wishlist.Archived = false
+	wishlist.Locked = false
+	wishlist.DeletedAt = nil
+	wishlist.CreatedAt = clock.Now()
+	wishlist.UpdatedAt = clock.Now()
+	wishlist.Version = 1
+
+	s.wishlists[wishlist.ID] = wishlist
+
+	c.Header("Location", wishlistLocation(wishlist.ID))
+	c.JSON(http.StatusCreated, wishlist)
+}
+
+func (s *Server) getWishlists(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	if raw, ok := c.GetQuery("ids"); ok {
+		s.getWishlistsByIDs(c, userID, raw)
+		return
+	}
+
+	includeArchived, err := strconv.ParseBool(c.DefaultQuery("include_archived", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "include_archived must be true or false"})
+		return
+	}
+	tag := strings.ToLower(strings.TrimSpace(c.Query("tag")))
+
+	// Диапазон дат создания полуоткрытый: created_after включительно, created_before - нет
+	createdAfter, err := parseTimeBound(c, "created_after")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	createdBefore, err := parseTimeBound(c, "created_before")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if createdAfter != nil && createdBefore != nil && !createdAfter.Before(*createdBefore) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "created_after must be earlier than created_before"})
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summaries := []model.WishlistSummary{}
+	for _, w := range s.wishlists {
+		if w.DeletedAt != nil || (w.Archived && !includeArchived) {
+			continue
+		}
+		if tag != "" && !hasTag(w.Tags, tag) {
+			continue
+		}
+		if createdAfter != nil && w.CreatedAt.Before(*createdAfter) {
+			continue
+		}
+		if createdBefore != nil && !w.CreatedAt.Before(*createdBefore) {
+			continue
+		}
+		if w.UserID == userID {
+			summaries = append(summaries, model.WishlistSummary{Wishlist: w})
+		}
+	}
+	s.fillItemCounts(summaries)
+
+	c.JSON(http.StatusOK, summaries)
+}
+
+// getWishlistsByIDs возвращает запрошенные через ids списки, к которым у пользователя есть доступ,
+// в порядке запроса. Остальные фильтры не применяются. Недоступные и несуществующие ID
+// перечисляются в denied без объяснения причины.
+func (s *Server) getWishlistsByIDs(c *gin.Context, userID, raw string) {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(raw, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids must not be empty"})
+		return
+	}
+	if int64(len(ids)) > maxBatchIDs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("too many ids, at most %d allowed", maxBatchIDs), "limit": maxBatchIDs})
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	batch := model.WishlistBatch{
+		Wishlists: []model.WishlistSummary{},
+		Denied:    []string{},
+	}
+	for _, id := range ids {
+		wishlist, exists := s.findWishlist(id)
+		if !exists || (wishlist.UserID != userID && !s.hasSharedAccess(userID, id)) {
+			batch.Denied = append(batch.Denied, id)
+			continue
+		}
+		batch.Wishlists = append(batch.Wishlists, model.WishlistSummary{Wishlist: wishlist})
+	}
+	s.fillItemCounts(batch.Wishlists)
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// fillItemCounts заполняет счетчики элементов у списков по индексу элементов списков,
+// не просматривая все элементы хранилища. Вызывается под блокировкой mu.
+func (s *Server) fillItemCounts(summaries []model.WishlistSummary) {
+	for i := range summaries {
+		ids := s.itemsByWishlist[summaries[i].ID]
+		summaries[i].ItemCount = len(ids)
+		for itemID := range ids {
+			if s.items[itemID].IsPurchased {
+				summaries[i].PurchasedCount++
+			}
+		}
+	}
+}
+
+func (s *Server) getWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	format, ok := negotiateFormat(c)
+	if !ok {
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	// Проверяем, что пользователь имеет доступ к списку
+	if wishlist.UserID != userID && !s.hasSharedAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	// Клиент может не загружать список повторно, если он не менялся.
+	// Представления в разных форматах получают разные ETag.
+	etag := wishlistETag(wishlist)
+	if format != binding.MIMEJSON {
+		etag = strings.TrimSuffix(etag, `"`) + `-xml"`
+	}
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	render(c, format, http.StatusOK, wishlist, nil)
+}
+
+func (s *Server) updateWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	var update model.Wishlist
+	if err := bindStrictJSON(c, &update); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	if err := normalizeWishlist(&update); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	tags, err := normalizeTags(update.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, ok := expectedVersion(c, update.Version)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	// Проверяем права на редактирование
+	if wishlist.UserID != userID && !s.hasEditAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	if version != wishlist.Version {
+		c.JSON(http.StatusConflict, gin.H{"error": "wishlist was modified by another request", "code": "VERSION_CONFLICT", "version": wishlist.Version})
+		return
+	}
+
+	if update.UniqueExternalIDs && !wishlist.UniqueExternalIDs && s.hasDuplicateExternalIDs(wishlistID) {
+		abortExternalIDConflict(c, "wishlist already contains items with the same external_id")
+		return
+	}
+
+	// Обновляем поля
+	wishlist.Title = update.Title
+	wishlist.Description = update.Description
+	wishlist.Tags = tags
+	wishlist.UniqueExternalIDs = update.UniqueExternalIDs
+	wishlist.UpdatedAt = clock.Now()
+	wishlist.Version++
+
+	s.wishlists[wishlistID] = wishlist
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+func (s *Server) deleteWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	// С dry_run=true ничего не удаляется: в ответе только то, что удалится вместе со списком
+	dryRun := false
+	if raw, ok := c.GetQuery("dry_run"); ok {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be a boolean"})
+			return
+		}
+		dryRun = parsed
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	// Проверяем права на удаление (только владелец может удалить)
+	if wishlist.UserID != userID {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, s.deleteImpact(wishlistID))
+		return
+	}
+
+	// Перемещаем список в корзину, элементы и доступы сохраняются до очистки
+	deletedAt := clock.Now()
+	wishlist.DeletedAt = &deletedAt
+	s.wishlists[wishlistID] = wishlist
+
+	c.Status(http.StatusNoContent)
+}
+
+// deleteImpact считает элементы, записи о доступе и публичные ссылки, которые удалятся
+// вместе со списком при очистке корзины. Вызывается под блокировкой mu.
+func (s *Server) deleteImpact(wishlistID string) model.DeleteImpact {
+	impact := model.DeleteImpact{WishlistID: wishlistID, DryRun: true}
+	impact.Items = int(s.itemCount(wishlistID))
+	for _, share := range s.sharedWishlists {
+		if share.WishlistID == wishlistID {
+			impact.Shares++
+		}
+	}
+	for _, link := range s.publicLinks {
+		if link.WishlistID == wishlistID {
+			impact.PublicLinks++
+		}
+	}
+	return impact
+}
+
+func (s *Server) duplicateWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	// Копировать может только владелец
+	if wishlist.UserID != userID {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	if !s.canAddWishlists(userID, 1) {
+		abortQuotaExceeded(c, "wishlist quota exceeded", maxWishlistsPerUser)
+		return
+	}
+
+	duplicate := model.Wishlist{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Title:       wishlist.Title + " (copy)",
+		Description: wishlist.Description,
+		Tags:        append([]string(nil), wishlist.Tags...),
+		CreatedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
+		Version:     1,
+	}
+	s.wishlists[duplicate.ID] = duplicate
+
+	// Записи о совместном доступе не переносятся
+	s.copyWishlistItems(wishlistID, duplicate.ID, userID)
+
+	c.Header("Location", wishlistLocation(duplicate.ID))
+	c.JSON(http.StatusCreated, duplicate)
+}
+
+// cloneSharedWishlist создает в аккаунте пользователя копию списка, которым с ним поделились.
+// В отличие от duplicateWishlist исходный список принадлежит другому пользователю.
+func (s *Server) cloneSharedWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("wishlist_id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "wishlist already belongs to you, use duplicate instead"})
+		return
+	}
+
+	if !s.hasSharedAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	if !s.canAddWishlists(userID, 1) {
+		abortQuotaExceeded(c, "wishlist quota exceeded", maxWishlistsPerUser)
+		return
+	}
+
+	clone := model.Wishlist{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Title:       wishlist.Title,
+		Description: wishlist.Description,
+		Tags:        append([]string(nil), wishlist.Tags...),
+		CreatedAt:   clock.Now(),
+		UpdatedAt:   clock.Now(),
+		Version:     1,
+	}
+	s.wishlists[clone.ID] = clone
+	s.copyWishlistItems(wishlistID, clone.ID, userID)
+
+	c.Header("Location", wishlistLocation(clone.ID))
+	c.JSON(http.StatusCreated, clone)
+}
+
+// copyWishlistItems копирует элементы одного списка в другой с новыми ID,
+// сброшенной отметкой о покупке и без резервов. Копии считаются добавленными addedBy.
+// Вызывается под блокировкой mu.
+func (s *Server) copyWishlistItems(sourceID, targetID, addedBy string) {
+	var copies []model.Item
+	for _, item := range s.items {
+		if item.WishlistID == sourceID {
+			item.ID = uuid.New().String()
+			item.WishlistID = targetID
+			item.AddedBy = addedBy
+			item.IsPurchased = false
+			item.ReservedBy = ""
+			item.Claimed = false
+			item.ClaimToken = ""
+			item.CreatedAt = clock.Now()
+			item.UpdatedAt = item.CreatedAt
+			item.Version = 1
+			copies = append(copies, item)
+		}
+	}
+	for _, item := range copies {
+		s.putItem(item)
+	}
+}
+
+func (s *Server) archiveWishlist(c *gin.Context) {
+	s.setWishlistArchived(c, true)
+}
+
+func (s *Server) unarchiveWishlist(c *gin.Context) {
+	s.setWishlistArchived(c, false)
+}
+
+func (s *Server) setWishlistArchived(c *gin.Context, archived bool) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	// Архивировать может только владелец
+	if wishlist.UserID != userID {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	wishlist.Archived = archived
+	wishlist.UpdatedAt = clock.Now()
+	wishlist.Version++
+	s.wishlists[wishlistID] = wishlist
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+func (s *Server) addItem(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	dedupe, err := parseDedupe(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var item model.Item
+	if err := bindStrictJSON(c, &item); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	if err := normalizeItem(&item); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Проверяем существование списка и права доступа
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID && !s.hasEditAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	if wishlist.Archived {
+		c.JSON(http.StatusConflict, gin.H{"error": "wishlist is archived, unarchive it to edit items"})
+		return
+	}
+
+	if wishlist.Locked {
+		abortWishlistLocked(c, "wishlist is locked, unlock it to edit items")
+		return
+	}
+
+	// Элемент с таким названием уже есть: возвращаем его вместо создания нового
+	if dedupe {
+		if existing, found := s.findItemByName(wishlistID, item.Name); found {
+			c.Header("Location", itemLocation(existing))
+			c.JSON(http.StatusOK, existing)
+			return
+		}
+	}
+
+	if s.externalIDTaken(wishlist, item, "") {
+		abortExternalIDConflict(c, "an item with this external_id already exists in the wishlist")
+		return
+	}
+
+	if !s.canAddItems(wishlistID, 1) {
+		abortQuotaExceeded(c, "item quota exceeded for this wishlist", maxItemsPerWishlist)
+		return
+	}
+
+	// Создаем элемент
+	item.ID = uuid.New().String()
+	item.WishlistID = wishlistID
+	item.AddedBy = userID
+	item.AddedByUsername = ""
+	item.IsPurchased = false
+	item.ReservedBy = ""
+	item.Claimed = false
+	item.CreatedAt = clock.Now()
+	item.UpdatedAt = item.CreatedAt
+	item.Version = 1
+
+	if err := s.AddItem(item); err != nil {
+		abortItemStoreError(c, err)
+		return
+	}
+	s.touchItems(wishlistID)
+	s.recordAudit(wishlistID, userID, auditItemAdded, item.ID)
+	publishWishlistEvent(wishlistID, auditItemAdded, item)
+
+	c.Header("Location", itemLocation(item))
+	c.JSON(http.StatusCreated, item)
+}
+
+func (s *Server) getItems(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	groupBy := c.Query("group_by")
+	if groupBy != "" && groupBy != "category" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported group_by value"})
+		return
+	}
+
+	// Сортировка по времени создания: created_at - по возрастанию, -created_at - по убыванию
+	sortBy := c.Query("sort")
+	if sortBy != "" && sortBy != "created_at" && sortBy != "-created_at" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported sort value"})
+		return
+	}
+
+	filter, err := parseItemFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format, ok := negotiateFormat(c)
+	if !ok {
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Проверяем существование списка и права доступа
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID && !s.hasSharedAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	// Собираем элементы списка
+	wishlistItems := []model.Item{}
+	for _, item := range s.items {
+		if item.WishlistID == wishlistID && filter.matches(item) {
+			wishlistItems = append(wishlistItems, item)
+		}
+	}
+
+	// Элементы хранятся в словаре, поэтому страницы стабильны, только если сортировать
+	// до нарезки. Без параметра sort элементы идут в порядке создания.
+	sortItemsByCreatedAt(wishlistItems, sortBy == "-created_at")
+
+	total := len(wishlistItems)
+	start, end := pageBounds(total, limit, offset)
+	page := wishlistItems[start:end]
+
+	// Имена добавивших подставляются только в элементы страницы, хранилище не меняется
+	for i := range page {
+		page[i].AddedByUsername = s.users[page[i].AddedBy].Username
+		if wishlist.UserID == userID {
+			page[i] = hideReserver(page[i])
+		}
+	}
+
+	if groupBy == "category" {
+		groups := model.ItemGroupPage{
+			Groups: groupItemsByCategory(page),
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		}
+		render(c, format, http.StatusOK, groups, groupsToXML(groups))
+		return
+	}
+
+	render(c, format, http.StatusOK,
+		model.ItemPage{Items: page, Total: total, Limit: limit, Offset: offset},
+		itemListXML{Items: page, Total: total, Limit: limit, Offset: offset})
+}
+
+func (s *Server) updateItem(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+	itemID := c.Param("item_id")
+
+	// С типом application/merge-patch+json тело содержит только изменяемые поля (RFC 7386),
+	// иначе - элемент целиком, и отсутствующие поля очищаются
+	var update model.Item
+	var patch map[string]interface{}
+	mergePatch := isMergePatch(c)
+	if mergePatch {
+		var err error
+		if patch, err = bindMergePatch(c); err != nil {
+			c.JSON(http.StatusBadRequest, validationError(err))
+			return
+		}
+		// Версия в патче - ожидаемая версия элемента, а не новое значение поля
+		if version, exists := patch["version"]; exists {
+			number, ok := version.(float64)
+			if !ok {
+				c.JSON(http.StatusBadRequest, validationError(&FieldError{Field: "version", Message: "must be a number"}))
+				return
+			}
+			update.Version = int(number)
+			delete(patch, "version")
+		}
+	} else {
+		if err := bindStrictJSON(c, &update); err != nil {
+			c.JSON(http.StatusBadRequest, validationError(err))
+			return
+		}
+
+		if err := normalizeItem(&update); err != nil {
+			c.JSON(http.StatusBadRequest, validationError(err))
+			return
+		}
+	}
+
+	// If-Unmodified-Since может заменять версию; если передано и то и другое, проверяются оба условия
+	since, ok := unmodifiedSince(c)
+	if !ok {
+		return
+	}
+	version := 0
+	if since == nil || c.GetHeader("If-Match") != "" || update.Version > 0 {
+		if version, ok = expectedVersion(c, update.Version); !ok {
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Проверяем существование списка и права доступа
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID && !s.hasEditAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	if wishlist.Archived {
+		c.JSON(http.StatusConflict, gin.H{"error": "wishlist is archived, unarchive it to edit items"})
+		return
+	}
+
+	if wishlist.Locked {
+		abortWishlistLocked(c, "wishlist is locked, unlock it to edit items")
+		return
+	}
+
+	// Проверяем существование элемента
+	item, err := s.getItemInWishlist(wishlistID, itemID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if version != 0 && version != item.Version {
+		c.JSON(http.StatusConflict, gin.H{"error": "item was modified by another request", "code": "VERSION_CONFLICT", "version": item.Version})
+		return
+	}
+
+	// HTTP-дата имеет точность до секунды, поэтому время изменения округляется так же
+	if since != nil && item.UpdatedAt.Truncate(time.Second).After(*since) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "item was modified after If-Unmodified-Since", "code": "PRECONDITION_FAILED", "updated_at": item.UpdatedAt})
+		return
+	}
+
+	if mergePatch {
+		if update, err = mergeItemPatch(item, patch); err == nil {
+			err = normalizeItem(&update)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, validationError(err))
+			return
+		}
+	}
+
+	if s.externalIDTaken(wishlist, update, itemID) {
+		abortExternalIDConflict(c, "an item with this external_id already exists in the wishlist")
+		return
+	}
+
+	// Обновляем поля
+	item.Name = update.Name
+	item.Description = update.Description
+	item.Price = update.Price
+	item.Link = update.Link
+	item.ImageURL = update.ImageURL
+	item.Category = update.Category
+	item.ExternalID = update.ExternalID
+	item.ExternalSource = update.ExternalSource
+	purchased := !item.IsPurchased && update.IsPurchased
+	item.IsPurchased = update.IsPurchased
+
+	// Купленный элемент больше не может быть зарезервирован или отмечен гостем
+	reservedBy := item.ReservedBy
+	if purchased {
+		item.ReservedBy = ""
+		item.Claimed = false
+		item.ClaimToken = ""
+	}
+	item.UpdatedAt = clock.Now()
+	item.Version++
+
+	s.putItem(item)
+	s.touchItems(wishlistID)
+	s.recordAudit(wishlistID, userID, auditItemUpdated, itemID)
+	publishWishlistEvent(wishlistID, auditItemUpdated, item)
+
+	if purchased {
+		s.recordAudit(wishlistID, userID, auditItemPurchased, itemID)
+		publishWishlistEvent(wishlistID, auditItemPurchased, item)
+		s.emitWebhook(wishlist.UserID, "item.purchased", item)
+
+		if reservedBy != "" && reservedBy != userID {
+			s.notifyReservationCleared(reservedBy, item, "was cleared because the item was purchased")
+		}
+	}
+
+	c.Header("Last-Modified", item.UpdatedAt.UTC().Format(http.TimeFormat))
+	c.JSON(http.StatusOK, item)
+}
+
+func (s *Server) deleteItem(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+	itemID := c.Param("item_id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Проверяем существование списка и права доступа
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID && !s.hasEditAccess(userID, wishlistID) {
+		s.denyWishlistAccess(c, userID, wishlist, "access denied")
+		return
+	}
+
+	if wishlist.Archived {
+		c.JSON(http.StatusConflict, gin.H{"error": "wishlist is archived, unarchive it to edit items"})
+		return
+	}
+
+	if wishlist.Locked {
+		abortWishlistLocked(c, "wishlist is locked, unlock it to edit items")
+		return
+	}
+
+	// Проверяем существование элемента
+	item, err := s.getItemInWishlist(wishlistID, itemID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.removeItem(itemID)
+	delete(s.comments, itemID)
+	s.touchItems(wishlistID)
+	s.recordAudit(wishlistID, userID, auditItemDeleted, itemID)
+	publishWishlistEvent(wishlistID, auditItemDeleted, item)
+
+	if item.ReservedBy != "" && item.ReservedBy != userID {
+		s.notifyReservationCleared(item.ReservedBy, item, "was cancelled because the item was removed")
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) shareWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	// Пользователя можно указать по ID, имени или email
+	var shareRequest struct {
+		SharedUserID string     `json:"shared_user_id"`
+		Username     string     `json:"username"`
+		Email        string     `json:"email"`
+		CanEdit      *bool      `json:"can_edit"`
+		ExpiresAt    *time.Time `json:"expires_at"`
+	}
+
+	if err := bindStrictJSON(c, &shareRequest); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	canEdit := shareDefaultCanEdit
+	if shareRequest.CanEdit != nil {
+		canEdit = *shareRequest.CanEdit
+	}
+
+	expiresAt := shareRequest.ExpiresAt
+	if expiresAt == nil && shareDefaultTTL > 0 {
+		defaultExpiry := clock.Now().Add(shareDefaultTTL)
+		expiresAt = &defaultExpiry
+	}
+	if expiresAt != nil && !expiresAt.After(clock.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future", "field": "expires_at"})
+		return
+	}
+
+	if shareRequest.SharedUserID == "" && shareRequest.Username == "" && shareRequest.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "one of shared_user_id, username or email is required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Проверяем существование списка
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	// Проверяем, что пользователь является владельцем
+	if wishlist.UserID != userID {
+		s.denyWishlistAccess(c, userID, wishlist, "only owner can share wishlist")
+		return
+	}
+
+	verified := s.users[userID].EmailVerified
+	ownerName := s.users[userID].Username
+	target, exists := s.resolveUser(shareRequest.SharedUserID, shareRequest.Username, shareRequest.Email)
+
+	// Делиться списками можно только после подтверждения email
+	if !verified {
+		c.JSON(http.StatusForbidden, gin.H{"error": "email must be verified to share wishlists"})
+		return
+	}
+
+	// Проверяем существование пользователя, с которым делимся
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user to share with not found"})
+		return
+	}
+
+	// Проверка выполняется после определения пользователя,
+	// как бы он ни был указан в запросе
+	if err := checkShareTarget(wishlist, target.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "SHARE_TO_OWNER"})
+		return
+	}
+
+	// Повторный доступ тому же пользователю меняет права существующей записи
+	if share, exists := s.findShare(wishlistID, target.ID); exists {
+		share.CanEdit = canEdit
+		share.ExpiresAt = expiresAt
+		s.sharedWishlists[share.ID] = share
+		s.reconcileShares(wishlistID)
+		c.JSON(http.StatusOK, share)
+		return
+	}
+
+	if s.activeShareCount(wishlistID) >= maxSharesPerWishlist {
+		abortShareLimitExceeded(c, "too many users have access to this wishlist, revoke unused shares first", maxSharesPerWishlist)
+		return
+	}
+
+	// Создаем запись о совместном доступе
+	share := model.SharedWishlist{
+		ID:         uuid.New().String(),
+		WishlistID: wishlistID,
+		UserID:     target.ID,
+		CanEdit:    canEdit,
+		ExpiresAt:  expiresAt,
+	}
+
+	// Доступ, уведомление и запись в журнале сохраняются вместе или не сохраняются вовсе
+	err := s.WithTx(func(tx *Tx) error {
+		tx.PutShare(share)
+		if err := tx.Notify(target.ID, notificationWishlistShared, ownerName+" shared the wishlist \""+wishlist.Title+"\" with you"); err != nil {
+			return err
+		}
+		tx.RecordAudit(wishlistID, userID, auditWishlistShared, target.ID)
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user to share with not found"})
+		return
+	}
+	s.reconcileShares(wishlistID)
+
+	c.JSON(http.StatusCreated, share)
+}
+
+// unshareWishlist отзывает доступ пользователя к списку и освобождает место в лимите записей о доступе
+func (s *Server) unshareWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	if wishlist.UserID != userID {
+		s.denyWishlistAccess(c, userID, wishlist, "only owner can revoke access")
+		return
+	}
+
+	share, exists := s.findShare(wishlistID, c.Param("user_id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return
+	}
+
+	delete(s.sharedWishlists, share.ID)
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) transferWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	// Нового владельца можно указать по ID, имени или email.
+	// KeepAccess оставляет прежнему владельцу доступ к списку.
+	var transferRequest struct {
+		UserID     string `json:"user_id"`
+		Username   string `json:"username"`
+		Email      string `json:"email"`
+		KeepAccess bool   `json:"keep_access"`
+		CanEdit    bool   `json:"can_edit"`
+	}
+
+	if err := bindStrictJSON(c, &transferRequest); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	if transferRequest.UserID == "" && transferRequest.Username == "" && transferRequest.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "one of user_id, username or email is required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.findWishlist(wishlistID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+
+	// Передать список может только владелец
+	if wishlist.UserID != userID {
+		s.denyWishlistAccess(c, userID, wishlist, "only owner can transfer wishlist")
+		return
+	}
+
+	ownerName := s.users[userID].Username
+	target, exists := s.resolveUser(transferRequest.UserID, transferRequest.Username, transferRequest.Email)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user to transfer to not found"})
+		return
+	}
+
+	if target.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "wishlist already belongs to this user"})
+		return
+	}
+
+	// Переданный список занимает квоту нового владельца
+	if !s.canAddWishlists(target.ID, 1) {
+		abortQuotaExceeded(c, "wishlist quota of the new owner exceeded", maxWishlistsPerUser)
+		return
+	}
+
+	// Доступ прежнего владельца - такая же запись о доступе, как выданная через share,
+	// и подчиняется тому же лимиту. Запись нового владельца после передачи удаляется и место не занимает
+	if transferRequest.KeepAccess {
+		shares := s.activeShareCount(wishlistID)
+		if s.hasSharedAccess(target.ID, wishlistID) {
+			shares--
+		}
+		if shares >= maxSharesPerWishlist {
+			abortShareLimitExceeded(c, "too many users have access to this wishlist, revoke unused shares before keeping access", maxSharesPerWishlist)
+			return
+		}
+	}
+
+	wishlist.UserID = target.ID
+	wishlist.UpdatedAt = clock.Now()
+	wishlist.Version++
+	s.wishlists[wishlistID] = wishlist
+
+	if transferRequest.KeepAccess {
+		share := model.SharedWishlist{
+			ID:         uuid.New().String(),
+			WishlistID: wishlistID,
+			UserID:     userID,
+			CanEdit:    transferRequest.CanEdit,
+		}
+		s.sharedWishlists[share.ID] = share
+	}
+
+	// Новый владелец больше не нуждается в совместном доступе
+	s.reconcileShares(wishlistID)
+
+	s.notify(target.ID, notificationWishlistTransferred, ownerName+" transferred the wishlist \""+wishlist.Title+"\" to you")
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+func (s *Server) getSharedWishlists(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	// Параметр favorites=true оставляет только избранные списки
+	onlyFavorites := false
+	if raw := c.Query("favorites"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "favorites must be a boolean"})
+			return
+		}
+		onlyFavorites = parsed
+	}
+
+	// Параметр can_edit оставляет только списки с правом редактирования (true) или только для просмотра (false)
+	var canEdit *bool
+	if raw, ok := c.GetQuery("can_edit"); ok {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "can_edit must be a boolean"})
+			return
+		}
+		canEdit = &parsed
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	shared := []model.SharedWishlistEntry{}
+
+	for _, share := range s.sharedWishlists {
+		if share.UserID == userID && shareActive(share) {
+			if canEdit != nil && share.CanEdit != *canEdit {
+				continue
+			}
+
+			wishlist, exists := s.findWishlist(share.WishlistID)
+			if !exists {
+				continue
+			}
+
+			favorite := s.isFavorite(userID, share.WishlistID)
+			if onlyFavorites && !favorite {
+				continue
+			}
+
+			shared = append(shared, model.SharedWishlistEntry{
+				Wishlist: wishlist,
+				Owner: model.WishlistOwner{
+					ID:       wishlist.UserID,
+					Username: s.users[wishlist.UserID].Username,
+				},
+				CanEdit:  share.CanEdit,
+				Favorite: favorite,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, shared)
+}
+
+// Вспомогательные функции
+
+// userResponse возвращает публичные данные пользователя без хэша пароля.
+// Все ответы с данными пользователя строятся через нее.
+func userResponse(user model.User) model.UserResponse {
+	return model.UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+// resolveUser ищет пользователя по ID, имени или email - по первому заданному значению.
+// Вызывается под блокировкой mu.
+func (s *Server) resolveUser(id, username, email string) (model.User, bool) {
+	switch {
+	case id != "":
+		user, exists := s.users[id]
+		return user, exists
+	case username != "":
+		user, exists := s.users[s.usersByUsername[usernameKey(username)]]
+		return user, exists
+	case email != "":
+		user, exists := s.users[s.usersByEmail[email]]
+		return user, exists
+	}
+	return model.User{}, false
+}
+
+// checkShareTarget запрещает выдавать доступ владельцу списка
+func checkShareTarget(wishlist model.Wishlist, targetUserID string) error {
+	if targetUserID == wishlist.UserID {
+		return errors.New("cannot share a wishlist with its owner")
+	}
+	return nil
+}
+
+// findShare возвращает запись о доступе пользователя к списку.
+// Вызывается под блокировкой mu.
+func (s *Server) findShare(wishlistID, userID string) (model.SharedWishlist, bool) {
+	for _, share := range s.sharedWishlists {
+		if share.WishlistID == wishlistID && share.UserID == userID {
+			return share, true
+		}
+	}
+	return model.SharedWishlist{}, false
+}
+
+// reconcileShares приводит записи о доступе к списку в согласие с его владельцем:
+// удаляет доступ самого владельца (например, после передачи списка), истекшие записи
+// и повторные записи для одного пользователя, оставляя запись с меньшим ID.
+// Записи удаленного списка удаляются.
+// Вызывается после каждой операции, меняющей владельца или доступ, под блокировкой mu.
+func (s *Server) reconcileShares(wishlistID string) {
+	wishlist, exists := s.wishlists[wishlistID]
+
+	kept := make(map[string]string)
+	for shareID, share := range s.sharedWishlists {
+		if share.WishlistID != wishlistID {
+			continue
+		}
+		if !exists || share.UserID == wishlist.UserID || !shareActive(share) {
+			delete(s.sharedWishlists, shareID)
+			continue
+		}
+		if other, ok := kept[share.UserID]; ok {
+			if other < shareID {
+				delete(s.sharedWishlists, shareID)
+				continue
+			}
+			delete(s.sharedWishlists, other)
+		}
+		kept[share.UserID] = shareID
+	}
+}
+
+// credentialsTaken проверяет, занято ли имя или email другим пользователем.
+// Вызывается под блокировкой mu.
+func (s *Server) credentialsTaken(username, email, exceptUserID string) bool {
+	if id, exists := s.usersByUsername[usernameKey(username)]; exists && id != exceptUserID {
+		return true
+	}
+	if id, exists := s.usersByEmail[email]; exists && id != exceptUserID {
+		return true
+	}
+	return false
+}
+
+// indexUser и unindexUser поддерживают индексы пользователей.
+// Имена хранятся в индексе по usernameKey, чтобы имена, отличающиеся только регистром, совпадали.
+// Вызываются под блокировкой mu при любом изменении имени или email.
+func (s *Server) indexUser(user model.User) {
+	s.usersByUsername[usernameKey(user.Username)] = user.ID
+	s.usersByEmail[user.Email] = user.ID
+}
+
+func (s *Server) unindexUser(user model.User) {
+	delete(s.usersByUsername, usernameKey(user.Username))
+	delete(s.usersByEmail, user.Email)
+}
+
+// findWishlist возвращает список, если он существует и не находится в корзине
+func (s *Server) findWishlist(wishlistID string) (model.Wishlist, bool) {
+	wishlist, exists := s.wishlists[wishlistID]
+	if !exists || wishlist.DeletedAt != nil {
+		return model.Wishlist{}, false
+	}
+	return wishlist, true
+}
+
+// wishlistLocation возвращает канонический адрес списка для заголовка Location
+func wishlistLocation(wishlistID string) string {
+	return apiPrefix + "/wishlists/" + wishlistID
+}
+
+// itemLocation возвращает канонический адрес элемента для заголовка Location
+func itemLocation(item model.Item) string {
+	return wishlistLocation(item.WishlistID) + "/items/" + item.ID
+}
+
+// expectedVersion извлекает ожидаемую версию записи из заголовка If-Match или поля version в теле.
+// Заголовок имеет приоритет. При ошибке ответ уже отправлен и возвращается false.
+func expectedVersion(c *gin.Context, bodyVersion int) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		if bodyVersion <= 0 {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header or version field is required", "code": "VERSION_REQUIRED"})
+			return 0, false
+		}
+		return bodyVersion, true
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must contain a record version"})
+		return 0, false
+	}
+	return version, true
+}
+
+// unmodifiedSince разбирает заголовок If-Unmodified-Since. Без заголовка возвращает nil.
+// При ошибке ответ уже отправлен и возвращается false.
+func unmodifiedSince(c *gin.Context) (*time.Time, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Unmodified-Since"))
+	if header == "" {
+		return nil, true
+	}
+
+	since, err := http.ParseTime(header)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Unmodified-Since must be an HTTP date"})
+		return nil, false
+	}
+	return &since, true
+}
+
+// touchItems отмечает изменение элементов списка, чтобы сменился его ETag.
+// Вызывается под блокировкой mu.
+func (s *Server) touchItems(wishlistID string) {
+	if wishlist, exists := s.wishlists[wishlistID]; exists {
+		wishlist.ItemChanges++
+		s.wishlists[wishlistID] = wishlist
+	}
+}
+
+// wishlistETag вычисляет ETag по времени изменения списка и счетчику изменений элементов
+func wishlistETag(wishlist model.Wishlist) string {
+	return fmt.Sprintf(`"%x-%x"`, wishlist.UpdatedAt.UnixNano(), wishlist.ItemChanges)
+}
+
+// etagMatches проверяет заголовок If-None-Match, который может содержать список ETag или "*"
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// groupItemsByCategory раскладывает элементы по категориям.
+// Элементы без категории попадают в группу "uncategorized".
+func groupItemsByCategory(list []model.Item) map[string][]model.Item {
+	groups := make(map[string][]model.Item)
+	for _, item := range list {
+		category := item.Category
+		if category == "" {
+			category = "uncategorized"
+		}
+		groups[category] = append(groups[category], item)
+	}
+	return groups
+}
+
+// sortItemsByCreatedAt упорядочивает элементы по времени создания.
+// При совпадении времени порядок определяется ID, чтобы он не зависел от обхода словаря.
+func sortItemsByCreatedAt(list []model.Item, descending bool) {
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			if descending {
+				return list[i].CreatedAt.After(list[j].CreatedAt)
+			}
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+}
+
+// shareActive сообщает, действует ли еще доступ. Истекшие записи не дают доступа,
+// даже если еще не удалены: они удаляются при следующем изменении доступа к списку.
+func shareActive(share model.SharedWishlist) bool {
+	return share.ExpiresAt == nil || clock.Now().Before(*share.ExpiresAt)
+}
+
+func (s *Server) hasSharedAccess(userID, wishlistID string) bool {
+	for _, share := range s.sharedWishlists {
+		if share.UserID == userID && share.WishlistID == wishlistID && shareActive(share) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) hasEditAccess(userID, wishlistID string) bool {
+	for _, share := range s.sharedWishlists {
+		if share.UserID == userID && share.WishlistID == wishlistID && share.CanEdit && shareActive(share) {
+			return true
+		}
+	}
+	return false
+}
+
+// abortItemStoreError отвечает на ошибку сохранения элемента: 404, если списка нет, иначе 500
+func abortItemStoreError(c *gin.Context, err error) {
+	var notFound *WishlistNotFoundError
+	if errors.As(err, &notFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "could not save item"})
+}
+
+// Элемент не найден или принадлежит другому списку
+var errItemNotFound = errors.New("item not found")
+
+// getItemInWishlist возвращает элемент, только если он принадлежит указанному списку.
+// Все обработчики с :item_id в адресе получают элемент через нее, чтобы элемент
+// нельзя было изменить через адрес чужого списка. Вызывается под блокировкой mu.
+func (s *Server) getItemInWishlist(wishlistID, itemID string) (model.Item, error) {
+	item, exists := s.items[itemID]
+	if !exists || item.WishlistID != wishlistID {
+		return model.Item{}, errItemNotFound
+	}
+	return item, nil
+}
+
+// findItemByName ищет в списке элемент с таким же названием без учета регистра.
+// Если таких несколько, возвращает самый ранний. Вызывается под блокировкой mu.
+func (s *Server) findItemByName(wishlistID, name string) (model.Item, bool) {
+	var found model.Item
+	exists := false
+	for _, item := range s.items {
+		if item.WishlistID != wishlistID || !strings.EqualFold(item.Name, name) {
+			continue
+		}
+		if !exists || item.CreatedAt.Before(found.CreatedAt) ||
+			(item.CreatedAt.Equal(found.CreatedAt) && item.ID < found.ID) {
+			found = item
+			exists = true
+		}
+	}
+	return found, exists
+}
+
+// parseDedupe читает параметр dedupe: с dedupe=true элемент с уже существующим названием не создается
+func parseDedupe(c *gin.Context) (bool, error) {
+	raw, ok := c.GetQuery("dedupe")
+	if !ok {
+		return false, nil
+	}
+	dedupe, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, errors.New("dedupe must be a boolean")
+	}
+	return dedupe, nil
+}
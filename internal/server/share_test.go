@@ -0,0 +1,287 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+func TestShareToOwnerRejected(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("Owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	path := "/api/v1/wishlists/" + wishlist.ID + "/share"
+
+	// Владельца можно указать по-разному, проверка идет после определения пользователя
+	for name, body := range map[string]gin.H{
+		"by id":       {"shared_user_id": owner.ID},
+		"by email":    {"email": owner.Email},
+		"by username": {"username": "OWNER"},
+	} {
+		rec := ts.do(http.MethodPost, path, owner.ID, body)
+		expectStatus(t, rec, http.StatusBadRequest)
+
+		var resp map[string]string
+		decodeBody(t, rec, &resp)
+		if resp["code"] != "SHARE_TO_OWNER" {
+			t.Errorf("%s: code = %q, want SHARE_TO_OWNER", name, resp["code"])
+		}
+	}
+
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	if len(ts.srv.sharedWishlists) != 0 {
+		t.Errorf("store has %d shares, want none", len(ts.srv.sharedWishlists))
+	}
+}
+
+func TestShareToOwnerAfterTransfer(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	wishlist := ts.createWishlist(alice.ID, "Birthday")
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/transfer", alice.ID, gin.H{"email": bob.Email})
+	expectStatus(t, rec, http.StatusOK)
+
+	// Новый владелец не может выдать доступ сам себе
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/share", bob.ID, gin.H{"email": bob.Email})
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestTransferWishlistOwnership(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	carol := ts.addUser("carol")
+	wishlist := ts.createWishlist(alice.ID, "Birthday")
+	path := "/api/v1/wishlists/" + wishlist.ID
+
+	rec := ts.do(http.MethodPost, path+"/transfer", alice.ID, gin.H{"user_id": bob.ID, "keep_access": true})
+	expectStatus(t, rec, http.StatusOK)
+	var transferred model.Wishlist
+	decodeBody(t, rec, &transferred)
+	if transferred.UserID != bob.ID {
+		t.Fatalf("owner = %s, want bob", transferred.UserID)
+	}
+
+	// Прежний владелец сохранил доступ на просмотр, но не права владельца
+	expectStatus(t, ts.do(http.MethodGet, path, alice.ID, nil), http.StatusOK)
+	expectStatus(t, ts.do(http.MethodPost, path+"/share", alice.ID, gin.H{"shared_user_id": carol.ID}), http.StatusForbidden)
+	expectStatus(t, ts.do(http.MethodPost, path+"/transfer", alice.ID, gin.H{"user_id": alice.ID}), http.StatusForbidden)
+	expectStatus(t, ts.do(http.MethodDelete, path, alice.ID, nil), http.StatusForbidden)
+	if titles := wishlistTitles(t, ts, alice.ID, ""); titles["Birthday"] {
+		t.Error("transferred wishlist is still among alice's own lists")
+	}
+
+	// Новый владелец распоряжается списком
+	if titles := wishlistTitles(t, ts, bob.ID, ""); !titles["Birthday"] {
+		t.Error("transferred wishlist is not among bob's lists")
+	}
+	ts.share(bob.ID, wishlist.ID, carol.ID, false)
+	expectStatus(t, ts.do(http.MethodDelete, path, bob.ID, nil), http.StatusNoContent)
+}
+
+func TestTransferWithoutKeepAccess(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	wishlist := ts.createWishlist(alice.ID, "Birthday")
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/transfer", alice.ID, gin.H{"username": "bob"})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID, alice.ID, nil)
+	if rec.Code == http.StatusOK {
+		t.Error("previous owner kept access without keep_access")
+	}
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID, bob.ID, nil), http.StatusOK)
+}
+
+func TestSharedWishlistsFilteredByCanEdit(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	ts.share(alice.ID, ts.createWishlist(alice.ID, "Editable").ID, bob.ID, true)
+	ts.share(alice.ID, ts.createWishlist(alice.ID, "View only").ID, bob.ID, false)
+
+	for query, want := range map[string]string{
+		"":                "Editable,View only",
+		"?can_edit=true":  "Editable",
+		"?can_edit=false": "View only",
+	} {
+		entries := ts.sharedWishlists(bob.ID, query)
+		var titles []string
+		for title, entry := range entries {
+			if entry.CanEdit != (title == "Editable") {
+				t.Errorf("%q: %s can_edit = %v", query, title, entry.CanEdit)
+			}
+			titles = append(titles, title)
+		}
+		sort.Strings(titles)
+		if strings.Join(titles, ",") != want {
+			t.Errorf("%q: shared = %v, want %s", query, titles, want)
+		}
+	}
+
+	for _, query := range []string{"?can_edit=maybe", "?can_edit="} {
+		expectStatus(t, ts.do(http.MethodGet, "/api/v1/shared"+query, bob.ID, nil), http.StatusBadRequest)
+	}
+}
+
+func TestSharedWishlistCarriesOwner(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	carol := ts.addUser("carol")
+	bob := ts.addUser("bob")
+	ts.share(alice.ID, ts.createWishlist(alice.ID, "Alice's birthday").ID, bob.ID, false)
+	ts.share(carol.ID, ts.createWishlist(carol.ID, "Carol's wedding").ID, bob.ID, true)
+
+	entries := ts.sharedWishlists(bob.ID, "")
+	for title, owner := range map[string]model.User{"Alice's birthday": alice, "Carol's wedding": carol} {
+		if got := entries[title].Owner; got.ID != owner.ID || got.Username != owner.Username {
+			t.Errorf("%s: owner = %+v, want %s (%s)", title, got, owner.Username, owner.ID)
+		}
+	}
+
+	// Email владельца в ответ не попадает
+	body := ts.do(http.MethodGet, "/api/v1/shared", bob.ID, nil).Body.String()
+	for _, owner := range []model.User{alice, carol} {
+		if strings.Contains(body, owner.Email) {
+			t.Errorf("shared wishlists reveal %s's email: %s", owner.Username, body)
+		}
+	}
+}
+
+// shareHolders возвращает пользователей, которым в хранилище открыт список, и число записей доступа у каждого
+func (ts *testServer) shareHolders(wishlistID string) map[string]int {
+	ts.t.Helper()
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+
+	holders := make(map[string]int)
+	for _, share := range ts.srv.sharedWishlists {
+		if share.WishlistID == wishlistID {
+			holders[share.UserID]++
+		}
+	}
+	return holders
+}
+
+func TestTransferDropsRedundantShare(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	carol := ts.addUser("carol")
+	wishlist := ts.createWishlist(alice.ID, "Birthday")
+	ts.share(alice.ID, wishlist.ID, bob.ID, true)
+	ts.share(alice.ID, wishlist.ID, carol.ID, false)
+	path := "/api/v1/wishlists/" + wishlist.ID + "/transfer"
+
+	// Передача тому, у кого уже есть доступ: его запись доступа становится лишней
+	expectStatus(t, ts.do(http.MethodPost, path, alice.ID, gin.H{"user_id": bob.ID, "keep_access": true}), http.StatusOK)
+	holders := ts.shareHolders(wishlist.ID)
+	if len(holders) != 2 || holders[alice.ID] != 1 || holders[carol.ID] != 1 {
+		t.Errorf("shares after transfer to bob = %v, want alice and carol once each", holders)
+	}
+	if entries := ts.sharedWishlists(bob.ID, ""); len(entries) != 0 {
+		t.Errorf("bob's shared wishlists = %+v, want none for his own list", entries)
+	}
+
+	// Обратная передача снимает доступ, сохраненный прежнему владельцу
+	expectStatus(t, ts.do(http.MethodPost, path, bob.ID, gin.H{"user_id": alice.ID}), http.StatusOK)
+	if holders := ts.shareHolders(wishlist.ID); len(holders) != 1 || holders[carol.ID] != 1 {
+		t.Errorf("shares after transfer back = %v, want carol only", holders)
+	}
+
+	expectStatus(t, ts.do(http.MethodPost, path, alice.ID, gin.H{"user_id": alice.ID}), http.StatusBadRequest)
+}
+
+// useShareDefaults задает параметры доступа по умолчанию до конца теста
+func useShareDefaults(t *testing.T, canEdit bool, ttl time.Duration) {
+	t.Helper()
+	previousCanEdit, previousTTL := shareDefaultCanEdit, shareDefaultTTL
+	shareDefaultCanEdit, shareDefaultTTL = canEdit, ttl
+	t.Cleanup(func() { shareDefaultCanEdit, shareDefaultTTL = previousCanEdit, previousTTL })
+}
+
+func TestExpiredShareDeniesAccess(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	editor := ts.addUser("editor")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	path := "/api/v1/wishlists/" + wishlist.ID
+
+	expires := fake.Now().Add(time.Hour)
+	rec := ts.do(http.MethodPost, path+"/share", owner.ID, gin.H{"shared_user_id": editor.ID, "can_edit": true, "expires_at": expires})
+	expectStatus(t, rec, http.StatusCreated)
+	var share model.SharedWishlist
+	decodeBody(t, rec, &share)
+	if share.ExpiresAt == nil || !share.ExpiresAt.Equal(expires) {
+		t.Errorf("share expires_at = %v, want %v", share.ExpiresAt, expires)
+	}
+	expectStatus(t, ts.do(http.MethodPost, path+"/items", editor.ID, gin.H{"name": "Lamp"}), http.StatusCreated)
+
+	fake.Advance(time.Hour)
+	expectStatus(t, ts.do(http.MethodGet, path, editor.ID, nil), http.StatusNotFound)
+	expectStatus(t, ts.do(http.MethodPost, path+"/items", editor.ID, gin.H{"name": "Chair"}), http.StatusNotFound)
+	if entries := ts.sharedWishlists(editor.ID, ""); len(entries) != 0 {
+		t.Errorf("shared wishlists after expiry = %+v", entries)
+	}
+
+	// Истекшая запись удаляется при следующем изменении доступа к списку
+	ts.share(owner.ID, wishlist.ID, ts.addUser("friend").ID, false)
+	if holders := ts.shareHolders(wishlist.ID); holders[editor.ID] != 0 {
+		t.Errorf("expired share was not pruned: %v", holders)
+	}
+}
+
+func TestNonExpiringShareKeepsAccess(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	friend := ts.addUser("friend")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	if share := ts.share(owner.ID, wishlist.ID, friend.ID, false); share.ExpiresAt != nil {
+		t.Errorf("share expires_at = %v, want none", share.ExpiresAt)
+	}
+	fake.Advance(10 * 365 * 24 * time.Hour)
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID, friend.ID, nil), http.StatusOK)
+}
+
+func TestShareDefaults(t *testing.T) {
+	fake := useFakeClock(t)
+	useShareDefaults(t, true, 24*time.Hour)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	path := "/api/v1/wishlists/" + wishlist.ID + "/share"
+
+	rec := ts.do(http.MethodPost, path, owner.ID, gin.H{"shared_user_id": ts.addUser("friend").ID})
+	expectStatus(t, rec, http.StatusCreated)
+	var share model.SharedWishlist
+	decodeBody(t, rec, &share)
+	if want := fake.Now().Add(24 * time.Hour); !share.CanEdit || share.ExpiresAt == nil || !share.ExpiresAt.Equal(want) {
+		t.Errorf("share = %+v, want can_edit and expiry %v", share, want)
+	}
+
+	// Явные значения запроса важнее значений по умолчанию
+	expires := fake.Now().Add(time.Hour)
+	rec = ts.do(http.MethodPost, path, owner.ID, gin.H{"shared_user_id": ts.addUser("guest").ID, "can_edit": false, "expires_at": expires})
+	expectStatus(t, rec, http.StatusCreated)
+	decodeBody(t, rec, &share)
+	if share.CanEdit || share.ExpiresAt == nil || !share.ExpiresAt.Equal(expires) {
+		t.Errorf("share = %+v, want view only until %v", share, expires)
+	}
+
+	rec = ts.do(http.MethodPost, path, owner.ID, gin.H{"shared_user_id": ts.addUser("late").ID, "expires_at": fake.Now()})
+	expectStatus(t, rec, http.StatusBadRequest)
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// getStats возвращает сводку по данным текущего пользователя: его списки и элементы в них,
+// а также действующий доступ, открытый ему и им. Списки в корзине не учитываются
+func (s *Server) getStats(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stats model.UserStats
+	owned := make(map[string]bool)
+	for _, wishlist := range s.wishlists {
+		if wishlist.UserID == userID && wishlist.DeletedAt == nil {
+			owned[wishlist.ID] = true
+		}
+	}
+	stats.Wishlists = len(owned)
+
+	// Элементы берутся по индексу элементов списков, а не поиском по всему хранилищу
+	for wishlistID := range owned {
+		for itemID := range s.itemsByWishlist[wishlistID] {
+			stats.Items++
+			if s.items[itemID].IsPurchased {
+				stats.PurchasedItems++
+			}
+		}
+	}
+	stats.RemainingItems = stats.Items - stats.PurchasedItems
+
+	for _, share := range s.sharedWishlists {
+		if !shareActive(share) {
+			continue
+		}
+		if owned[share.WishlistID] {
+			stats.SharedByMe++
+			continue
+		}
+		if share.UserID != userID {
+			continue
+		}
+		if _, exists := s.findWishlist(share.WishlistID); exists {
+			stats.SharedWithMe++
+		}
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
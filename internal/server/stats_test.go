@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// stats возвращает сводку GET /stats пользователя
+func (ts *testServer) stats(token string) model.UserStats {
+	ts.t.Helper()
+	rec := ts.do(http.MethodGet, "/api/v1/stats", token, nil)
+	expectStatus(ts.t, rec, http.StatusOK)
+
+	var stats model.UserStats
+	decodeBody(ts.t, rec, &stats)
+	return stats
+}
+
+func TestStatsSummarizeOwnData(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	carol := ts.addUser("carol")
+
+	if stats := ts.stats(alice.ID); stats != (model.UserStats{}) {
+		t.Errorf("stats of a new user = %+v, want zeros", stats)
+	}
+
+	birthday := ts.createWishlist(alice.ID, "Birthday")
+	home := ts.createWishlist(alice.ID, "Home")
+	ts.addItem(alice.ID, birthday.ID, gin.H{"name": "Lamp"})
+	ts.addItem(alice.ID, birthday.ID, gin.H{"name": "Book"})
+	ts.addItem(alice.ID, home.ID, gin.H{"name": "Chair"})
+	ts.setAllPurchased(alice.ID, birthday.ID, "purchase-all")
+	ts.share(alice.ID, birthday.ID, bob.ID, false)
+	ts.share(alice.ID, home.ID, carol.ID, true)
+
+	bobs := ts.createWishlist(bob.ID, "Bob's list")
+	ts.addItem(bob.ID, bobs.ID, gin.H{"name": "Guitar"})
+	ts.share(bob.ID, bobs.ID, alice.ID, false)
+
+	// Список в корзине не учитывается вместе с элементами и доступом к нему
+	trashed := ts.createWishlist(alice.ID, "Old")
+	ts.addItem(alice.ID, trashed.ID, gin.H{"name": "Vase"})
+	ts.share(alice.ID, trashed.ID, bob.ID, false)
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+trashed.ID, alice.ID, nil), http.StatusNoContent)
+
+	want := model.UserStats{Wishlists: 2, Items: 3, PurchasedItems: 2, RemainingItems: 1, SharedWithMe: 1, SharedByMe: 2}
+	if stats := ts.stats(alice.ID); stats != want {
+		t.Errorf("alice stats = %+v, want %+v", stats, want)
+	}
+
+	// Чужие списки, открытые пользователю, не попадают в его собственные числа
+	want = model.UserStats{Wishlists: 1, Items: 1, RemainingItems: 1, SharedWithMe: 1, SharedByMe: 1}
+	if stats := ts.stats(bob.ID); stats != want {
+		t.Errorf("bob stats = %+v, want %+v", stats, want)
+	}
+	want = model.UserStats{SharedWithMe: 1}
+	if stats := ts.stats(carol.ID); stats != want {
+		t.Errorf("carol stats = %+v, want %+v", stats, want)
+	}
+}
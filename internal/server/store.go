@@ -0,0 +1,234 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+
+	"wana/internal/model"
+)
+
+// MemStore - хранилище данных сервиса в памяти.
+//
+// Правила блокировок:
+//   - все поля MemStore защищены одним мьютексом mu. Независимые служебные хранилища
+//     (например, ключи идемпотентности) имеют собственные мьютексы, под которыми
+//     никакие другие не захватываются;
+//   - обработчик захватывает мьютекс один раз на все время работы с хранилищами:
+//     RLock - если он только читает, Lock - если есть хотя бы одна запись.
+//     Повысить RLock до Lock нельзя, поэтому обработчик, которому может понадобиться запись,
+//     сразу берет Lock;
+//   - вспомогательные функции, работающие с хранилищами (findWishlist, hasSharedAccess,
+//     purgeWishlist, resolveUser и т.д.), сами mu не захватывают
+//     и вызываются только под ним;
+//   - под мьютексами не выполняются медленные операции и ввод-вывод: bcrypt, HTTP-запросы,
+//     отправка писем и вебхуков. Данные для них копируются под блокировкой,
+//     а сама операция выполняется после ее освобождения или в отдельной горутине.
+//
+// Один мьютекс на все хранилище выбран сознательно: почти каждый обработчик читает данные
+// нескольких пользователей сразу (владелец, получатели доступа, резерв гостя), и разбиение
+// блокировки по пользователям требует порядка захвата в каждом таком месте. Частичное
+// разделение уже приводило к нарушению этого порядка. Пропускную способность под
+// конкурентной нагрузкой показывает BenchmarkConcurrentUsers.
+type MemStore struct {
+	wishlists       map[string]model.Wishlist
+	items           map[string]model.Item
+	sharedWishlists map[string]model.SharedWishlist
+	mu              sync.RWMutex
+
+	// Индекс элементов: ID списка -> множество ID его элементов.
+	// Элементы сохраняются и удаляются только через putItem и removeItem, которые поддерживают индекс
+	itemsByWishlist map[string]map[string]bool
+
+	// Журнал действий: ID списка -> события в порядке записи
+	auditLog map[string][]AuditEvent
+	// Комментарии: ID элемента -> комментарии в порядке добавления
+	comments map[string][]Comment
+	// Избранные списки: ID пользователя -> множество ID списков
+	favorites map[string]map[string]bool
+	// Уведомления: ID пользователя -> уведомления в порядке создания
+	notifications map[string][]Notification
+	// Публичные ссылки: токен -> ссылка
+	publicLinks map[string]PublicLink
+
+	users map[string]model.User
+
+	// Индексы пользователей: имя/email -> ID пользователя
+	usersByUsername map[string]string
+	usersByEmail    map[string]string
+	// Токены подтверждения email
+	verifications map[string]EmailVerification
+
+	// Почта хранилища задается при создании и не меняется, поэтому читается без mu
+	mailer Mailer
+}
+
+// NewMemStore создает пустое хранилище
+func NewMemStore() *MemStore {
+	return &MemStore{
+		wishlists:       make(map[string]model.Wishlist),
+		items:           make(map[string]model.Item),
+		sharedWishlists: make(map[string]model.SharedWishlist),
+		itemsByWishlist: make(map[string]map[string]bool),
+		auditLog:        make(map[string][]AuditEvent),
+		comments:        make(map[string][]Comment),
+		favorites:       make(map[string]map[string]bool),
+		notifications:   make(map[string][]Notification),
+		publicLinks:     make(map[string]PublicLink),
+		users:           make(map[string]model.User),
+		usersByUsername: make(map[string]string),
+		usersByEmail:    make(map[string]string),
+		verifications:   make(map[string]EmailVerification),
+		mailer:          &logMailer{},
+	}
+}
+
+// Store - хранилище, с которым работают обработчики. Обработчики читают и меняют данные
+// напрямую под мьютексами хранилища (см. правила блокировок MemStore), поэтому Store
+// реализуется только хранилищами этого пакета: метод memStore возвращает их данные.
+type Store interface {
+	// AddItem сохраняет элемент, если его список существует (см. MemStore.AddItem)
+	AddItem(item model.Item) error
+	// WithTx выполняет fn как одну транзакцию (см. MemStore.WithTx)
+	WithTx(fn func(tx *Tx) error) error
+
+	memStore() *MemStore
+}
+
+var _ Store = (*MemStore)(nil)
+
+func (m *MemStore) memStore() *MemStore {
+	return m
+}
+
+// Server - обработчики HTTP-запросов, работающие с хранилищем
+type Server struct {
+	*MemStore
+}
+
+// newServer создает обработчики, работающие с переданным хранилищем
+func newServer(store Store) *Server {
+	return &Server{MemStore: store.memStore()}
+}
+
+// WishlistNotFoundError - элемент нельзя сохранить: списка, к которому он относится, нет или он в корзине
+type WishlistNotFoundError struct {
+	WishlistID string
+}
+
+func (e *WishlistNotFoundError) Error() string {
+	return fmt.Sprintf("wishlist %s not found", e.WishlistID)
+}
+
+// AddItem сохраняет новый элемент, только если его список существует и не находится в корзине,
+// иначе возвращает *WishlistNotFoundError. Так элемент без списка не появится, даже если
+// вызывающий код не проверил список сам. Вызывается под блокировкой mu.
+func (m *MemStore) AddItem(item model.Item) error {
+	wishlist, exists := m.wishlists[item.WishlistID]
+	if !exists || wishlist.DeletedAt != nil {
+		return &WishlistNotFoundError{WishlistID: item.WishlistID}
+	}
+	m.putItem(item)
+	return nil
+}
+
+// putItem сохраняет элемент и обновляет индекс элементов списков, в том числе
+// при переносе элемента в другой список. Вызывается под блокировкой mu.
+func (m *MemStore) putItem(item model.Item) {
+	if previous, exists := m.items[item.ID]; exists && previous.WishlistID != item.WishlistID {
+		m.unindexItem(previous)
+	}
+	m.items[item.ID] = item
+
+	ids, exists := m.itemsByWishlist[item.WishlistID]
+	if !exists {
+		ids = make(map[string]bool)
+		m.itemsByWishlist[item.WishlistID] = ids
+	}
+	ids[item.ID] = true
+}
+
+// removeItem удаляет элемент вместе с записью в индексе. Вызывается под блокировкой mu.
+func (m *MemStore) removeItem(itemID string) {
+	if item, exists := m.items[itemID]; exists {
+		m.unindexItem(item)
+		delete(m.items, itemID)
+	}
+}
+
+func (m *MemStore) unindexItem(item model.Item) {
+	ids := m.itemsByWishlist[item.WishlistID]
+	delete(ids, item.ID)
+	if len(ids) == 0 {
+		delete(m.itemsByWishlist, item.WishlistID)
+	}
+}
+
+// Tx - изменения хранилища внутри WithTx. Каждая запись через Tx запоминает, как ее отменить,
+// и если функция транзакции вернула ошибку, изменения откатываются в обратном порядке.
+type Tx struct {
+	store *MemStore
+	undo  []func()
+}
+
+// WithTx выполняет fn как одну транзакцию: либо сохраняются все изменения, сделанные через tx,
+// либо, если fn вернула ошибку, ни одного. Изоляцию обеспечивает мьютекс, поэтому WithTx,
+// как и остальные функции хранилища, вызывается под блокировкой mu (Lock).
+func (m *MemStore) WithTx(fn func(tx *Tx) error) error {
+	tx := &Tx{store: m}
+	if err := fn(tx); err != nil {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+		return err
+	}
+	return nil
+}
+
+// PutShare сохраняет запись о совместном доступе
+func (tx *Tx) PutShare(share model.SharedWishlist) {
+	m := tx.store
+	previous, existed := m.sharedWishlists[share.ID]
+	tx.undo = append(tx.undo, func() {
+		if existed {
+			m.sharedWishlists[share.ID] = previous
+		} else {
+			delete(m.sharedWishlists, share.ID)
+		}
+	})
+	m.sharedWishlists[share.ID] = share
+}
+
+// Notify добавляет уведомление пользователю. Если пользователя уже нет, возвращает ошибку,
+// и транзакция откатывается.
+func (tx *Tx) Notify(userID, notificationType, message string) error {
+	m := tx.store
+	_, exists := m.users[userID]
+	if !exists {
+		return fmt.Errorf("notification recipient %s not found", userID)
+	}
+
+	previous, existed := m.notifications[userID]
+	tx.undo = append(tx.undo, func() {
+		if existed {
+			m.notifications[userID] = previous
+		} else {
+			delete(m.notifications, userID)
+		}
+	})
+	m.notify(userID, notificationType, message)
+	return nil
+}
+
+// RecordAudit добавляет событие в журнал списка
+func (tx *Tx) RecordAudit(wishlistID, actorID, action, targetID string) {
+	m := tx.store
+	previous, existed := m.auditLog[wishlistID]
+	tx.undo = append(tx.undo, func() {
+		if existed {
+			m.auditLog[wishlistID] = previous
+		} else {
+			delete(m.auditLog, wishlistID)
+		}
+	})
+	m.recordAudit(wishlistID, actorID, action, targetID)
+}
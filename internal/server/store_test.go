@@ -0,0 +1,120 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"wana/internal/model"
+)
+
+func TestStoreAddItemRequiresWishlist(t *testing.T) {
+	store := NewMemStore()
+	deletedAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	store.wishlists["live"] = model.Wishlist{ID: "live", UserID: "u1", Title: "Live"}
+	store.wishlists["trashed"] = model.Wishlist{ID: "trashed", UserID: "u1", Title: "Trashed", DeletedAt: &deletedAt}
+
+	for _, wishlistID := range []string{"missing", "trashed"} {
+		err := store.AddItem(model.Item{ID: "orphan-" + wishlistID, WishlistID: wishlistID, Name: "Lamp"})
+		var notFound *WishlistNotFoundError
+		if !errors.As(err, &notFound) || notFound.WishlistID != wishlistID {
+			t.Errorf("AddItem to %s wishlist: error = %v, want *WishlistNotFoundError", wishlistID, err)
+		}
+	}
+	if len(store.items) != 0 {
+		t.Fatalf("store has orphan items: %+v", store.items)
+	}
+
+	if err := store.AddItem(model.Item{ID: "i1", WishlistID: "live", Name: "Lamp"}); err != nil {
+		t.Fatalf("AddItem to existing wishlist: %v", err)
+	}
+	if item, exists := store.items["i1"]; !exists || item.WishlistID != "live" {
+		t.Errorf("stored items = %+v", store.items)
+	}
+}
+
+func TestStoreWithTxRollsBackShare(t *testing.T) {
+	store := NewMemStore()
+	store.users["owner"] = model.User{ID: "owner", Username: "owner"}
+	store.users["guest"] = model.User{ID: "guest", Username: "guest"}
+	store.wishlists["w1"] = model.Wishlist{ID: "w1", UserID: "owner", Title: "Birthday"}
+	store.notify("guest", notificationWishlistShared, "earlier")
+	share := func(id, userID string, canEdit bool) model.SharedWishlist {
+		return model.SharedWishlist{ID: id, WishlistID: "w1", UserID: userID, CanEdit: canEdit}
+	}
+	shareAndNotify := func(s model.SharedWishlist) error {
+		return store.WithTx(func(tx *Tx) error {
+			tx.PutShare(s)
+			if err := tx.Notify(s.UserID, notificationWishlistShared, "shared"); err != nil {
+				return err
+			}
+			tx.RecordAudit("w1", "owner", auditWishlistShared, s.UserID)
+			return nil
+		})
+	}
+
+	// Уведомление получателю, которого нет, не записывается, а вместе с ним откатывается и доступ
+	if err := shareAndNotify(share("s-missing", "missing", false)); err == nil {
+		t.Fatal("share with a missing recipient succeeded")
+	}
+	if len(store.sharedWishlists) != 0 || len(store.auditLog["w1"]) != 0 {
+		t.Fatalf("failed transaction left shares %+v and audit %+v", store.sharedWishlists, store.auditLog)
+	}
+
+	if err := shareAndNotify(share("s1", "guest", false)); err != nil {
+		t.Fatalf("share with guest: %v", err)
+	}
+	if len(store.sharedWishlists) != 1 || len(store.notifications["guest"]) != 2 || len(store.auditLog["w1"]) != 1 {
+		t.Fatalf("committed transaction: shares %+v, notifications %+v, audit %+v",
+			store.sharedWishlists, store.notifications["guest"], store.auditLog["w1"])
+	}
+
+	// Ошибка после уже сделанных записей откатывает их все, включая перезапись доступа
+	forced := errors.New("forced failure")
+	err := store.WithTx(func(tx *Tx) error {
+		tx.PutShare(share("s1", "guest", true))
+		if err := tx.Notify("guest", notificationWishlistShared, "upgraded"); err != nil {
+			return err
+		}
+		tx.RecordAudit("w1", "owner", auditWishlistShared, "guest")
+		return forced
+	})
+	if !errors.Is(err, forced) {
+		t.Fatalf("WithTx error = %v, want the forced failure", err)
+	}
+	if s := store.sharedWishlists["s1"]; s.CanEdit || len(store.sharedWishlists) != 1 {
+		t.Errorf("share after rollback = %+v", store.sharedWishlists)
+	}
+	if len(store.notifications["guest"]) != 2 || len(store.auditLog["w1"]) != 1 {
+		t.Errorf("after rollback: notifications %+v, audit %+v", store.notifications["guest"], store.auditLog["w1"])
+	}
+}
+
+func TestStoreItemIndexFollowsChanges(t *testing.T) {
+	store := NewMemStore()
+	store.wishlists["w1"] = model.Wishlist{ID: "w1", UserID: "u1", Title: "First"}
+	store.wishlists["w2"] = model.Wishlist{ID: "w2", UserID: "u1", Title: "Second"}
+	for _, id := range []string{"i1", "i2"} {
+		if err := store.AddItem(model.Item{ID: id, WishlistID: "w1", Name: "Lamp"}); err != nil {
+			t.Fatalf("AddItem %s: %v", id, err)
+		}
+	}
+	counts := func() (int, int) {
+		return len(store.itemsByWishlist["w1"]), len(store.itemsByWishlist["w2"])
+	}
+
+	// Перенос элемента переводит его в индекс другого списка
+	moved := store.items["i2"]
+	moved.WishlistID = "w2"
+	store.putItem(moved)
+	if first, second := counts(); first != 1 || second != 1 || !store.itemsByWishlist["w2"]["i2"] {
+		t.Fatalf("index after move = %+v", store.itemsByWishlist)
+	}
+
+	// Опустевший список из индекса удаляется, повторное удаление ничего не ломает
+	store.removeItem("i1")
+	store.removeItem("i1")
+	if _, exists := store.itemsByWishlist["w1"]; exists || len(store.items) != 1 {
+		t.Errorf("after remove: index %+v, items %+v", store.itemsByWishlist, store.items)
+	}
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ответ клиенту, если обработчик не уложился в requestTimeout
+var timeoutBody, _ = json.Marshal(gin.H{
+	"error": "request timed out",
+	"code":  "TIMEOUT",
+})
+
+// timeoutWriter накапливает ответ обработчика, как http.TimeoutHandler: заголовки,
+// статус и тело передаются клиенту, только если обработчик завершился до дедлайна.
+// После дедлайна запись отклоняется с http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	gin.ResponseWriter
+
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	written  bool
+	timedOut bool
+}
+
+func newTimeoutWriter(w gin.ResponseWriter) *timeoutWriter {
+	// Заголовки, которые уже выставили предыдущие middleware, видны обработчику
+	// и могут быть им изменены
+	return &timeoutWriter{ResponseWriter: w, header: w.Header().Clone(), status: http.StatusOK}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader, как в gin, позволяет сменить статус, пока не записано тело
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written && !w.timedOut {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.written = true
+	return w.buf.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		return -1
+	}
+	return w.buf.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// Ответ целиком накапливается до конца обработки, поэтому отправить его часть нельзя.
+// Маршруты, которые пишут ответ потоком, timeoutMiddleware не оборачивает.
+func (w *timeoutWriter) Flush() {}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("hijacking is not supported within the request timeout")
+}
+
+func (w *timeoutWriter) Pusher() http.Pusher {
+	return nil
+}
+
+// finish передает накопленный ответ клиенту. Вызывается после завершения обработчика
+func (w *timeoutWriter) finish() {
+	dst := w.ResponseWriter.Header()
+	for key := range dst {
+		if _, ok := w.header[key]; !ok {
+			delete(dst, key)
+		}
+	}
+	for key, values := range w.header {
+		dst[key] = values
+	}
+
+	// Статус без тела gin отправит сам после обработки запроса
+	if w.status != http.StatusOK || w.written {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	switch {
+	case w.buf.Len() > 0:
+		w.ResponseWriter.Write(w.buf.Bytes())
+	case w.written:
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// timeout отвечает клиенту 503 и запрещает обработчику дальнейшую запись.
+// Ответ сразу отправляется клиенту: длина указана заранее, поэтому клиент
+// получает его целиком, не дожидаясь, пока обработчик вернет управление.
+func (w *timeoutWriter) timeout() {
+	w.mu.Lock()
+	w.timedOut = true
+	w.mu.Unlock()
+
+	header := w.ResponseWriter.Header()
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(timeoutBody)))
+	w.ResponseWriter.WriteHeader(http.StatusServiceUnavailable)
+	w.ResponseWriter.Write(timeoutBody)
+	w.ResponseWriter.Flush()
+}
+
+// Middleware для ограничения времени обработки запроса.
+// Контекст запроса получает дедлайн requestTimeout: обработчики, выполняющие
+// ввод-вывод, должны передавать c.Request.Context() дальше, чтобы работа
+// отменялась вместе с запросом. Обработчик выполняется в отдельной горутине
+// с буферизованным ответом; если к дедлайну он не завершился, клиент сразу
+// получает 503, а все, что обработчик запишет позже, отбрасывается.
+func timeoutMiddleware(c *gin.Context) {
+	// Поток событий открыт долго намеренно, снятие профиля длится столько,
+	// сколько запросил клиент, а полная выгрузка аккаунта пишется потоком по мере чтения,
+	// поэтому ограничение на них не действует
+	if strings.HasSuffix(c.FullPath(), "/events") || strings.HasSuffix(c.FullPath(), "/export/all") ||
+		strings.HasPrefix(c.FullPath(), "/debug/pprof") {
+		c.Next()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+
+	original := c.Writer
+	writer := newTimeoutWriter(original)
+	c.Writer = writer
+
+	done := make(chan struct{})
+	var panicValue interface{}
+	go func() {
+		defer close(done)
+		defer func() {
+			panicValue = recover()
+		}()
+		c.Next()
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			writer.timeout()
+		}
+		// gin переиспользует контекст после возврата из обработки, поэтому
+		// ждем, пока обработчик, получивший отмену контекста, вернет управление
+		<-done
+	}
+
+	c.Writer = original
+	if panicValue != nil {
+		// Паника передается дальше, чтобы ее обработал recovery
+		panic(panicValue)
+	}
+	if !writer.timedOut {
+		writer.finish()
+	}
+}
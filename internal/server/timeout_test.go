@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useRequestTimeout задает время обработки запроса до конца теста
+func useRequestTimeout(t *testing.T, timeout time.Duration) {
+	t.Helper()
+	previous := requestTimeout
+	requestTimeout = timeout
+	t.Cleanup(func() { requestTimeout = previous })
+}
+
+// timeoutEngine - движок с теми же middleware вокруг обработчика, что и в NewRouter
+func timeoutEngine(handler gin.HandlerFunc) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.CustomRecovery(recoverPanic), gzipMiddleware, timeoutMiddleware)
+	r.GET("/handler", handler)
+	return r
+}
+
+func TestSlowHandlerTimesOut(t *testing.T) {
+	useRequestTimeout(t, 50*time.Millisecond)
+
+	cancelled := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(timeoutEngine(func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		close(cancelled)
+		// Обработчик задерживается и после отмены: клиент не должен его ждать
+		<-release
+		c.JSON(http.StatusOK, gin.H{"late": true})
+	}))
+	t.Cleanup(server.Close)
+	defer close(release)
+
+	start := time.Now()
+	resp, err := server.Client().Get(server.URL + "/handler")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Code string `json:"code"`
+		Late bool   `json:"late"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusServiceUnavailable || body.Code != "TIMEOUT" || body.Late {
+		t.Errorf("response = %d %+v, want 503 TIMEOUT", resp.StatusCode, body)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("response took %v with a 50ms timeout", elapsed)
+	}
+	select {
+	case <-cancelled:
+	default:
+		t.Error("handler context was not cancelled")
+	}
+}
+
+func TestFastHandlerResponsePassesThrough(t *testing.T) {
+	useRequestTimeout(t, time.Second)
+
+	for name, tc := range map[string]struct {
+		handler gin.HandlerFunc
+		status  int
+		body    string
+	}{
+		"json": {func(c *gin.Context) {
+			c.Header("Location", "/created")
+			c.JSON(http.StatusCreated, gin.H{"id": "1"})
+		}, http.StatusCreated, `{"id":"1"}`},
+		"status changed before body": {func(c *gin.Context) {
+			c.Status(http.StatusAccepted)
+			c.String(http.StatusNotFound, "missing")
+		}, http.StatusNotFound, "missing"},
+		"no body": {func(c *gin.Context) {
+			c.Header("Location", "/created")
+			c.Status(http.StatusNoContent)
+		}, http.StatusNoContent, ""},
+	} {
+		rec := httptest.NewRecorder()
+		timeoutEngine(tc.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/handler", nil))
+
+		if rec.Code != tc.status || rec.Body.String() != tc.body {
+			t.Errorf("%s: response = %d %q, want %d %q", name, rec.Code, rec.Body.String(), tc.status, tc.body)
+		}
+		if name != "status changed before body" && rec.Header().Get("Location") != "/created" {
+			t.Errorf("%s: handler header was lost: %v", name, rec.Header())
+		}
+		if !containsHeaderValue(rec.Header(), "Vary", "Accept-Encoding") {
+			t.Errorf("%s: header set before the timeout middleware was lost: %v", name, rec.Header())
+		}
+	}
+}
+
+func TestTimeoutMiddlewarePassesPanicToRecovery(t *testing.T) {
+	useRequestTimeout(t, time.Second)
+
+	rec := httptest.NewRecorder()
+	timeoutEngine(func(c *gin.Context) {
+		c.Header("X-Partial", "1")
+		panic("handler failed")
+	}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/handler", nil))
+
+	expectStatus(t, rec, http.StatusInternalServerError)
+	body, _ := io.ReadAll(rec.Body)
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope["code"] != "INTERNAL" {
+		t.Errorf("body = %s", body)
+	}
+	// Ответ обработчика, не дошедшего до конца, отбрасывается целиком
+	if rec.Header().Get("X-Partial") != "" {
+		t.Error("header of the panicked handler reached the client")
+	}
+}
+
+func containsHeaderValue(header http.Header, key, value string) bool {
+	for _, v := range header.Values(key) {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
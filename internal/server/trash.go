@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// Срок хранения удаленных списков в корзине
+const trashRetention = 30 * 24 * time.Hour
+
+func (s *Server) getTrash(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trash := []model.Wishlist{}
+	for _, w := range s.wishlists {
+		if w.UserID == userID && w.DeletedAt != nil && clock.Now().Sub(*w.DeletedAt) < trashRetention {
+			trash = append(trash, w)
+		}
+	}
+
+	c.JSON(http.StatusOK, trash)
+}
+
+func (s *Server) restoreWishlist(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	wishlistID := c.Param("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wishlist, exists := s.wishlists[wishlistID]
+	if !exists || wishlist.DeletedAt == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "wishlist not found in trash"})
+		return
+	}
+
+	// Восстановить может только владелец
+	if wishlist.UserID != userID {
+		s.denyAccess(c, userID, wishlist, "wishlist not found in trash", "access denied")
+		return
+	}
+
+	if clock.Now().Sub(*wishlist.DeletedAt) >= trashRetention {
+		c.JSON(http.StatusGone, gin.H{"error": "retention window expired, wishlist cannot be restored"})
+		return
+	}
+
+	// Списки в корзине не занимают квоту, поэтому восстановление проверяет ее заново
+	if !s.canAddWishlists(userID, 1) {
+		abortQuotaExceeded(c, "wishlist quota exceeded", maxWishlistsPerUser)
+		return
+	}
+
+	wishlist.DeletedAt = nil
+	wishlist.UpdatedAt = clock.Now()
+	wishlist.Version++
+	s.wishlists[wishlistID] = wishlist
+
+	c.JSON(http.StatusOK, wishlist)
+}
+
+// sweepTrash окончательно удаляет списки с истекшим сроком хранения в корзине
+// и возвращает их число. Вызывается под блокировкой mu.
+func (s *Server) sweepTrash() int {
+	purged := 0
+	for wishlistID, w := range s.wishlists {
+		if w.DeletedAt != nil && clock.Now().Sub(*w.DeletedAt) >= trashRetention {
+			s.purgeWishlist(wishlistID)
+			purged++
+		}
+	}
+	return purged
+}
+
+// purgeWishlist удаляет список вместе с элементами, комментариями, записями о доступе и публичными ссылками.
+// Вызывается под блокировкой mu.
+func (s *Server) purgeWishlist(wishlistID string) {
+	delete(s.wishlists, wishlistID)
+	delete(s.auditLog, wishlistID)
+	for _, set := range s.favorites {
+		delete(set, wishlistID)
+	}
+	for itemID := range s.itemsByWishlist[wishlistID] {
+		s.removeItem(itemID)
+		delete(s.comments, itemID)
+	}
+
+	for shareID, share := range s.sharedWishlists {
+		if share.WishlistID == wishlistID {
+			delete(s.sharedWishlists, shareID)
+		}
+	}
+	for token, link := range s.publicLinks {
+		if link.WishlistID == wishlistID {
+			delete(s.publicLinks, token)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+func TestDeleteThenRestoreWishlist(t *testing.T) {
+	useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Garden")
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Rake"})
+
+	rec := ts.do(http.MethodDelete, "/api/v1/wishlists/"+wishlist.ID, owner.ID, nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	if titles := wishlistTitles(t, ts, owner.ID, ""); titles["Garden"] {
+		t.Errorf("deleted wishlist is still listed")
+	}
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID, owner.ID, nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/trash", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var trash []model.Wishlist
+	decodeBody(t, rec, &trash)
+	if len(trash) != 1 || trash[0].ID != wishlist.ID || trash[0].DeletedAt == nil {
+		t.Fatalf("trash = %+v", trash)
+	}
+
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/restore", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	if got := ts.items(owner.ID, wishlist.ID); len(got) != 1 || got[0].Name != "Rake" {
+		t.Errorf("restored items = %+v", got)
+	}
+	if titles := wishlistTitles(t, ts, owner.ID, ""); !titles["Garden"] {
+		t.Errorf("restored wishlist is not listed")
+	}
+}
+
+func TestRestoreFailsAfterRetentionWindow(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Garden")
+
+	rec := ts.do(http.MethodDelete, "/api/v1/wishlists/"+wishlist.ID, owner.ID, nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	fake.Advance(trashRetention + time.Minute)
+
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/trash", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if rec.Body.String() != "[]" {
+		t.Errorf("trash = %s, want the expired wishlist hidden", rec.Body.String())
+	}
+
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/restore", owner.ID, nil)
+	expectStatus(t, rec, http.StatusGone)
+}
+
+func TestDeleteWishlistDryRun(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	friend := ts.addUser("friend")
+	wishlist := ts.createWishlist(owner.ID, "Garden")
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Rake"})
+	ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Hose"})
+	ts.share(owner.ID, wishlist.ID, friend.ID, false)
+	ts.share(owner.ID, wishlist.ID, ts.addUser("editor").ID, true)
+	ts.publicLink(owner.ID, wishlist.ID)
+	path := "/api/v1/wishlists/" + wishlist.ID
+
+	rec := ts.do(http.MethodDelete, path+"?dry_run=true", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var impact model.DeleteImpact
+	decodeBody(t, rec, &impact)
+	want := model.DeleteImpact{WishlistID: wishlist.ID, DryRun: true, Items: 2, Shares: 2, PublicLinks: 1}
+	if impact != want {
+		t.Errorf("impact = %+v, want %+v", impact, want)
+	}
+
+	// Предпросмотр ничего не меняет
+	if titles := wishlistTitles(t, ts, owner.ID, ""); !titles["Garden"] {
+		t.Error("wishlist is not listed after dry run")
+	}
+	if items := ts.items(friend.ID, wishlist.ID); len(items) != 2 {
+		t.Errorf("items after dry run = %+v", items)
+	}
+
+	expectStatus(t, ts.do(http.MethodDelete, path+"?dry_run=maybe", owner.ID, nil), http.StatusBadRequest)
+	expectStatus(t, ts.do(http.MethodDelete, path+"?dry_run=true", friend.ID, nil), http.StatusForbidden)
+
+	expectStatus(t, ts.do(http.MethodDelete, path+"?dry_run=false", owner.ID, nil), http.StatusNoContent)
+	if titles := wishlistTitles(t, ts, owner.ID, ""); titles["Garden"] {
+		t.Error("wishlist is still listed after delete")
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+const (
+	// Минимальная длина запроса: по одному символу можно перебрать всех пользователей
+	minUserSearchQuery   = 2
+	maxUserSearchResults = 10
+)
+
+// searchUsers подсказывает пользователей для открытия доступа к списку: ищет по началу имени или email
+// без учета регистра. В ответе только ID и имя, email найденных пользователей не раскрывается.
+func (s *Server) searchUsers(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	query := strings.ToLower(strings.TrimSpace(c.Query("q")))
+	if len([]rune(query)) < minUserSearchQuery {
+		c.JSON(http.StatusBadRequest, validationError(&FieldError{Field: "q", Message: "must be at least 2 characters"}))
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := []model.PublicUser{}
+	for _, user := range s.users {
+		if user.ID == userID {
+			continue
+		}
+		if strings.HasPrefix(usernameKey(user.Username), query) || strings.HasPrefix(strings.ToLower(user.Email), query) {
+			results = append(results, model.PublicUser{ID: user.ID, Username: user.Username})
+		}
+	}
+
+	// Пользователи хранятся в map, поэтому сортируем выдачу до обрезки
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Username != results[j].Username {
+			return results[i].Username < results[j].Username
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > maxUserSearchResults {
+		results = results[:maxUserSearchResults]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": results})
+}
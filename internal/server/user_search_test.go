@@ -0,0 +1,88 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"wana/internal/model"
+)
+
+// searchUsers возвращает найденных пользователей и тело ответа как есть
+func (ts *testServer) searchUsers(token, query string) ([]model.PublicUser, string) {
+	ts.t.Helper()
+	rec := ts.do(http.MethodGet, "/api/v1/users/search?q="+url.QueryEscape(query), token, nil)
+	expectStatus(ts.t, rec, http.StatusOK)
+	var body struct {
+		Users []model.PublicUser `json:"users"`
+	}
+	decodeBody(ts.t, rec, &body)
+	return body.Users, rec.Body.String()
+}
+
+func TestUserSearchByPrefix(t *testing.T) {
+	ts := newTestServer(t)
+	viewer := ts.addUser("viewer")
+	alice := ts.addUser("Alice")
+	ts.addUser("alina")
+	ts.addUser("malice")
+	bob := ts.addUser("bob")
+	ts.srv.mu.Lock()
+	bob.Email = "al.bob@example.com"
+	ts.srv.users[bob.ID] = bob
+	ts.srv.mu.Unlock()
+
+	// Совпадение по началу имени без учета регистра или по началу email, но не в середине
+	users, raw := ts.searchUsers(viewer.ID, "ALI")
+	if len(users) != 2 || users[0].ID != alice.ID || users[0].Username != "Alice" || users[1].Username != "alina" {
+		t.Errorf("search ALI = %+v", users)
+	}
+	if users, _ := ts.searchUsers(viewer.ID, "al."); len(users) != 1 || users[0].ID != bob.ID {
+		t.Errorf("search by email prefix = %+v", users)
+	}
+	// Наружу не уходит ничего, кроме ID и имени
+	if strings.Contains(raw, "@") || strings.Contains(raw, "email") || strings.Contains(raw, "password") {
+		t.Errorf("search response exposes private data: %s", raw)
+	}
+
+	// Себя пользователь в подсказках не видит
+	if users, _ := ts.searchUsers(alice.ID, "ali"); len(users) != 1 || users[0].Username != "alina" {
+		t.Errorf("search by alice = %+v", users)
+	}
+}
+
+func TestUserSearchMinimumLength(t *testing.T) {
+	ts := newTestServer(t)
+	viewer := ts.addUser("viewer")
+	ts.addUser("alice")
+
+	for _, query := range []string{"", "a", " a ", "я"} {
+		rec := ts.do(http.MethodGet, "/api/v1/users/search?q="+url.QueryEscape(query), viewer.ID, nil)
+		expectFieldError(t, rec, "q")
+	}
+	// Длина считается в символах, а не в байтах
+	if users, _ := ts.searchUsers(viewer.ID, "яб"); len(users) != 0 {
+		t.Errorf("search яб = %+v", users)
+	}
+}
+
+func TestUserSearchResultsCapped(t *testing.T) {
+	ts := newTestServer(t)
+	viewer := ts.addUser("viewer")
+	for i := 0; i < maxUserSearchResults+3; i++ {
+		ts.addUser(fmt.Sprintf("user%02d", i))
+	}
+
+	users, _ := ts.searchUsers(viewer.ID, "user")
+	if len(users) != maxUserSearchResults {
+		t.Fatalf("found %d users, want %d", len(users), maxUserSearchResults)
+	}
+	// Выдача упорядочена по имени, поэтому обрезка детерминирована
+	for i, user := range users {
+		if want := fmt.Sprintf("user%02d", i); user.Username != want {
+			t.Errorf("users[%d] = %s, want %s", i, user.Username, want)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// Ограничения на теги списков и поля элементов
+const (
+	maxTags           = 10
+	maxTagLength      = 32
+	maxCategoryLength = 50
+
+	maxExternalIDLength     = 128
+	maxExternalSourceLength = 64
+
+	maxTitleLength       = 200
+	maxItemNameLength    = 200
+	maxDescriptionLength = 2000
+
+	minPasswordLength = 8
+	// bcrypt учитывает только первые 72 байта пароля
+	maxPasswordBytes = 72
+
+	minUsernameLength = 3
+	maxUsernameLength = 32
+)
+
+// FieldError - ошибка проверки конкретного поля запроса
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// validationError формирует тело ответа для ошибки проверки,
+// добавляя имя поля или позицию ошибки в теле, если они известны
+func validationError(err error) gin.H {
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return gin.H{"error": err.Error(), "field": fieldErr.Field}
+	}
+	var bodyErr *BodyError
+	if errors.As(err, &bodyErr) && bodyErr.Offset > 0 {
+		return gin.H{"error": err.Error(), "offset": bodyErr.Offset}
+	}
+	return gin.H{"error": err.Error()}
+}
+
+// normalizeTags приводит теги к нижнему регистру, убирает пустые и повторяющиеся
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, fmt.Errorf("tag %q exceeds %d characters", tag, maxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > maxTags {
+		return nil, fmt.Errorf("too many tags, maximum is %d", maxTags)
+	}
+
+	return normalized, nil
+}
+
+// hasTag проверяет, содержит ли список тегов указанный тег
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeWishlist убирает пробелы по краям названия и проверяет длину названия и описания
+func normalizeWishlist(wishlist *model.Wishlist) error {
+	wishlist.Title = strings.TrimSpace(wishlist.Title)
+	if wishlist.Title == "" {
+		return &FieldError{Field: "title", Message: "must not be empty"}
+	}
+	if len([]rune(wishlist.Title)) > maxTitleLength {
+		return &FieldError{Field: "title", Message: fmt.Sprintf("exceeds %d characters", maxTitleLength)}
+	}
+	if len([]rune(wishlist.Description)) > maxDescriptionLength {
+		return &FieldError{Field: "description", Message: fmt.Sprintf("exceeds %d characters", maxDescriptionLength)}
+	}
+
+	return nil
+}
+
+// normalizeItem проверяет и нормализует поля элемента, переданные клиентом
+func normalizeItem(item *model.Item) error {
+	item.Name = strings.TrimSpace(stripControlChars(item.Name, false))
+	if item.Name == "" {
+		return &FieldError{Field: "name", Message: "must not be empty"}
+	}
+	if len([]rune(item.Name)) > maxItemNameLength {
+		return &FieldError{Field: "name", Message: fmt.Sprintf("exceeds %d characters", maxItemNameLength)}
+	}
+
+	item.Description = strings.TrimSpace(stripControlChars(item.Description, true))
+	if len([]rune(item.Description)) > maxDescriptionLength {
+		return &FieldError{Field: "description", Message: fmt.Sprintf("exceeds %d characters", maxDescriptionLength)}
+	}
+
+	item.Category = strings.TrimSpace(item.Category)
+	if len([]rune(item.Category)) > maxCategoryLength {
+		return &FieldError{Field: "category", Message: fmt.Sprintf("exceeds %d characters", maxCategoryLength)}
+	}
+
+	item.Price = strings.TrimSpace(item.Price)
+	if item.Price != "" {
+		if strings.HasPrefix(item.Price, "-") {
+			return &FieldError{Field: "price", Message: "must not be negative"}
+		}
+		cents, err := parsePriceCents(item.Price)
+		if err != nil {
+			return &FieldError{Field: "price", Message: strings.TrimPrefix(err.Error(), "price ")}
+		}
+		// Приводим цену к виду с двумя знаками после точки: "5" -> "5.00"
+		item.Price = formatPriceCents(cents)
+	}
+
+	item.ExternalID = strings.TrimSpace(item.ExternalID)
+	item.ExternalSource = strings.TrimSpace(item.ExternalSource)
+	if len([]rune(item.ExternalID)) > maxExternalIDLength {
+		return &FieldError{Field: "external_id", Message: fmt.Sprintf("exceeds %d characters", maxExternalIDLength)}
+	}
+	if len([]rune(item.ExternalSource)) > maxExternalSourceLength {
+		return &FieldError{Field: "external_source", Message: fmt.Sprintf("exceeds %d characters", maxExternalSourceLength)}
+	}
+	if item.ExternalSource != "" && item.ExternalID == "" {
+		return &FieldError{Field: "external_id", Message: "is required when external_source is set"}
+	}
+
+	// Ссылки сохраняются уже очищенными, чтобы клиенты могли выводить их как есть
+	var err error
+	if item.Link, err = sanitizeURL("link", item.Link); err != nil {
+		return err
+	}
+	if item.ImageURL, err = sanitizeURL("image_url", item.ImageURL); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// stripControlChars удаляет управляющие символы. В многострочном тексте (keepNewlines)
+// сохраняются переводы строк и табуляция
+func stripControlChars(text string, keepNewlines bool) string {
+	return strings.Map(func(r rune) rune {
+		if keepNewlines && (r == '\n' || r == '\t') {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// sanitizeURL проверяет ссылку так же, как validateURL, и приводит ее к безопасному виду:
+// убирает пробелы по краям и данные для входа (user:password@), приводит хост к нижнему регистру.
+// Пустое значение остается пустым.
+func sanitizeURL(field, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if err := validateURL(field, raw); err != nil || raw == "" {
+		return "", err
+	}
+
+	u, _ := url.Parse(raw)
+	u.User = nil
+	u.Host = strings.ToLower(u.Host)
+	return u.String(), nil
+}
+
+// validateURL разрешает пустое значение или абсолютный http/https адрес
+func validateURL(field, raw string) error {
+	if raw == "" {
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return &FieldError{Field: field, Message: "malformed URL"}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &FieldError{Field: field, Message: "only http and https URLs are allowed"}
+	}
+	if u.Host == "" {
+		return &FieldError{Field: field, Message: "URL must include a host"}
+	}
+
+	return nil
+}
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// normalizeUsername убирает пробелы по краям и проверяет имя пользователя: от 3 до 32 символов,
+// только латинские буквы, цифры, подчеркивание и дефис. Регистр сохраняется для отображения,
+// а уникальность и поиск идут по usernameKey.
+func normalizeUsername(field, username string) (string, error) {
+	username = strings.TrimSpace(username)
+	if len(username) < minUsernameLength || len(username) > maxUsernameLength {
+		return "", &FieldError{Field: field, Message: fmt.Sprintf("must be between %d and %d characters", minUsernameLength, maxUsernameLength)}
+	}
+	if !usernamePattern.MatchString(username) {
+		return "", &FieldError{Field: field, Message: "may contain only letters, digits, underscores and hyphens"}
+	}
+	return username, nil
+}
+
+// usernameKey - ключ имени пользователя в индексе: "Alice" и "alice" - одно и то же имя
+func usernameKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// validatePassword проверяет пароль на соответствие политике сложности:
+// длина от 8 символов и хотя бы два класса символов из
+// строчных, заглавных букв, цифр и прочих символов
+func validatePassword(field, password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return &FieldError{Field: field, Message: fmt.Sprintf("must be at least %d characters", minPasswordLength)}
+	}
+	if len(password) > maxPasswordBytes {
+		return &FieldError{Field: field, Message: fmt.Sprintf("must be at most %d bytes", maxPasswordBytes)}
+	}
+
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			classes++
+		}
+	}
+	if classes < 2 {
+		return &FieldError{Field: field, Message: "must mix at least two of lowercase, uppercase, digits and symbols"}
+	}
+
+	return nil
+}
+
+// Цена: неотрицательное десятичное число, не более двух знаков после точки
+var pricePattern = regexp.MustCompile(`^\d+(\.\d{1,2})?$`)
+
+// parsePriceCents переводит цену в копейки (минимальные единицы валюты)
+func parsePriceCents(price string) (int64, error) {
+	price = strings.TrimSpace(price)
+	if !pricePattern.MatchString(price) {
+		return 0, errors.New("price must be a non-negative decimal with at most two fractional digits")
+	}
+
+	whole, fraction, _ := strings.Cut(price, ".")
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	cents, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, errors.New("price is too large")
+	}
+	return cents, nil
+}
+
+// formatPriceCents форматирует цену в копейках как десятичное число с двумя знаками после точки
+func formatPriceCents(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
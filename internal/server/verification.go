@@ -0,0 +1,86 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// Срок действия ссылки для подтверждения email
+const verificationTTL = 24 * time.Hour
+
+// EmailVerification - токен подтверждения. Email, для которого выдан токен, хранится вместе с ним:
+// после смены адреса токен перестает действовать
+type EmailVerification struct {
+	Token     string
+	UserID    string
+	Email     string
+	ExpiresAt time.Time
+}
+
+// issueVerification создает токен подтверждения и отправляет письмо.
+// Вызывается под блокировкой mu.
+func (s *Server) issueVerification(user model.User) error {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+
+	verification := EmailVerification{
+		Token:     hex.EncodeToString(buf),
+		UserID:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: clock.Now().Add(verificationTTL),
+	}
+	s.verifications[verification.Token] = verification
+
+	// Письмо отправляется асинхронно, чтобы не держать блокировку на время отправки
+	link := baseURL + "/auth/verify?token=" + url.QueryEscape(verification.Token)
+	go sendMail(s.mailer, user.Email, "Confirm your email", "Open the link to confirm your email: "+link)
+	return nil
+}
+
+// revokeVerifications удаляет все токены подтверждения пользователя.
+// Вызывается под блокировкой mu.
+func (s *Server) revokeVerifications(userID string) {
+	for token, verification := range s.verifications {
+		if verification.UserID == userID {
+			delete(s.verifications, token)
+		}
+	}
+}
+
+func (s *Server) verifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	verification, exists := s.verifications[token]
+	if !exists || !clock.Now().Before(verification.ExpiresAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired token"})
+		return
+	}
+
+	user, exists := s.users[verification.UserID]
+	if !exists || user.Email != verification.Email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired token"})
+		return
+	}
+
+	// Повторное подтверждение тем же токеном ничего не меняет
+	user.EmailVerified = true
+	s.users[user.ID] = user
+
+	c.JSON(http.StatusOK, gin.H{"email_verified": true})
+}
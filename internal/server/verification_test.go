@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var verifyLinkPattern = regexp.MustCompile(`/auth/verify\?token=(\S+)`)
+
+// verificationToken читает токен из последнего письма адресату через /debug/mail.
+// Письмо отправляется в фоне, поэтому его ждем. У каждого тестового хранилища свой ящик logMailer
+func (ts *testServer) verificationToken(email string) string {
+	ts.t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		req := ts.newRequest(http.MethodGet, "/debug/mail?to="+url.QueryEscape(email), "", nil)
+		req.RemoteAddr = "127.0.0.1:1234"
+		rec := ts.serve(req)
+		expectStatus(ts.t, rec, http.StatusOK)
+
+		var mails []SentMail
+		decodeBody(ts.t, rec, &mails)
+		for i := len(mails) - 1; i >= 0; i-- {
+			if match := verifyLinkPattern.FindStringSubmatch(mails[i].Body); match != nil {
+				token, err := url.QueryUnescape(match[1])
+				if err != nil {
+					ts.t.Fatalf("unescape token: %v", err)
+				}
+				return token
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ts.t.Fatalf("no verification email for %s", email)
+	return ""
+}
+
+func (ts *testServer) emailVerified(userID string) bool {
+	ts.srv.mu.RLock()
+	defer ts.srv.mu.RUnlock()
+	return ts.srv.users[userID].EmailVerified
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.register("verify-ok")
+	if user.EmailVerified {
+		t.Fatal("email is verified right after registration")
+	}
+
+	token := ts.verificationToken(user.Email)
+	rec := ts.do(http.MethodGet, "/auth/verify?token="+url.QueryEscape(token), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if !ts.emailVerified(user.ID) {
+		t.Error("email is not verified after following the link")
+	}
+
+	// Повторный переход по ссылке не ошибка и ничего не меняет
+	rec = ts.do(http.MethodGet, "/auth/verify?token="+url.QueryEscape(token), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if !ts.emailVerified(user.ID) {
+		t.Error("repeated verification reset the flag")
+	}
+}
+
+func TestVerifyEmailInvalidToken(t *testing.T) {
+	ts := newTestServer(t)
+	user := ts.register("verify-bad")
+
+	rec := ts.do(http.MethodGet, "/auth/verify?token=not-a-token", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = ts.do(http.MethodGet, "/auth/verify", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	if ts.emailVerified(user.ID) {
+		t.Error("invalid token verified the email")
+	}
+}
+
+func TestUnverifiedUserCannotShare(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.register("verify-share")
+	friend := ts.addUser("friend")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+wishlist.ID+"/share", owner.ID, gin.H{"shared_user_id": friend.ID})
+	expectStatus(t, rec, http.StatusForbidden)
+
+	token := ts.verificationToken(owner.Email)
+	expectStatus(t, ts.do(http.MethodGet, "/auth/verify?token="+url.QueryEscape(token), "", nil), http.StatusOK)
+	ts.share(owner.ID, wishlist.ID, friend.ID, false)
+}
+
+func TestDevMailRequiresLoopback(t *testing.T) {
+	ts := newTestServer(t)
+
+	rec := ts.do(http.MethodGet, "/debug/mail?to=someone@example.com", "", nil)
+	expectStatus(t, rec, http.StatusForbidden)
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"wana/internal/model"
+)
+
+func TestAPIPrefixesServeSameResources(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	for _, path := range []string{"/api/v1/wishlists/" + wishlist.ID, "/api/wishlists/" + wishlist.ID} {
+		rec := ts.do(http.MethodGet, path, owner.ID, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var fetched model.Wishlist
+		decodeBody(t, rec, &fetched)
+		if fetched.ID != wishlist.ID || fetched.Title != "Birthday" {
+			t.Errorf("GET %s = %+v", path, fetched)
+		}
+
+		// Авторизация обязательна под обоими префиксами
+		expectStatus(t, ts.do(http.MethodGet, path, "", nil), http.StatusUnauthorized)
+	}
+}
+
+func TestDeprecatedAliasHeaders(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	rec := ts.do(http.MethodGet, "/api/wishlists/"+wishlist.ID+"/items", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("alias Deprecation = %q, want \"true\"", got)
+	}
+	if got, want := rec.Header().Get("Link"), `</api/v1/wishlists/`+wishlist.ID+`/items>; rel="successor-version"`; got != want {
+		t.Errorf("alias Link = %q, want %q", got, want)
+	}
+
+	// Ответ на ошибку авторизации тоже предупреждает об устаревшем пути
+	rec = ts.do(http.MethodGet, "/api/wishlists", "", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+	if rec.Header().Get("Deprecation") != "true" {
+		t.Error("unauthorized alias response has no Deprecation header")
+	}
+
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+wishlist.ID+"/items", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	if rec.Header().Get("Deprecation") != "" || rec.Header().Get("Link") != "" {
+		t.Errorf("v1 response has deprecation headers: %v", rec.Header())
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// Параметры доставки вебхуков
+const (
+	webhookTimeout     = 5 * time.Second
+	webhookMaxAttempts = 3
+	webhookRetryDelay  = time.Second
+)
+
+// Заголовок с HMAC-SHA256 подписью тела запроса
+const webhookSignatureHeader = "X-Wishlist-Signature"
+
+var webhookClient = &http.Client{
+	Timeout:   webhookTimeout,
+	Transport: externalTransport,
+}
+
+// WebhookEvent - тело запроса, отправляемого на вебхук пользователя
+type WebhookEvent struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Payload   interface{} `json:"payload"`
+}
+
+func (s *Server) setWebhook(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	var request struct {
+		URL string `json:"url" binding:"required"`
+	}
+
+	if err := bindStrictJSON(c, &request); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	if err := validateURL("url", request.URL); err != nil {
+		c.JSON(http.StatusBadRequest, validationError(err))
+		return
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate secret"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.users[userID]
+	user.WebhookURL = request.URL
+	user.WebhookSecret = secret
+	s.users[userID] = user
+
+	// Секрет возвращается только при настройке вебхука
+	c.JSON(http.StatusOK, gin.H{
+		"url":    user.WebhookURL,
+		"secret": user.WebhookSecret,
+	})
+}
+
+func (s *Server) deleteWebhook(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.users[userID]
+	user.WebhookURL = ""
+	user.WebhookSecret = ""
+	s.users[userID] = user
+
+	c.Status(http.StatusNoContent)
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// signWebhookBody возвращает подпись тела в формате "sha256=<hex>"
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// emitWebhook ставит событие в очередь на доставку, если у пользователя настроен вебхук.
+// Вызывается под блокировкой mu, сама доставка выполняется асинхронно.
+func (s *Server) emitWebhook(userID, event string, payload interface{}) {
+	queueWebhook(s.users[userID], event, payload)
+}
+
+// queueWebhook ставит событие в очередь на доставку по вебхуку пользователя user.
+// Хранилища не использует, поэтому вызывается и после освобождения mu
+// с копией пользователя, сделанной под блокировкой.
+func queueWebhook(user model.User, event string, payload interface{}) {
+	if user.WebhookURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(WebhookEvent{
+		Event:     event,
+		CreatedAt: clock.Now(),
+		Payload:   payload,
+	})
+	if err != nil {
+		log.Printf("webhook: could not encode %s event: %v", event, err)
+		return
+	}
+
+	go deliverWebhook(user.WebhookURL, signWebhookBody(user.WebhookSecret, body), body)
+}
+
+// deliverWebhook отправляет событие с ограниченным числом повторов.
+// Повтор выполняется при сетевой ошибке или ответе 5xx.
+func deliverWebhook(url, signature string, body []byte) {
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			log.Printf("webhook: invalid request to %s: %v", url, err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(webhookSignatureHeader, signature)
+
+		resp, err := webhookClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 500 {
+				return
+			}
+			err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+
+		log.Printf("webhook: attempt %d/%d to %s failed: %v", attempt, webhookMaxAttempts, url, err)
+		if attempt < webhookMaxAttempts {
+			time.Sleep(webhookRetryDelay * time.Duration(attempt))
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+// webhookDelivery - запрос, полученный тестовым получателем вебхуков
+type webhookDelivery struct {
+	signature string
+	body      []byte
+}
+
+// newWebhookReceiver запускает получателя, который отвечает статусами из statuses по очереди
+// (последний повторяется) и передает полученные запросы в канал
+func newWebhookReceiver(t *testing.T, statuses ...int) (*httptest.Server, <-chan webhookDelivery) {
+	t.Helper()
+	deliveries := make(chan webhookDelivery, 10)
+	var calls int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- webhookDelivery{signature: r.Header.Get(webhookSignatureHeader), body: body}
+
+		n := int(atomic.AddInt32(&calls, 1))
+		if n > len(statuses) {
+			n = len(statuses)
+		}
+		w.WriteHeader(statuses[n-1])
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver, deliveries
+}
+
+func waitDelivery(t *testing.T, deliveries <-chan webhookDelivery) webhookDelivery {
+	t.Helper()
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+		return webhookDelivery{}
+	}
+}
+
+// reserveSetup создает владельца с вебхуком, список с элементом и гостя с доступом к нему
+func reserveSetup(t *testing.T, ts *testServer, hookURL string) (secret string, guest model.User, item model.Item) {
+	t.Helper()
+	owner := ts.addUser("owner")
+	guest = ts.addUser("guest")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	item = ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Kite"})
+	ts.share(owner.ID, wishlist.ID, guest.ID, false)
+
+	rec := ts.do(http.MethodPut, "/api/v1/account/webhook", owner.ID, gin.H{"url": hookURL})
+	expectStatus(t, rec, http.StatusOK)
+	var hook struct {
+		Secret string `json:"secret"`
+	}
+	decodeBody(t, rec, &hook)
+	return hook.Secret, guest, item
+}
+
+func TestReserveWebhookIsSigned(t *testing.T) {
+	receiver, deliveries := newWebhookReceiver(t, http.StatusOK)
+	ts := newTestServer(t)
+	secret, guest, item := reserveSetup(t, ts, receiver.URL)
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+item.WishlistID+"/items/"+item.ID+"/reserve", guest.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	delivery := waitDelivery(t, deliveries)
+	if !hmac.Equal([]byte(delivery.signature), []byte(signWebhookBody(secret, delivery.body))) {
+		t.Errorf("signature %q does not match the body", delivery.signature)
+	}
+
+	var event struct {
+		Event   string     `json:"event"`
+		Payload model.Item `json:"payload"`
+	}
+	if err := json.Unmarshal(delivery.body, &event); err != nil {
+		t.Fatalf("decode webhook body: %v", err)
+	}
+	if event.Event != "item.reserved" || event.Payload.ID != item.ID || event.Payload.ReservedBy != guest.ID {
+		t.Errorf("webhook event = %+v", event)
+	}
+}
+
+func TestWebhookRetriedAfterServerError(t *testing.T) {
+	receiver, deliveries := newWebhookReceiver(t, http.StatusInternalServerError, http.StatusOK)
+	ts := newTestServer(t)
+	_, guest, item := reserveSetup(t, ts, receiver.URL)
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+item.WishlistID+"/items/"+item.ID+"/reserve", guest.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	first := waitDelivery(t, deliveries)
+	second := waitDelivery(t, deliveries)
+	if string(first.body) != string(second.body) || first.signature != second.signature {
+		t.Errorf("retry differs from the first attempt")
+	}
+
+	// После успешного ответа повторов больше нет
+	select {
+	case <-deliveries:
+		t.Error("webhook delivered again after a 200 response")
+	case <-time.After(2*webhookRetryDelay + 500*time.Millisecond):
+	}
+}
+
+func TestRepeatedReserveDoesNotEmitWebhook(t *testing.T) {
+	receiver, deliveries := newWebhookReceiver(t, http.StatusOK)
+	ts := newTestServer(t)
+	_, guest, item := reserveSetup(t, ts, receiver.URL)
+	path := "/api/v1/wishlists/" + item.WishlistID + "/items/" + item.ID + "/reserve"
+
+	expectStatus(t, ts.do(http.MethodPost, path, guest.ID, nil), http.StatusOK)
+	waitDelivery(t, deliveries)
+
+	expectStatus(t, ts.do(http.MethodPost, path, guest.ID, nil), http.StatusOK)
+	select {
+	case <-deliveries:
+		t.Error("repeated reservation emitted a webhook")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,480 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"wana/internal/model"
+)
+
+func TestDuplicateWishlistIsIndependent(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	friend := ts.addUser("friend")
+	original := ts.createWishlist(owner.ID, "Holidays")
+	item := ts.addItem(owner.ID, original.ID, gin.H{"name": "Skis"})
+	ts.share(owner.ID, original.ID, friend.ID, true)
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+original.ID+"/duplicate", owner.ID, nil)
+	expectStatus(t, rec, http.StatusCreated)
+	var copied model.Wishlist
+	decodeBody(t, rec, &copied)
+
+	if copied.ID == original.ID || copied.UserID != owner.ID || copied.Title != "Holidays (copy)" {
+		t.Fatalf("duplicate = %+v", copied)
+	}
+
+	copiedItems := ts.items(owner.ID, copied.ID)
+	if len(copiedItems) != 1 || copiedItems[0].ID == item.ID || copiedItems[0].Name != "Skis" {
+		t.Fatalf("duplicate items = %+v", copiedItems)
+	}
+
+	// Правка копии не затрагивает оригинал
+	edited := copiedItems[0]
+	edited.Name = "Snowboard"
+	rec = ts.do(http.MethodPut, "/api/v1/wishlists/"+copied.ID+"/items/"+edited.ID, owner.ID, edited)
+	expectStatus(t, rec, http.StatusOK)
+	if got := ts.items(owner.ID, original.ID); got[0].Name != "Skis" {
+		t.Errorf("original item renamed to %q", got[0].Name)
+	}
+
+	// Доступ друга к оригиналу на копию не переносится
+	rec = ts.do(http.MethodGet, "/api/v1/wishlists/"+copied.ID, friend.ID, nil)
+	if rec.Code == http.StatusOK {
+		t.Errorf("friend can read the duplicate")
+	}
+}
+
+func TestDuplicateWishlistResetsPurchased(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	original := ts.createWishlist(owner.ID, "Holidays")
+	item := ts.addItem(owner.ID, original.ID, gin.H{"name": "Skis"})
+
+	item.IsPurchased = true
+	rec := ts.do(http.MethodPut, "/api/v1/wishlists/"+original.ID+"/items/"+item.ID, owner.ID, item)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/"+original.ID+"/duplicate", owner.ID, nil)
+	expectStatus(t, rec, http.StatusCreated)
+	var copied model.Wishlist
+	decodeBody(t, rec, &copied)
+
+	if got := ts.items(owner.ID, copied.ID); len(got) != 1 || got[0].IsPurchased {
+		t.Errorf("duplicate items = %+v, want one unpurchased item", got)
+	}
+	if got := ts.items(owner.ID, original.ID); !got[0].IsPurchased {
+		t.Errorf("original item lost its purchased flag")
+	}
+}
+
+// wishlistTitles возвращает названия списков из ответа GET /wishlists
+func wishlistTitles(t *testing.T, ts *testServer, token, query string) map[string]bool {
+	t.Helper()
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists"+query, token, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var summaries []model.WishlistSummary
+	decodeBody(t, rec, &summaries)
+	titles := map[string]bool{}
+	for _, summary := range summaries {
+		titles[summary.Title] = true
+	}
+	return titles
+}
+
+func TestArchivedWishlists(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	ts.createWishlist(owner.ID, "Current")
+	old := ts.createWishlist(owner.ID, "Last year")
+	item := ts.addItem(owner.ID, old.ID, gin.H{"name": "Scarf"})
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists/"+old.ID+"/archive", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	if titles := wishlistTitles(t, ts, owner.ID, ""); titles["Last year"] || !titles["Current"] {
+		t.Errorf("default listing = %v, want archived list excluded", titles)
+	}
+	if titles := wishlistTitles(t, ts, owner.ID, "?include_archived=true"); !titles["Last year"] || !titles["Current"] {
+		t.Errorf("listing with include_archived = %v, want both lists", titles)
+	}
+
+	// Элементы архивного списка менять нельзя, пока его не вернут из архива
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/"+old.ID+"/items", owner.ID, gin.H{"name": "Gloves"})
+	expectStatus(t, rec, http.StatusConflict)
+	var body map[string]string
+	decodeBody(t, rec, &body)
+	if body["error"] != "wishlist is archived, unarchive it to edit items" {
+		t.Errorf("error = %q", body["error"])
+	}
+
+	item.Name = "Wool scarf"
+	rec = ts.do(http.MethodPut, "/api/v1/wishlists/"+old.ID+"/items/"+item.ID, owner.ID, item)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists/"+old.ID+"/unarchive", owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	ts.addItem(owner.ID, old.ID, gin.H{"name": "Gloves"})
+}
+
+func TestArchivedWishlistsInvalidFlag(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists?include_archived=maybe", owner.ID, nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestWishlistTagsAreNormalized(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, gin.H{
+		"title": "Reading",
+		"tags":  []string{" Books ", "books", "", "SciFi"},
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var wishlist model.Wishlist
+	decodeBody(t, rec, &wishlist)
+
+	if len(wishlist.Tags) != 2 || wishlist.Tags[0] != "books" || wishlist.Tags[1] != "scifi" {
+		t.Errorf("tags = %q, want [books scifi]", wishlist.Tags)
+	}
+}
+
+func TestWishlistTagFilter(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	for title, tags := range map[string][]string{
+		"Reading": {"books"},
+		"Kitchen": {"home"},
+		"Mixed":   {"home", "books"},
+	} {
+		rec := ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, gin.H{"title": title, "tags": tags})
+		expectStatus(t, rec, http.StatusCreated)
+	}
+
+	titles := wishlistTitles(t, ts, owner.ID, "?tag=BOOKS")
+	if len(titles) != 2 || !titles["Reading"] || !titles["Mixed"] {
+		t.Errorf("tag=BOOKS = %v, want Reading and Mixed", titles)
+	}
+	if titles := wishlistTitles(t, ts, owner.ID, ""); len(titles) != 3 {
+		t.Errorf("unfiltered = %v, want all three", titles)
+	}
+}
+
+func TestWishlistTagLimits(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	tooMany := make([]string, maxTags+1)
+	for i := range tooMany {
+		tooMany[i] = string(rune('a' + i))
+	}
+	rec := ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, gin.H{"title": "Many", "tags": tooMany})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	tooLong := strings.Repeat("x", maxTagLength+1)
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, gin.H{"title": "Long", "tags": []string{tooLong}})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	// Ровно на границе теги принимаются
+	rec = ts.do(http.MethodPost, "/api/v1/wishlists", owner.ID, gin.H{
+		"title": "Edge",
+		"tags":  append(tooMany[:maxTags-1], strings.Repeat("y", maxTagLength)),
+	})
+	expectStatus(t, rec, http.StatusCreated)
+}
+
+// getWishlistIfNoneMatch запрашивает список с заголовком If-None-Match
+func (ts *testServer) getWishlistIfNoneMatch(token, wishlistID, etag string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	req := ts.newRequest(http.MethodGet, "/api/v1/wishlists/"+wishlistID, token, nil)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	return ts.serve(req)
+}
+
+func TestWishlistConditionalGet(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	rec := ts.getWishlistIfNoneMatch(owner.ID, wishlist.ID, "")
+	expectStatus(t, rec, http.StatusOK)
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("response has no ETag")
+	}
+
+	rec = ts.getWishlistIfNoneMatch(owner.ID, wishlist.ID, etag)
+	expectStatus(t, rec, http.StatusNotModified)
+	if rec.Body.Len() != 0 {
+		t.Errorf("304 response has a body: %s", rec.Body.String())
+	}
+
+	// Слабый ETag и список кандидатов тоже совпадают
+	rec = ts.getWishlistIfNoneMatch(owner.ID, wishlist.ID, `"other", W/`+etag)
+	expectStatus(t, rec, http.StatusNotModified)
+}
+
+func TestWishlistETagChangesWithItems(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+
+	etag := ts.getWishlistIfNoneMatch(owner.ID, wishlist.ID, "").Header().Get("ETag")
+	item := ts.addItem(owner.ID, wishlist.ID, gin.H{"name": "Book"})
+
+	rec := ts.getWishlistIfNoneMatch(owner.ID, wishlist.ID, etag)
+	expectStatus(t, rec, http.StatusOK)
+	afterAdd := rec.Header().Get("ETag")
+	if afterAdd == etag {
+		t.Fatalf("ETag %s did not change after an item was added", etag)
+	}
+
+	// Изменение элемента тоже меняет ETag списка
+	item.Name = "Book, 2nd edition"
+	expectStatus(t, ts.do(http.MethodPut, "/api/v1/wishlists/"+wishlist.ID+"/items/"+item.ID, owner.ID, item), http.StatusOK)
+	rec = ts.getWishlistIfNoneMatch(owner.ID, wishlist.ID, afterAdd)
+	expectStatus(t, rec, http.StatusOK)
+	if rec.Header().Get("ETag") == afterAdd {
+		t.Errorf("ETag %s did not change after an item was updated", afterAdd)
+	}
+}
+
+func TestUpdateWishlistVersion(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	wishlist := ts.createWishlist(owner.ID, "Birthday")
+	if wishlist.Version != 1 {
+		t.Fatalf("new wishlist version = %d, want 1", wishlist.Version)
+	}
+	path := "/api/v1/wishlists/" + wishlist.ID
+
+	rec := ts.do(http.MethodPut, path, owner.ID, gin.H{"title": "Birthday 30", "version": 1})
+	expectStatus(t, rec, http.StatusOK)
+	var updated model.Wishlist
+	decodeBody(t, rec, &updated)
+	if updated.Version != 2 || updated.Title != "Birthday 30" {
+		t.Fatalf("updated wishlist = %+v, want version 2", updated)
+	}
+
+	// Изменение по устаревшей версии отклоняется и не применяется
+	rec = ts.do(http.MethodPut, path, owner.ID, gin.H{"title": "Stale", "version": 1})
+	expectStatus(t, rec, http.StatusConflict)
+	var conflict struct {
+		Code    string `json:"code"`
+		Version int    `json:"version"`
+	}
+	decodeBody(t, rec, &conflict)
+	if conflict.Code != "VERSION_CONFLICT" || conflict.Version != 2 {
+		t.Errorf("conflict = %+v, want VERSION_CONFLICT with version 2", conflict)
+	}
+
+	// Версию можно передать в If-Match
+	req := ts.newRequest(http.MethodPut, path, owner.ID, gin.H{"title": "Birthday 31"})
+	req.Header.Set("If-Match", `"2"`)
+	expectStatus(t, ts.serve(req), http.StatusOK)
+
+	expectStatus(t, ts.do(http.MethodPut, path, owner.ID, gin.H{"title": "No version"}), http.StatusPreconditionRequired)
+
+	rec = ts.do(http.MethodGet, path, owner.ID, nil)
+	expectStatus(t, rec, http.StatusOK)
+	var stored model.Wishlist
+	decodeBody(t, rec, &stored)
+	if stored.Title != "Birthday 31" || stored.Version != 3 {
+		t.Errorf("stored wishlist = %q version %d, want \"Birthday 31\" version 3", stored.Title, stored.Version)
+	}
+}
+
+func TestCreateWishlistLocation(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	// Адрес канонический, даже если список создан через устаревший путь без версии
+	for _, path := range []string{"/api/v1/wishlists", "/api/wishlists"} {
+		rec := ts.do(http.MethodPost, path, owner.ID, gin.H{"title": "Birthday"})
+		expectStatus(t, rec, http.StatusCreated)
+		var created model.Wishlist
+		decodeBody(t, rec, &created)
+
+		location := rec.Header().Get("Location")
+		if want := "/api/v1/wishlists/" + created.ID; location != want {
+			t.Fatalf("POST %s: Location = %q, want %q", path, location, want)
+		}
+
+		rec = ts.do(http.MethodGet, location, owner.ID, nil)
+		expectStatus(t, rec, http.StatusOK)
+		var fetched model.Wishlist
+		decodeBody(t, rec, &fetched)
+		if fetched.ID != created.ID {
+			t.Errorf("GET %s returned wishlist %s", location, fetched.ID)
+		}
+	}
+}
+
+// wishlistSummaries возвращает сводки списков пользователя по названию
+func (ts *testServer) wishlistSummaries(token string) map[string]model.WishlistSummary {
+	ts.t.Helper()
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists", token, nil)
+	expectStatus(ts.t, rec, http.StatusOK)
+
+	var summaries []model.WishlistSummary
+	decodeBody(ts.t, rec, &summaries)
+	byTitle := map[string]model.WishlistSummary{}
+	for _, summary := range summaries {
+		byTitle[summary.Title] = summary
+	}
+	return byTitle
+}
+
+func TestWishlistSummaryCounts(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+	home := ts.createWishlist(owner.ID, "Home")
+	ts.createWishlist(owner.ID, "Empty")
+
+	lamp := ts.addItem(owner.ID, home.ID, gin.H{"name": "Lamp"})
+	ts.addItem(owner.ID, home.ID, gin.H{"name": "Book"})
+	ts.addItem(owner.ID, home.ID, gin.H{"name": "Mug"})
+
+	summaries := ts.wishlistSummaries(owner.ID)
+	if got := summaries["Home"]; got.ItemCount != 3 || got.PurchasedCount != 0 {
+		t.Errorf("Home counts = %d/%d, want 3/0", got.ItemCount, got.PurchasedCount)
+	}
+	if got := summaries["Empty"]; got.ItemCount != 0 || got.PurchasedCount != 0 {
+		t.Errorf("Empty counts = %d/%d, want 0/0", got.ItemCount, got.PurchasedCount)
+	}
+
+	lamp.IsPurchased = true
+	expectStatus(t, ts.do(http.MethodPut, "/api/v1/wishlists/"+home.ID+"/items/"+lamp.ID, owner.ID, lamp), http.StatusOK)
+	if got := ts.wishlistSummaries(owner.ID)["Home"]; got.ItemCount != 3 || got.PurchasedCount != 1 {
+		t.Errorf("Home counts after purchase = %d/%d, want 3/1", got.ItemCount, got.PurchasedCount)
+	}
+
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+home.ID+"/items/"+lamp.ID, owner.ID, nil), http.StatusNoContent)
+	if got := ts.wishlistSummaries(owner.ID)["Home"]; got.ItemCount != 2 || got.PurchasedCount != 0 {
+		t.Errorf("Home counts after delete = %d/%d, want 2/0", got.ItemCount, got.PurchasedCount)
+	}
+}
+
+func TestWishlistsFilteredByCreationDate(t *testing.T) {
+	fake := useFakeClock(t)
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	ts.createWishlist(owner.ID, "First")
+	fake.Advance(24 * time.Hour)
+	second := ts.createWishlist(owner.ID, "Second")
+	fake.Advance(24 * time.Hour)
+	third := ts.createWishlist(owner.ID, "Third")
+
+	// created_after включает границу, created_before - нет
+	after := second.CreatedAt.Format(time.RFC3339)
+	before := third.CreatedAt.Format(time.RFC3339)
+	titles := wishlistTitles(t, ts, owner.ID, "?created_after="+after+"&created_before="+before)
+	if len(titles) != 1 || !titles["Second"] {
+		t.Errorf("bounded range = %v, want Second", titles)
+	}
+
+	if titles := wishlistTitles(t, ts, owner.ID, "?created_after="+after); len(titles) != 2 || !titles["Second"] || !titles["Third"] {
+		t.Errorf("created_after = %v, want Second and Third", titles)
+	}
+	if titles := wishlistTitles(t, ts, owner.ID, "?created_before="+after); len(titles) != 1 || !titles["First"] {
+		t.Errorf("created_before = %v, want First", titles)
+	}
+}
+
+func TestWishlistCreationDateValidation(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.addUser("owner")
+
+	for query, message := range map[string]string{
+		"?created_after=2024-03-01": "created_after must be an RFC3339 timestamp",
+		"?created_before=yesterday": "created_before must be an RFC3339 timestamp",
+		"?created_after=2024-03-02T00:00:00Z&created_before=2024-03-01T00:00:00Z": "created_after must be earlier than created_before",
+	} {
+		rec := ts.do(http.MethodGet, "/api/v1/wishlists"+query, owner.ID, nil)
+		expectStatus(t, rec, http.StatusBadRequest)
+		var body map[string]string
+		decodeBody(t, rec, &body)
+		if body["error"] != message {
+			t.Errorf("%s: error = %q, want %q", query, body["error"], message)
+		}
+	}
+}
+
+// wishlistBatch запрашивает списки по ID через параметр ids
+func (ts *testServer) wishlistBatch(token string, ids ...string) model.WishlistBatch {
+	ts.t.Helper()
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists?ids="+strings.Join(ids, ","), token, nil)
+	expectStatus(ts.t, rec, http.StatusOK)
+
+	var batch model.WishlistBatch
+	decodeBody(ts.t, rec, &batch)
+	return batch
+}
+
+func TestWishlistBatchMixedAccess(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+	bob := ts.addUser("bob")
+	own := ts.createWishlist(alice.ID, "Own")
+	ts.addItem(alice.ID, own.ID, gin.H{"name": "Lamp"})
+	shared := ts.createWishlist(bob.ID, "Shared")
+	ts.share(bob.ID, shared.ID, alice.ID, false)
+	private := ts.createWishlist(bob.ID, "Private")
+	trashed := ts.createWishlist(alice.ID, "Trashed")
+	expectStatus(t, ts.do(http.MethodDelete, "/api/v1/wishlists/"+trashed.ID, alice.ID, nil), http.StatusNoContent)
+
+	// Порядок ответа следует порядку запроса, повторы отбрасываются
+	batch := ts.wishlistBatch(alice.ID, shared.ID, private.ID, own.ID, "missing", trashed.ID, own.ID)
+	var titles []string
+	for _, summary := range batch.Wishlists {
+		titles = append(titles, summary.Title)
+	}
+	if strings.Join(titles, ",") != "Shared,Own" {
+		t.Errorf("wishlists = %v, want Shared,Own", titles)
+	}
+	if strings.Join(batch.Denied, ",") != private.ID+",missing,"+trashed.ID {
+		t.Errorf("denied = %v", batch.Denied)
+	}
+	if len(batch.Wishlists) == 2 && batch.Wishlists[1].ItemCount != 1 {
+		t.Errorf("own wishlist item count = %d, want 1", batch.Wishlists[1].ItemCount)
+	}
+
+	// Без ids выдается обычный постраничный список
+	if titles := wishlistTitles(t, ts, alice.ID, ""); len(titles) != 1 || !titles["Own"] {
+		t.Errorf("wishlists without ids = %v", titles)
+	}
+}
+
+func TestWishlistBatchCap(t *testing.T) {
+	previous := maxBatchIDs
+	maxBatchIDs = 3
+	t.Cleanup(func() { maxBatchIDs = previous })
+	ts := newTestServer(t)
+	alice := ts.addUser("alice")
+
+	if batch := ts.wishlistBatch(alice.ID, "a", "b", "c", "a"); len(batch.Denied) != 3 {
+		t.Errorf("denied = %v, want three distinct ids", batch.Denied)
+	}
+
+	rec := ts.do(http.MethodGet, "/api/v1/wishlists?ids=a,b,c,d", alice.ID, nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+	var body map[string]interface{}
+	decodeBody(t, rec, &body)
+	if body["limit"] != float64(3) {
+		t.Errorf("body = %v, want limit 3", body)
+	}
+
+	expectStatus(t, ts.do(http.MethodGet, "/api/v1/wishlists?ids=,,", alice.ID, nil), http.StatusBadRequest)
+}